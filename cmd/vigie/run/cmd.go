@@ -3,9 +3,12 @@ package run
 import (
 	"fmt"
 	"github.com/asaskevich/govalidator"
+	"github.com/vincoll/vigie/pkg/alertmanager"
 	"github.com/vincoll/vigie/pkg/core"
 	"github.com/vincoll/vigie/pkg/ha"
 	"github.com/vincoll/vigie/pkg/load"
+	"github.com/vincoll/vigie/pkg/maintenance"
+	"github.com/vincoll/vigie/pkg/pause"
 	"github.com/vincoll/vigie/pkg/promexporter"
 	"github.com/vincoll/vigie/pkg/tsdb"
 	"github.com/vincoll/vigie/pkg/utils/dnscache"
@@ -155,11 +158,55 @@ var Cmd = &cobra.Command{
 		}
 
 		//
-		// Init AlertManager                                      DEPRECATED
+		// Init AlertManager
 		//
 
 		if vigieConf.Alerting.Enable {
-			// go alertmanager.InitAlertManager(vigieConf.Alerting, vigieInstance.HostInfo.Name, vigieInstance.HostInfo.URL)
+			err = alertmanager.InitAlertManager(vigieConf.Alerting, vigieInstance.HostInfo.Name, vigieInstance.HostInfo.URL)
+			if err != nil {
+				utils.Log.WithFields(logrus.Fields{"component": "alerting", "status": "failed", "error": err}).Fatal("[ConfAlerting] fail to init the alerting.")
+				os.Exit(1)
+			}
+		}
+
+		//
+		// Global maintenance windows
+		//
+
+		err = maintenance.InitManager(vigieConf.Maintenance)
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{"component": "maintenance", "status": "failed", "error": err}).Fatal("[ConfMaintenance] invalid maintenance window.")
+			os.Exit(1)
+		}
+
+		//
+		// Pauses kept across restarts
+		//
+
+		err = pause.InitManager(vigieConf.Pause)
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{"component": "pause", "status": "failed", "error": err}).Fatal("[ConfPause] fail to load the pauses.")
+			os.Exit(1)
+		}
+
+		//
+		// Scheduler: workers running the tests
+		//
+
+		err = vigieInstance.InitScheduler(vigieConf.Scheduler)
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{"component": "scheduler", "status": "failed", "error": err}).Fatal("[ConfScheduler] invalid scheduler.")
+			os.Exit(1)
+		}
+
+		//
+		// State store: state of the TestSteps across restarts
+		//
+
+		err = vigieInstance.InitStore(vigieConf.Store)
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{"component": "store", "status": "failed", "error": err}).Fatal("[ConfStore] fail to load the state.")
+			os.Exit(1)
 		}
 
 		//
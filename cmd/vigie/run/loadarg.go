@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/spf13/viper"
+	"github.com/vincoll/vigie/pkg/secret"
 )
 
 func loadVigieConfigFile(confpath string) (vc VigieConf) {
@@ -35,6 +36,17 @@ func loadVigieConfigFile(confpath string) (vc VigieConf) {
 
 	applyEnvironment(&vc)
 
+	// secret:// references are replaced by their values
+	if err := secret.ResolveStruct(&vc); err != nil {
+		fmt.Println("Couldn't resolve a secret of the config:", err)
+		os.Exit(1)
+	}
+
+	// --env: the environment variables are available in the templates
+	if withEnv {
+		vc.Import.Variables.Fromenv = true
+	}
+
 	return vc
 }
 
@@ -5,6 +5,10 @@ import (
 	"github.com/vincoll/vigie/pkg/alertmanager"
 	"github.com/vincoll/vigie/pkg/ha"
 	"github.com/vincoll/vigie/pkg/load"
+	"github.com/vincoll/vigie/pkg/maintenance"
+	"github.com/vincoll/vigie/pkg/pause"
+	"github.com/vincoll/vigie/pkg/scheduler"
+	"github.com/vincoll/vigie/pkg/store"
 	"github.com/vincoll/vigie/pkg/vigie"
 	"os"
 	"path/filepath"
@@ -32,6 +36,10 @@ type VigieConf struct {
 	Warp10      tsdb.ConfWarp10
 	Datadog     tsdb.ConfDatadog
 	Alerting    alertmanager.ConfAlerting
+	Maintenance maintenance.ConfMaintenance
+	Pause       pause.ConfPause
+	Scheduler   scheduler.ConfScheduler
+	Store       store.ConfStore
 	Log         utils.LogConf
 }
 
@@ -28,5 +28,6 @@ require (
 	golang.org/x/net v0.0.0-20201031054903-ff519b6c9102
 	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
 	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df
+	gopkg.in/yaml.v3 v3.0.1
 
 )
@@ -19,10 +19,19 @@ type AlertManager struct {
 	email             email
 	alrtList          alrtList
 	hooks             map[string]hook
+	routes            map[string]route // Severities sent to each hook
 }
 
+// route tells which TestSteps are sent to a hook.
+type route struct {
+	critical bool
+	warning  bool
+}
+
+// alrtList holds the views of the TestSteps failing, taken when they are added:
+// the alerting never reads the TestSuites while they are updated.
 type alrtList struct {
-	Testsuites map[uint64]teststruct.TestSuite
+	Testsuites map[uint64]teststruct.TSAlertShort
 	anyChanges bool
 }
 
@@ -42,7 +51,21 @@ var AM AlertManager
 
 func InitAlertManager(vConfAlerting ConfAlerting, vigieInstName, vigieURL string) error {
 
+	if err := AM.configure(vConfAlerting, vigieInstName, vigieURL); err != nil {
+		return err
+	}
+
+	go AM.run()
+
+	return nil
+}
+
+// configure sets the AlertManager up under its lock, released on every path.
+func (am *AlertManager) configure(vConfAlerting ConfAlerting, vigieInstName, vigieURL string) error {
+
 	AM.Lock()
+	defer AM.Unlock()
+
 	AM.Enable = vConfAlerting.Enable
 	AM.vigieInstanceName = vigieInstName
 	AM.vigieURL = vigieURL
@@ -54,7 +77,7 @@ func InitAlertManager(vConfAlerting ConfAlerting, vigieInstName, vigieURL string
 			return errHook
 		}
 
-		AM.alrtList.Testsuites = make(map[uint64]teststruct.TestSuite, 0)
+		AM.alrtList.Testsuites = make(map[uint64]teststruct.TSAlertShort, 0)
 
 		if vConfAlerting.Interval == 0 {
 			AM.ticker = *time.NewTicker(time.Second * 5)
@@ -89,9 +112,6 @@ func InitAlertManager(vConfAlerting ConfAlerting, vigieInstName, vigieURL string
 			"status":    "disable",
 		}).Infof(fmt.Sprintf("AlertManager is set to: %t", AM.Enable))
 	}
-	AM.Unlock()
-
-	go AM.run()
 
 	return nil
 }
@@ -130,6 +150,20 @@ func (am *AlertManager) loadHooks(vigieConf ConfAlerting) error {
 		am.hooks["email"] = &ea
 	}
 
+	// Routing ---
+	am.routes = make(map[string]route, len(am.hooks))
+	for name := range am.hooks {
+		am.routes[name] = route{
+			critical: inRouting(name, vigieConf.Routing.Critical),
+			warning:  inRouting(name, vigieConf.Routing.Warning),
+		}
+	}
+	for _, name := range append(vigieConf.Routing.Critical, vigieConf.Routing.Warning...) {
+		if _, exists := am.hooks[name]; !exists {
+			return fmt.Errorf("alerting routing: hook %q is not configured", name)
+		}
+	}
+
 	if len(AM.hooks) == 0 {
 		utils.Log.WithFields(logrus.Fields{
 			"component": "alerting",
@@ -144,69 +178,67 @@ func (am *AlertManager) loadHooks(vigieConf ConfAlerting) error {
 // If a TxStep has no leafs => delete this TxStep on AM
 // AlertList is a list that contanins only fail TestX
 func (am *AlertManager) AddToAlertList(task teststruct.Task) error {
+	return am.addToAlertList(task, true)
+}
+
+// RestoreAlert puts a TestStep whose state is restored back into the AlertList.
+// Its alert was sent before the restart: it is not a change, but it is in the reminders.
+func (am *AlertManager) RestoreAlert(task teststruct.Task) error {
+	return am.addToAlertList(task, false)
+}
 
-	task.RLockAll()
+// addToAlertList adds or removes the TestStep of the task, a change is sent if notify is set.
+func (am *AlertManager) addToAlertList(task teststruct.Task, notify bool) error {
 
-	ts := task.TestSuite
-	tc := task.TestCase
-	tstep := task.TestStep
+	// The views are taken before locking the AlertManager, one TestStruct at a time
+	status := task.TestStep.GetStatus()
+	ats := task.TestSuite.ToAlertShortTS()
+	atc := task.TestCase.ToAlertShortTC()
+	astep := task.TestStep.ToStepAlertShort()
 
-	is_success := tstep.GetStatus() == teststruct.Success
+	// A step skipped because of a dependency is not alerted, its root cause is.
+	// Neither is a paused step.
+	is_success := status == teststruct.Success || status == teststruct.DependencyFailed || status == teststruct.Paused
 
 	am.Lock()
-	am.alrtList.anyChanges = true
+	defer am.Unlock()
+	if notify {
+		am.alrtList.anyChanges = true
+	}
 
 	if is_success {
 
 		// Is this OK Tstep present ?
-		if _, ok := am.alrtList.Testsuites[ts.ID]; ok {
-			if _, ok := am.alrtList.Testsuites[ts.ID].TestCases[tc.ID]; ok {
+		if ts, ok := am.alrtList.Testsuites[ats.ID]; ok {
+			if tc, ok := ts.TestCases[atc.ID]; ok {
 
 				// Delete TStp
-				if _, ok := am.alrtList.Testsuites[ts.ID].TestCases[tc.ID].TestSteps[tstep.ID]; ok {
-					delete(am.alrtList.Testsuites[ts.ID].TestCases[tc.ID].TestSteps, tstep.ID)
-				}
+				delete(tc.TestSteps, astep.ID)
 				// Is this OK Tstep the last present ? => Then Delete Parent
 				// Delete TC
-				if len(am.alrtList.Testsuites[ts.ID].TestCases[tc.ID].TestSteps) == 0 {
-					delete(am.alrtList.Testsuites[ts.ID].TestCases, tc.ID)
+				if len(tc.TestSteps) == 0 {
+					delete(ts.TestCases, atc.ID)
 				}
 			}
 			// Delete TS
-			if len(am.alrtList.Testsuites[ts.ID].TestCases) == 0 {
-				delete(am.alrtList.Testsuites, ts.ID)
+			if len(ts.TestCases) == 0 {
+				delete(am.alrtList.Testsuites, ats.ID)
 			}
 		}
+		return nil
+	}
 
-	} else {
-
-		// If Testsuite is not register, then no TC nor TStep is.
-		// Add them all
-		if _, here := am.alrtList.Testsuites[ts.ID]; !here {
-			// Add only Bare TS without any TS
-			am.alrtList.Testsuites[ts.ID] = ts.WithoutTC()
-			// Add only bare TC without any TC
-			am.alrtList.Testsuites[ts.ID].TestCases[tc.ID] = tc.WithoutTStep()
-			// Simply add the TestStep
-			am.alrtList.Testsuites[ts.ID].TestCases[tc.ID].TestSteps[tstep.ID] = tstep
-
-		} else {
-			// This TestSuites is already register => Add TC (if not register)
-			if _, here := am.alrtList.Testsuites[ts.ID].TestCases[tc.ID]; !here {
-
-				am.alrtList.Testsuites[ts.ID].TestCases[tc.ID] = tc.WithoutTStep()
-				am.alrtList.Testsuites[ts.ID].TestCases[tc.ID].TestSteps[tstep.ID] = tstep
-
-			} else {
-				// This TC is already register => Add TStep (if not register)
-				am.alrtList.Testsuites[ts.ID].TestCases[tc.ID].TestSteps[tstep.ID] = tstep
-
-			}
-		}
+	// The TestSuite and TestCase already registered keep their TestSteps, their headers are refreshed
+	if ts, here := am.alrtList.Testsuites[ats.ID]; here {
+		ats.TestCases = ts.TestCases
+	}
+	if tc, here := ats.TestCases[atc.ID]; here {
+		atc.TestSteps = tc.TestSteps
 	}
+	atc.TestSteps[astep.ID] = astep
+	ats.TestCases[atc.ID] = atc
+	am.alrtList.Testsuites[ats.ID] = ats
 
-	am.Unlock()
-	task.RUnlockAll()
 	return nil
 }
 
@@ -217,9 +249,9 @@ func (am *AlertManager) sendHooks(amsg *teststruct.TotalAlertMessage, at alertTy
 		"pkg": "alerting",
 	}).Debug("Sending Alerting Messages")
 
-	for _, hook := range am.hooks {
+	for name, hook := range am.hooks {
 
-		err := hook.send(*amsg, at)
+		err := hook.send(am.routes[name].filter(amsg), at)
 		if err != nil {
 			utils.Log.WithFields(logrus.Fields{
 				"component": "alerting",
@@ -253,8 +285,63 @@ func (am *AlertManager) anyChange() (change bool) {
 
 func (am *AlertManager) resetChangeState() {
 
-	am.RLock()
+	am.Lock()
 	am.alrtList.anyChanges = false
-	am.RUnlock()
+	am.Unlock()
+
+}
+
+// inRouting returns true if the hook is in the routing list,
+// an empty list routes to every hook.
+func inRouting(hookName string, routing []string) bool {
+
+	if len(routing) == 0 {
+		return true
+	}
+	for _, name := range routing {
+		if name == hookName {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the AlertMessage with only the TestSteps routed to the hook.
+func (r route) filter(amsg *teststruct.TotalAlertMessage) teststruct.TotalAlertMessage {
+
+	if r.critical && r.warning {
+		return *amsg
+	}
+
+	fmsg := teststruct.TotalAlertMessage{
+		Date:       amsg.Date,
+		TestSuites: make(map[uint64]teststruct.TSAlertShort, len(amsg.TestSuites)),
+	}
+
+	for tsID, ts := range amsg.TestSuites {
+
+		fts := ts
+		fts.TestCases = make(map[uint64]teststruct.TCAlertShort, len(ts.TestCases))
+
+		for tcID, tc := range ts.TestCases {
+
+			ftc := tc
+			ftc.TestSteps = make(map[uint64]teststruct.TStepAlertShort, len(tc.TestSteps))
+
+			for stepID, tstep := range tc.TestSteps {
+				isWarning := tstep.Status == teststruct.Warning.String()
+				if (isWarning && r.warning) || (!isWarning && r.critical) {
+					ftc.TestSteps[stepID] = tstep
+				}
+			}
+			if len(ftc.TestSteps) > 0 {
+				fts.TestCases[tcID] = ftc
+			}
+		}
+		if len(fts.TestCases) > 0 {
+			fmsg.TestSuites[tsID] = fts
+		}
+	}
 
+	return fmsg
 }
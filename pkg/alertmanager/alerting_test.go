@@ -0,0 +1,55 @@
+package alertmanager
+
+import (
+	"testing"
+
+	"github.com/vincoll/vigie/pkg/teststruct"
+)
+
+func TestAlertManager_RestoreAlert(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		status      teststruct.StepStatus
+		restore     bool // restored after a restart, or run
+		wantAlert   bool
+		wantChanges bool
+	}{
+		{name: "restored failure", status: teststruct.Error, restore: true, wantAlert: true, wantChanges: false},
+		{name: "restored success", status: teststruct.Success, restore: true, wantAlert: false, wantChanges: false},
+		{name: "run failure", status: teststruct.Error, restore: false, wantAlert: true, wantChanges: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			am := AlertManager{alrtList: alrtList{Testsuites: map[uint64]teststruct.TSAlertShort{}}}
+			tStep := &teststruct.TestStep{ID: 3, Name: "ping"}
+			task := teststruct.Task{
+				TestSuite: &teststruct.TestSuite{ID: 1, Name: "core"},
+				TestCase:  &teststruct.TestCase{ID: 2, Name: "network"},
+				TestStep:  tStep,
+			}
+			if !tStep.RestoreState(teststruct.StepState{Status: tt.status, Failures: []string{"unreachable"}}) {
+				t.Fatal("RestoreState() expected the state to be set")
+			}
+
+			var err error
+			if tt.restore {
+				err = am.RestoreAlert(task)
+			} else {
+				err = am.AddToAlertList(task)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, alerted := am.alrtList.Testsuites[1].TestCases[2].TestSteps[3]
+			if alerted != tt.wantAlert {
+				t.Errorf("TestStep in the AlertList = %v, want %v", alerted, tt.wantAlert)
+			}
+			if am.anyChange() != tt.wantChanges {
+				t.Errorf("anyChange() = %v, want %v", am.anyChange(), tt.wantChanges)
+			}
+		})
+	}
+}
@@ -158,6 +158,9 @@ func generateField(tsas teststruct.TSAlertShort) (tsField fields) {
 		for _, tstp := range tc.TestSteps {
 
 			str2 += fmt.Sprintf("%s (%s)\n", tstp.Name, tstp.Status)
+			if len(tstp.Skipped) > 0 {
+				str2 += fmt.Sprintf("  root cause of %d skipped steps\n", len(tstp.Skipped))
+			}
 			//str2 += fmt.Sprintf( "[%s](%s) \n", tstp.Name, "http://foo.tld")
 		}
 
@@ -35,7 +35,7 @@ func (am *AlertManager) run() {
 	}
 }
 
-// Generate a Recap of each TestX
+// Generate a Recap of each TestX, a copy of the alert list
 func (am *AlertManager) processAlertList() *teststruct.TotalAlertMessage {
 
 	am.RLock()
@@ -46,27 +46,23 @@ func (am *AlertManager) processAlertList() *teststruct.TotalAlertMessage {
 		Date: time.Now(),
 	}
 
-	for _, ts := range am.alrtList.Testsuites {
+	for tsID, ts := range am.alrtList.Testsuites {
 
-		ats := ts.ToAlertShortTS()
-		ats.TestCases = make(map[uint64]teststruct.TCAlertShort, len(am.alrtList.Testsuites[ts.ID].TestCases))
+		ats := ts
+		ats.TestCases = make(map[uint64]teststruct.TCAlertShort, len(ts.TestCases))
 
-		for _, tc := range am.alrtList.Testsuites[ts.ID].TestCases {
+		for tcID, tc := range ts.TestCases {
 
-			ats.TestCases[tc.ID] = tc.ToAlertShortTC()
-
-			//x := make(map[int64]teststruct.TStepAlertShort, len(am.alrtList.Testsuites[ts.ID].TestCases[tc.ID].TestSteps))
-			//ats.TestCases[tc.ID].TestSteps = x
-
-			for _, tstp := range am.alrtList.Testsuites[ts.ID].TestCases[tc.ID].TestSteps {
-
-				ats.TestCases[tc.ID].TestSteps[tstp.ID] = tstp.ToStepAlertShort()
+			atc := tc
+			atc.TestSteps = make(map[uint64]teststruct.TStepAlertShort, len(tc.TestSteps))
 
+			for tstpID, tstp := range tc.TestSteps {
+				atc.TestSteps[tstpID] = tstp
 			}
-
+			ats.TestCases[tcID] = atc
 		}
 
-		alrtsTS[ts.ID] = ats
+		alrtsTS[tsID] = ats
 	}
 
 	am.RUnlock()
@@ -6,7 +6,13 @@ type ConfAlerting struct {
 	Enable   bool          `toml:"enable"`
 	Interval time.Duration `toml:"interval"`
 	Reminder time.Duration `toml:"reminder"`
-	Email    struct {
+	// Routing lists the hooks (email, slack, discord) that receive each severity,
+	// an empty list sends to every hook.
+	Routing struct {
+		Critical []string `toml:"critical"`
+		Warning  []string `toml:"warning"`
+	} `toml:"routing"`
+	Email struct {
 		To       string `toml:"to"`
 		From     string `toml:"from"`
 		Username string `toml:"username"`
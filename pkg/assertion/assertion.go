@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/vincoll/vigie/pkg/assertion/expr"
 	"github.com/vincoll/vigie/pkg/probe"
 	"github.com/vincoll/vigie/pkg/utils"
 )
@@ -17,18 +19,24 @@ type AssertionsApplied struct {
 }
 
 type Assert struct {
-	Key          string       // Key in wich to pick the value from a probe result
-	Method       AssertMethod // Assert method (equal, sup, contains ...)
-	Value        interface{}  // value to assert
-	Values       []string     // values to assert
-	ResultStatus int8         //TODO: à typer en teststruct.Status like
-	ResultAssert string       // ok, or assert fail msg
+	Key          string        // Key in wich to pick the value from a probe result
+	Method       AssertMethod  // Assert method (equal, sup, contains ...)
+	Value        interface{}   // value to assert
+	Values       []string      // values to assert
+	Typed        TypedValue    // unit of the value to assert (duration, bytes, percent, time)
+	Expression   string        // expression of an "expr:" assertion
+	Severity     Severity      // critical, warning or info
+	program      *expr.Program `hash:"ignore"`
+	ResultStatus int8          //TODO: à typer en teststruct.Status like
+	ResultAssert string        // ok, or assert fail msg
 }
 
 type AssertResult struct {
 	Assertion    string
+	Severity     Severity
 	ResultStatus int8   //TODO: à typer en teststruct.Status like
 	ResultAssert string // ok, or assert fail msg
+	Detail       *Diff  `json:",omitempty"` // differences of a failed equality on an array or a JSON document
 }
 
 type AssertShortJSON struct {
@@ -38,9 +46,10 @@ type AssertShortJSON struct {
 }
 
 type AssertDesc struct {
-	LongAssert   string `json:"assertion"`
-	ResultStatus int8   `json:"resultstatus"`
-	ResultAssert string `json:"resultassert"`
+	LongAssert   string   `json:"assertion"`
+	Severity     Severity `json:"severity"`
+	ResultStatus int8     `json:"resultstatus"`
+	ResultAssert string   `json:"resultassert"`
 }
 
 // ToAssertJSON returns a struct with content easily readable
@@ -51,8 +60,10 @@ func (a Assert) ToAssertJSON() *AssertShortJSON {
 		Method: a.Method.LongName,
 	}
 
-	if a.Values == nil {
-		assertjson.Value = fmt.Sprintf("%v", a.Value)
+	if a.Expression != "" {
+		assertjson.Value = a.Expression
+	} else if a.Values == nil {
+		assertjson.Value = a.valueString()
 	} else {
 		jsonValues, _ := json.Marshal(a.Values)
 		assertjson.Value = string(jsonValues)
@@ -65,6 +76,7 @@ func (a Assert) ToAssertDesc() AssertDesc {
 
 	return AssertDesc{
 		LongAssert:   a.AssertConditionsLong(),
+		Severity:     a.Severity,
 		ResultStatus: a.ResultStatus,
 		ResultAssert: a.ResultAssert,
 	}
@@ -73,8 +85,11 @@ func (a Assert) ToAssertDesc() AssertDesc {
 
 func (a Assert) AssertConditionsLong() string {
 
+	if a.Expression != "" {
+		return fmt.Sprintf("%s %s", a.Method.Symbol, a.Expression)
+	}
 	if a.Values == nil {
-		return fmt.Sprintf("%s %s %v", a.Key, a.Method.LongName, a.Value)
+		return fmt.Sprintf("%s %s %s", a.Key, a.Method.LongName, a.valueString())
 	} else {
 		jsonValues, _ := json.Marshal(a.Values)
 		return fmt.Sprintf("%s %s %s", a.Key, a.Method.LongName, string(jsonValues))
@@ -82,34 +97,89 @@ func (a Assert) AssertConditionsLong() string {
 
 }
 
+// valueString returns the expected value as written in the assertion.
+func (a Assert) valueString() string {
+	if a.Typed.Raw != "" {
+		return a.Typed.Raw
+	}
+	return fmt.Sprintf("%v", a.Value)
+}
+
 // ApplyAssert find the corresponding value to assert in probeAnswer
 // then asserts the probe value with the expected value.
-func ApplyAssert(probeAnswer probe.ProbeReturnInterface, tAssert *Assert) (assertRes bool, failCause string) {
+// A failed equality on an array or a JSON document returns the detail of the differences.
+func ApplyAssert(probeAnswer probe.ProbeReturnInterface, tAssert *Assert) (assertRes bool, failCause string, detail *Diff) {
 
 	probeValues := probeAnswer.DumpAnswer()
+
+	if tAssert.program != nil {
+		assertRes, failCause = applyExpression(probeValues, tAssert)
+		return assertRes, failCause, nil
+	}
+
 	// Looking for the key value assertion in the probe result
 	probeValueToAssert, found := browse(tAssert.Key, probeValues)
 	if !found {
-		return false, fmt.Sprintf("key '%q' does not exist in result of probe: %+v", tAssert.Key, probeAnswer)
+		return false, fmt.Sprintf("key '%q' does not exist in result of probe: %+v", tAssert.Key, probeAnswer), nil
 	}
 
 	probValueFmt, probValuesFmt := formatProbeVal(probeValueToAssert, tAssert)
 
+	// Equality on arrays and JSON documents: only the differences are printed
+	if tAssert.Method.ShortName == Equal.ShortName || tAssert.Method.ShortName == OrderedEqual.ShortName {
+		if detail, compared := diffEqual(tAssert, probValueFmt, probValuesFmt); compared {
+			if detail == nil {
+				return true, "", nil
+			}
+			return false, fmt.Sprintf("assertion '%s %s' failed: %s", tAssert.Key, tAssert.Method.LongName, detail), detail
+		}
+	}
+
+	// Typed values (10MB, 1%, now+30d) are asserted as numbers,
+	// the probe value is converted in the same unit.
+	expectValue := tAssert.Value
+	if tAssert.Typed.Type != Untyped {
+		probValueFmt = tAssert.Typed.convert(probValueFmt)
+		expectValue = tAssert.Typed.expected(tAssert.Value, time.Now())
+	}
+
 	// Assertion de Probe ResultValue sur l'attendu
-	_, assertResult := tAssert.Method.AssertFunc(probValueFmt, probValuesFmt, tAssert.Value, tAssert.Values)
+	_, assertResult := tAssert.Method.AssertFunc(probValueFmt, probValuesFmt, expectValue, tAssert.Values)
 	if assertResult != "" {
 		var failCause string
-		if tAssert.Method.IsDuration {
-			// If Duration, the formating need precise
-			numActualVal, _ := utils.GetFloat(probValueFmt)
-			failCause = fmt.Sprintf("assertion '%s' failed: probe result is '%s'", tAssert.AssertConditionsLong(), time.Duration(numActualVal))
-
-		} else {
+		switch {
+		case tAssert.Typed.Relative:
+			// Print the resolved timestamp, "now" is not explicit enough
+			failCause = fmt.Sprintf("assertion '%s' failed: probe result is '%s' (expected value was '%s')",
+				tAssert.AssertConditionsLong(), tAssert.Typed.format(probValueFmt), tAssert.Typed.format(expectValue))
+		case tAssert.Typed.Type != Untyped:
+			// Print the probe value in the unit of the assertion (1s, 20ms, 2MiB, 1%)
+			failCause = fmt.Sprintf("assertion '%s' failed: probe result is '%s'", tAssert.AssertConditionsLong(), tAssert.Typed.format(probValueFmt))
+		default:
 			failCause = fmt.Sprintf("assertion '%s' failed: probe result is '%v'", tAssert.AssertConditionsLong(), probValueFmt)
 		}
-		return false, failCause
+		return false, failCause, nil
 	}
-	return true, ""
+	return true, "", nil
+}
+
+// applyExpression evaluates an "expr:" assertion, the values of
+// its sub-expressions are added to the fail message.
+func applyExpression(probeValues map[string]interface{}, tAssert *Assert) (bool, string) {
+
+	ok, traces, err := tAssert.program.Eval(probeValues)
+	if err != nil {
+		return false, fmt.Sprintf("assertion '%s' cannot be evaluated: %s", tAssert.AssertConditionsLong(), err)
+	}
+	if ok {
+		return true, ""
+	}
+
+	details := make([]string, 0, len(traces))
+	for _, t := range traces {
+		details = append(details, t.String())
+	}
+	return false, fmt.Sprintf("assertion '%s' failed: %s", tAssert.AssertConditionsLong(), strings.Join(details, ", "))
 }
 
 func formatProbeVal(probeValue string, tAssert *Assert) (value interface{}, values []string) {
@@ -138,6 +208,14 @@ func formatProbeVal(probeValue string, tAssert *Assert) (value interface{}, valu
 		return fmt.Sprint(probeValue), nil
 	}
 
+	// JSON Object
+	if strings.HasPrefix(probeValue, "{") {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(probeValue), &obj); err == nil {
+			return obj, nil
+		}
+	}
+
 	// Array
 	if utils.IsArray(probeValue) && !utils.IsNestedArray(probeValue) {
 
@@ -146,7 +224,10 @@ func formatProbeVal(probeValue string, tAssert *Assert) (value interface{}, valu
 		// Simple Array
 		err := json.Unmarshal([]byte(probeValue), &fmtProbeValues)
 		if err != nil {
-			panic(err)
+			// Array of numbers or objects: compared as a JSON document
+			var arr []interface{}
+			_ = json.Unmarshal([]byte(probeValue), &arr)
+			return arr, nil
 		}
 
 		if tAssert.Method.IsEqualType && !tAssert.Method.IsOrdered {
@@ -159,14 +240,10 @@ func formatProbeVal(probeValue string, tAssert *Assert) (value interface{}, valu
 	// Nested Array
 	if utils.IsNestedArray(probeValue) && utils.IsArray(probeValue) {
 
-		// TODO: NestedArray
-
-		/*	err := json.Unmarshal([]byte(v), &aNestedArrayValue)
-			if err != nil {
-				panic(err)
-			}
-		*/
-
+		// Compared as a JSON document
+		var arr []interface{}
+		_ = json.Unmarshal([]byte(probeValue), &arr)
+		return arr, nil
 	}
 
 	return nil, nil
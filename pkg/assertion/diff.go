@@ -0,0 +1,266 @@
+package assertion
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxDiffEntries is the max number of differences listed in a Diff,
+// the others are only counted.
+const maxDiffEntries = 10
+
+// Diff is the detail of a failed equality on an array or a JSON document.
+// For an array, Missing and Extra are elements,
+// for a JSON document, they are paths (key.sub[0]).
+type Diff struct {
+	Expected  string   `json:"expected"`
+	Actual    string   `json:"actual"`
+	Missing   []string `json:"missing,omitempty"`   // Expected but not in the probe result
+	Extra     []string `json:"extra,omitempty"`     // In the probe result but not expected
+	Changes   []Change `json:"changes,omitempty"`   // Same path, different value
+	Truncated int      `json:"truncated,omitempty"` // Number of differences not listed
+}
+
+// Change is a value that differs at the same path.
+type Change struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// String returns a compact one line summary of the differences.
+func (d *Diff) String() string {
+
+	parts := make([]string, 0, 4)
+	if len(d.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("missing [%s]", strings.Join(d.Missing, ", ")))
+	}
+	if len(d.Extra) > 0 {
+		parts = append(parts, fmt.Sprintf("extra [%s]", strings.Join(d.Extra, ", ")))
+	}
+	for _, c := range d.Changes {
+		parts = append(parts, fmt.Sprintf("%s: expected %s, got %s", c.Path, c.Expected, c.Actual))
+	}
+	if d.Truncated > 0 {
+		parts = append(parts, fmt.Sprintf("%d more differences", d.Truncated))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("expected %s, got %s", d.Expected, d.Actual)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (d *Diff) empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changes) == 0 && d.Truncated == 0
+}
+
+func (d *Diff) addMissing(s string) {
+	if d.full() {
+		d.Truncated++
+		return
+	}
+	d.Missing = append(d.Missing, s)
+}
+
+func (d *Diff) addExtra(s string) {
+	if d.full() {
+		d.Truncated++
+		return
+	}
+	d.Extra = append(d.Extra, s)
+}
+
+func (d *Diff) addChange(path string, expected, actual interface{}) {
+	if d.full() {
+		d.Truncated++
+		return
+	}
+	d.Changes = append(d.Changes, Change{Path: path, Expected: compactJSON(expected, 80), Actual: compactJSON(actual, 80)})
+}
+
+func (d *Diff) full() bool {
+	return len(d.Missing)+len(d.Extra)+len(d.Changes) >= maxDiffEntries
+}
+
+// diffEqual compares arrays and JSON documents for the Equal assertions,
+// compared is false if the values are neither.
+func diffEqual(tAssert *Assert, actual interface{}, actuals []string) (detail *Diff, compared bool) {
+
+	if tAssert.Values != nil {
+		if actuals == nil {
+			return nil, false
+		}
+		return diffArrays(tAssert.Key, actuals, tAssert.Values, tAssert.Method.IsOrdered), true
+	}
+
+	exp, expIsDoc := toDocument(tAssert.Value)
+	act, actIsDoc := toDocument(actual)
+	switch {
+	case expIsDoc && actIsDoc:
+		return diffDocuments(tAssert.Key, act, exp), true
+	case expIsDoc || actIsDoc:
+		// A document against a single value
+		return &Diff{Expected: compactJSON(tAssert.Value, 200), Actual: compactJSON(actual, 200)}, true
+	}
+	return nil, false
+}
+
+// diffArrays compares two arrays of strings, unordered arrays are already sorted.
+// Returns nil if they are equal.
+func diffArrays(key string, actual, expected []string, ordered bool) *Diff {
+
+	d := &Diff{Expected: compactJSON(expected, 200), Actual: compactJSON(actual, 200)}
+
+	// Elements count
+	count := make(map[string]int, len(expected))
+	for _, e := range expected {
+		count[e]++
+	}
+	for _, a := range actual {
+		count[a]--
+	}
+
+	for _, e := range expected {
+		if count[e] > 0 {
+			count[e]--
+			d.addMissing(e)
+		}
+	}
+	for _, a := range actual {
+		if count[a] < 0 {
+			count[a]++
+			d.addExtra(a)
+		}
+	}
+
+	// Same elements in another order
+	if ordered && d.empty() {
+		for i := range expected {
+			if expected[i] != actual[i] {
+				d.addChange(fmt.Sprintf("%s[%d]", key, i), expected[i], actual[i])
+			}
+		}
+	}
+
+	if d.empty() {
+		return nil
+	}
+	return d
+}
+
+// diffDocuments compares two decoded JSON documents path by path.
+// Returns nil if they are equal.
+func diffDocuments(key string, actual, expected interface{}) *Diff {
+
+	d := &Diff{Expected: compactJSON(expected, 200), Actual: compactJSON(actual, 200)}
+	diffValue(d, key, actual, expected)
+
+	if d.empty() {
+		return nil
+	}
+	return d
+}
+
+func diffValue(d *Diff, path string, actual, expected interface{}) {
+
+	switch exp := expected.(type) {
+
+	case map[string]interface{}:
+		act, isMap := actual.(map[string]interface{})
+		if !isMap {
+			d.addChange(path, expected, actual)
+			return
+		}
+		for _, k := range sortedKeys(exp) {
+			if _, found := act[k]; !found {
+				d.addMissing(joinPath(path, k))
+				continue
+			}
+			diffValue(d, joinPath(path, k), act[k], exp[k])
+		}
+		for _, k := range sortedKeys(act) {
+			if _, found := exp[k]; !found {
+				d.addExtra(joinPath(path, k))
+			}
+		}
+
+	case []interface{}:
+		act, isArray := actual.([]interface{})
+		if !isArray {
+			d.addChange(path, expected, actual)
+			return
+		}
+		for i := range exp {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if i >= len(act) {
+				d.addMissing(p)
+				continue
+			}
+			diffValue(d, p, act[i], exp[i])
+		}
+		for i := len(exp); i < len(act); i++ {
+			d.addExtra(fmt.Sprintf("%s[%d]", path, i))
+		}
+
+	default:
+		if !reflect.DeepEqual(actual, expected) {
+			d.addChange(path, expected, actual)
+		}
+	}
+}
+
+// toDocument returns a JSON object or array,
+// decoded from a string if needed.
+func toDocument(v interface{}) (interface{}, bool) {
+
+	switch val := v.(type) {
+	case map[string]interface{}, []interface{}:
+		return val, true
+	case string:
+		s := strings.TrimSpace(val)
+		if !strings.HasPrefix(s, "{") && !strings.HasPrefix(s, "[") {
+			return nil, false
+		}
+		var doc interface{}
+		if err := json.Unmarshal([]byte(s), &doc); err != nil {
+			return nil, false
+		}
+		return doc, true
+	}
+	return nil, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compactJSON prints a value as JSON, truncated to max characters.
+func compactJSON(v interface{}, max int) string {
+
+	var s string
+	b, err := json.Marshal(v)
+	if err != nil {
+		s = fmt.Sprintf("%v", v)
+	} else {
+		s = string(b)
+	}
+	if len(s) > max {
+		s = s[:max-3] + "..."
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package assertion
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffArrays(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		actual   []string
+		expected []string
+		ordered  bool
+		want     string
+	}{
+		{name: "equal", actual: []string{"a", "b"}, expected: []string{"a", "b"}},
+		{name: "missing_extra", actual: []string{"a", "c", "d"}, expected: []string{"a", "b"}, want: "missing [b]; extra [c, d]"},
+		{name: "duplicate", actual: []string{"a"}, expected: []string{"a", "a"}, want: "missing [a]"},
+		{name: "ordered", actual: []string{"b", "a"}, expected: []string{"a", "b"}, ordered: true, want: `answer[0]: expected "a", got "b"; answer[1]: expected "b", got "a"`},
+		{name: "truncated", actual: nil, expected: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}, want: "missing [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]; 2 more differences"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := diffArrays("answer", tt.actual, tt.expected, tt.ordered)
+			if tt.want == "" {
+				if d != nil {
+					t.Errorf("diffArrays() = %v, want no difference", d)
+				}
+				return
+			}
+			if d == nil || d.String() != tt.want {
+				t.Errorf("diffArrays() = %v, want %q", d, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffDocuments(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		actual   string
+		expected string
+		want     string
+	}{
+		{name: "equal", actual: `{"a": 1, "b": [1, 2]}`, expected: `{"b": [1, 2], "a": 1}`},
+		{name: "changed", actual: `{"status": "ko", "count": 2}`, expected: `{"status": "ok", "count": 2}`, want: `bodyjson.status: expected "ok", got "ko"`},
+		{name: "missing_extra_keys", actual: `{"a": 1, "c": 3}`, expected: `{"a": 1, "b": 2}`, want: "missing [bodyjson.b]; extra [bodyjson.c]"},
+		{name: "nested_array", actual: `{"items": [{"id": 1}, {"id": 3}, {"id": 4}]}`, expected: `{"items": [{"id": 1}, {"id": 2}]}`,
+			want: "extra [bodyjson.items[2]]; bodyjson.items[1].id: expected 2, got 3"},
+		{name: "type", actual: `{"a": "1"}`, expected: `{"a": {"b": 1}}`, want: `bodyjson.a: expected {"b":1}, got "1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actual, expected interface{}
+			_ = json.Unmarshal([]byte(tt.actual), &actual)
+			_ = json.Unmarshal([]byte(tt.expected), &expected)
+			d := diffDocuments("bodyjson", actual, expected)
+			if tt.want == "" {
+				if d != nil {
+					t.Errorf("diffDocuments() = %v, want no difference", d)
+				}
+				return
+			}
+			if d == nil || d.String() != tt.want {
+				t.Errorf("diffDocuments() = %v, want %q", d, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type checker struct {
+	src     string
+	resolve Resolver
+}
+
+// check sets and returns the static type of n and its children.
+func (c *checker) check(n *node) (Type, error) {
+	t, err := c.typeOf(n)
+	if err != nil {
+		return Any, err
+	}
+	n.typ = t
+	return t, nil
+}
+
+func (c *checker) text(n *node) string {
+	return strings.TrimSpace(c.src[n.start:n.end])
+}
+
+func (c *checker) typeOf(n *node) (Type, error) {
+
+	switch n.kind {
+	case nodeLiteral:
+		return n.typ, nil
+
+	case nodeIdent, nodeMember, nodeIndex:
+		if path, ok := n.path(); ok {
+			return c.field(n, path)
+		}
+		for _, a := range n.args {
+			if _, err := c.check(a); err != nil {
+				return Any, err
+			}
+		}
+		if n.kind == nodeIndex {
+			switch n.args[0].typ {
+			case Any, Array, Object:
+			default:
+				return Any, fmt.Errorf("cannot index %q which is a %s", c.text(n.args[0]), n.args[0].typ)
+			}
+		}
+		return Any, nil
+
+	case nodeList:
+		for _, a := range n.args {
+			if _, err := c.check(a); err != nil {
+				return Any, err
+			}
+		}
+		return Array, nil
+
+	case nodeUnary:
+		t, err := c.check(n.args[0])
+		if err != nil {
+			return Any, err
+		}
+		if n.op == "!" {
+			if !compatible(t, Bool) {
+				return Any, fmt.Errorf("operator ! cannot be used on %q which is a %s", c.text(n.args[0]), t)
+			}
+			return Bool, nil
+		}
+		if !isNumeric(t) {
+			return Any, fmt.Errorf("operator - cannot be used on %q which is a %s", c.text(n.args[0]), t)
+		}
+		return t, nil
+
+	case nodeBinary:
+		return c.binary(n)
+
+	case nodeCall:
+		return c.call(n)
+	}
+
+	return Any, fmt.Errorf("invalid expression %q", c.text(n))
+}
+
+// field returns the type of a field of the answer.
+func (c *checker) field(n *node, path []string) (Type, error) {
+
+	if c.resolve == nil {
+		return Any, nil
+	}
+	t, found := c.resolve(path)
+	if !found {
+		return Any, fmt.Errorf("field %q does not exist in the probe answer", c.text(n))
+	}
+	return t, nil
+}
+
+func (c *checker) binary(n *node) (Type, error) {
+
+	l, err := c.check(n.args[0])
+	if err != nil {
+		return Any, err
+	}
+	r, err := c.check(n.args[1])
+	if err != nil {
+		return Any, err
+	}
+	mismatch := fmt.Errorf("operator %s cannot be used between %q (%s) and %q (%s)", n.op, c.text(n.args[0]), l, c.text(n.args[1]), r)
+
+	switch n.op {
+	case "&&", "||":
+		if !compatible(l, Bool) || !compatible(r, Bool) {
+			return Any, mismatch
+		}
+		return Bool, nil
+
+	case "+":
+		if compatible(l, String) && compatible(r, String) && (l == String || r == String) {
+			return String, nil
+		}
+		fallthrough
+	case "-", "*", "/", "%":
+		if !isNumeric(l) || !isNumeric(r) {
+			return Any, mismatch
+		}
+		return arithType(n.op, l, r), nil
+
+	case "<", "<=", ">", ">=":
+		if (isNumeric(l) && isNumeric(r)) || (compatible(l, String) && compatible(r, String)) {
+			return Bool, nil
+		}
+		return Any, mismatch
+
+	case "==", "!=":
+		if !comparable(l, r) {
+			return Any, mismatch
+		}
+		return Bool, nil
+	}
+
+	return Any, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func (c *checker) call(n *node) (Type, error) {
+
+	switch n.name {
+	case "has":
+		// has(field) tells if an optional field is present
+		if len(n.args) != 1 {
+			return Any, fmt.Errorf("has() takes 1 argument, got %d", len(n.args))
+		}
+		if _, isPath := n.args[0].path(); !isPath {
+			return Any, fmt.Errorf("has() argument must be a field, got %q", c.text(n.args[0]))
+		}
+		return Bool, nil
+
+	case "matches":
+		// The pattern must be a literal, it is compiled once
+		if len(n.args) != 2 {
+			return Any, fmt.Errorf("matches() takes 2 arguments, got %d", len(n.args))
+		}
+		t, err := c.check(n.args[0])
+		if err != nil {
+			return Any, err
+		}
+		if !compatible(t, String) {
+			return Any, fmt.Errorf("matches() first argument must be a string, %q is a %s", c.text(n.args[0]), t)
+		}
+		pattern, isStr := n.args[1].value.(string)
+		if n.args[1].kind != nodeLiteral || !isStr {
+			return Any, fmt.Errorf("matches() pattern must be a string literal")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Any, fmt.Errorf("matches() invalid pattern: %s", err)
+		}
+		n.re = re
+		return Bool, nil
+	}
+
+	fn, exists := functions[n.name]
+	if !exists {
+		return Any, fmt.Errorf("unknown function %s()", n.name)
+	}
+	if len(n.args) != len(fn.args) {
+		return Any, fmt.Errorf("%s() takes %d argument(s), got %d", n.name, len(fn.args), len(n.args))
+	}
+	for i, a := range n.args {
+		t, err := c.check(a)
+		if err != nil {
+			return Any, err
+		}
+		if !compatible(t, fn.args[i]) {
+			return Any, fmt.Errorf("%s() argument %d must be a %s, %q is a %s", n.name, i+1, fn.args[i], c.text(a), t)
+		}
+	}
+	if fn.result == Number && len(n.args) > 0 && n.args[0].typ == Duration && n.name != "len" {
+		// abs(), min(), max() keep the duration type
+		return Duration, nil
+	}
+	return fn.result, nil
+}
+
+func isNumeric(t Type) bool {
+	return t == Number || t == Duration || t == Any
+}
+
+// compatible tells if a value of type got can be used where want is expected.
+func compatible(got, want Type) bool {
+	switch {
+	case got == Any, want == Any, got == want:
+		return true
+	case want == Number && got == Duration, want == Duration && got == Number:
+		return true
+	}
+	return false
+}
+
+func comparable(l, r Type) bool {
+	if l == Null || r == Null {
+		return true
+	}
+	return compatible(l, r)
+}
+
+func arithType(op string, l, r Type) Type {
+	switch {
+	case l == Duration && r == Duration && (op == "/" || op == "*"):
+		return Number
+	case l == Duration || r == Duration:
+		return Duration
+	case l == Any && r == Any:
+		return Any
+	}
+	return Number
+}
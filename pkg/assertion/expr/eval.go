@@ -0,0 +1,305 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type evaluator struct {
+	src    string
+	answer map[string]interface{}
+	traces []Trace
+	seen   map[string]bool
+}
+
+// record keeps the value of a sub-expression for the fail message.
+func (e *evaluator) record(n *node, v interface{}) {
+
+	if n.kind == nodeLiteral {
+		return
+	}
+	text := strings.TrimSpace(e.src[n.start:n.end])
+	if e.seen[text] {
+		return
+	}
+	e.seen[text] = true
+	e.traces = append(e.traces, Trace{Expr: text, Value: formatValue(v, n.typ), start: n.start, end: n.end})
+}
+
+func (e *evaluator) text(n *node) string {
+	return strings.TrimSpace(e.src[n.start:n.end])
+}
+
+func (e *evaluator) eval(n *node) (interface{}, error) {
+
+	switch n.kind {
+	case nodeLiteral:
+		return n.value, nil
+
+	case nodeIdent:
+		v, found := e.answer[n.name]
+		if !found {
+			return nil, fmt.Errorf("field %q does not exist in the probe answer", n.name)
+		}
+		return v, nil
+
+	case nodeMember:
+		obj, err := e.eval(n.args[0])
+		if err != nil {
+			return nil, err
+		}
+		return e.lookup(n, obj, n.name)
+
+	case nodeIndex:
+		obj, err := e.eval(n.args[0])
+		if err != nil {
+			return nil, err
+		}
+		idx, err := e.eval(n.args[1])
+		if err != nil {
+			return nil, err
+		}
+		if arr, isArr := obj.([]interface{}); isArr {
+			i, isNum := idx.(float64)
+			if !isNum || i != math.Trunc(i) {
+				return nil, fmt.Errorf("index of %q must be an integer", e.text(n.args[0]))
+			}
+			if i < 0 || int(i) >= len(arr) {
+				return nil, fmt.Errorf("index %d out of range of %q (len %d)", int(i), e.text(n.args[0]), len(arr))
+			}
+			return arr[int(i)], nil
+		}
+		key, isStr := idx.(string)
+		if !isStr {
+			return nil, fmt.Errorf("key of %q must be a string", e.text(n.args[0]))
+		}
+		return e.lookup(n, obj, key)
+
+	case nodeList:
+		list := make([]interface{}, 0, len(n.args))
+		for _, a := range n.args {
+			v, err := e.eval(a)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+
+	case nodeUnary:
+		v, err := e.eval(n.args[0])
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			b, isBool := v.(bool)
+			if !isBool {
+				return nil, fmt.Errorf("%q is not a bool", e.text(n.args[0]))
+			}
+			return !b, nil
+		}
+		num, err := toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		return -num, nil
+
+	case nodeBinary:
+		return e.binary(n)
+
+	case nodeCall:
+		return e.call(n)
+	}
+
+	return nil, fmt.Errorf("invalid expression %q", e.text(n))
+}
+
+func (e *evaluator) lookup(n *node, obj interface{}, key string) (interface{}, error) {
+
+	m, isMap := obj.(map[string]interface{})
+	if !isMap {
+		return nil, fmt.Errorf("%q is not an object", e.text(n.args[0]))
+	}
+	v, found := m[key]
+	if !found {
+		return nil, fmt.Errorf("field %q does not exist in the probe answer", e.text(n))
+	}
+	return v, nil
+}
+
+func (e *evaluator) binary(n *node) (interface{}, error) {
+
+	l, err := e.eval(n.args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// Logical operators are short-circuited
+	if n.op == "&&" || n.op == "||" {
+		lb, isBool := l.(bool)
+		if !isBool {
+			return nil, fmt.Errorf("%q is not a bool", e.text(n.args[0]))
+		}
+		if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+			return lb, nil
+		}
+		r, err := e.eval(n.args[1])
+		if err != nil {
+			return nil, err
+		}
+		rb, isBool := r.(bool)
+		if !isBool {
+			return nil, fmt.Errorf("%q is not a bool", e.text(n.args[1]))
+		}
+		return rb, nil
+	}
+
+	r, err := e.eval(n.args[1])
+	if err != nil {
+		return nil, err
+	}
+	e.record(n.args[0], l)
+	e.record(n.args[1], r)
+
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	}
+
+	ls, lIsStr := l.(string)
+	rs, rIsStr := r.(string)
+	bothStr := lIsStr && rIsStr && n.args[0].typ != Duration && n.args[1].typ != Duration
+
+	if n.op == "+" && bothStr {
+		return ls + rs, nil
+	}
+
+	switch n.op {
+	case "<", "<=", ">", ">=":
+		if bothStr {
+			return compare(n.op, strings.Compare(ls, rs)), nil
+		}
+	}
+
+	x, err := toNumber(l)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %s", e.text(n.args[0]), err)
+	}
+	y, err := toNumber(r)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %s", e.text(n.args[1]), err)
+	}
+
+	switch n.op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/":
+		if y == 0 {
+			return nil, fmt.Errorf("division by zero in %q", e.text(n))
+		}
+		return x / y, nil
+	case "%":
+		if y == 0 {
+			return nil, fmt.Errorf("division by zero in %q", e.text(n))
+		}
+		return math.Mod(x, y), nil
+	case "<", "<=", ">", ">=":
+		switch {
+		case x < y:
+			return compare(n.op, -1), nil
+		case x > y:
+			return compare(n.op, 1), nil
+		default:
+			return compare(n.op, 0), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func (e *evaluator) call(n *node) (interface{}, error) {
+
+	if n.name == "has" {
+		_, err := e.eval(n.args[0])
+		return err == nil, nil
+	}
+
+	args := make([]interface{}, 0, len(n.args))
+	for _, a := range n.args {
+		v, err := e.eval(a)
+		if err != nil {
+			return nil, err
+		}
+		e.record(a, v)
+		args = append(args, v)
+	}
+
+	if n.name == "matches" {
+		s, isStr := args[0].(string)
+		if !isStr {
+			return nil, fmt.Errorf("%q is not a string", e.text(n.args[0]))
+		}
+		return n.re.MatchString(s), nil
+	}
+
+	v, err := functions[n.name].call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", e.text(n), err)
+	}
+	return v, nil
+}
+
+// compare converts the result of a comparison (-1, 0, 1) for op.
+func compare(op string, cmp int) bool {
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// toNumber converts a value to a number,
+// durations written as strings (1.2s) are converted in nanoseconds.
+func toNumber(v interface{}) (float64, error) {
+
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case string:
+		if num, err := strconv.ParseFloat(val, 64); err == nil {
+			return num, nil
+		}
+		if d, err := time.ParseDuration(val); err == nil {
+			return float64(d), nil
+		}
+	}
+	return 0, fmt.Errorf("%s is not a number", formatValue(v, Any))
+}
+
+// equal compares two values, a number is equal to a string holding the same number or duration.
+func equal(l, r interface{}) bool {
+
+	_, lIsNum := l.(float64)
+	_, rIsNum := r.(float64)
+	if lIsNum != rIsNum {
+		x, errX := toNumber(l)
+		y, errY := toNumber(r)
+		return errX == nil && errY == nil && x == y
+	}
+	return reflect.DeepEqual(l, r)
+}
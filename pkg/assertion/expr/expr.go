@@ -0,0 +1,165 @@
+// Package expr is a small sandboxed expression language used by the
+// "expr:" assertions. Expressions are evaluated over a probe answer:
+//
+//	responses_time.tlshandshake < responses_time.total * 0.3 && headers["Server"] != ""
+//
+// Expressions are type-checked at compile time against the fields of the
+// probe answer, they cannot loop nor access anything but the answer.
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Type is the static type of an expression.
+type Type int8
+
+const (
+	Any Type = iota // Unknown until evaluation
+	Null
+	Bool
+	Number
+	Duration // Number of nanoseconds, printed as a duration
+	String
+	Array
+	Object
+)
+
+func (t Type) String() string {
+	switch t {
+	case Null:
+		return "null"
+	case Bool:
+		return "bool"
+	case Number:
+		return "number"
+	case Duration:
+		return "duration"
+	case String:
+		return "string"
+	case Array:
+		return "array"
+	case Object:
+		return "object"
+	default:
+		return "any"
+	}
+}
+
+// Resolver returns the type of the field at path in the evaluated answer,
+// found is false if the field does not exist.
+type Resolver func(path []string) (t Type, found bool)
+
+// Program is a compiled expression.
+type Program struct {
+	source string
+	root   *node
+}
+
+// Trace is the value of a sub-expression during an evaluation.
+type Trace struct {
+	Expr  string
+	Value string
+
+	start, end int
+}
+
+func (t Trace) String() string {
+	return fmt.Sprintf("%s = %s", t.Expr, t.Value)
+}
+
+// maxLen is the max length of an expression.
+const maxLen = 4096
+
+// Compile parses and checks an expression, resolve is used to check the fields
+// of the answer (nil accepts any field).
+func Compile(source string, resolve Resolver) (*Program, error) {
+
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	if len(source) > maxLen {
+		return nil, fmt.Errorf("expression is longer than %d characters", maxLen)
+	}
+
+	root, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	c := checker{src: source, resolve: resolve}
+	t, err := c.check(root)
+	if err != nil {
+		return nil, err
+	}
+	if t != Bool && t != Any {
+		return nil, fmt.Errorf("expression must be a bool, not a %s", t)
+	}
+
+	return &Program{source: source, root: root}, nil
+}
+
+func (p *Program) String() string {
+	return p.source
+}
+
+// Eval evaluates the expression over an answer. The values of the
+// sub-expressions are returned to explain the result.
+func (p *Program) Eval(answer map[string]interface{}) (bool, []Trace, error) {
+
+	e := evaluator{src: p.source, answer: answer, seen: map[string]bool{}}
+	v, err := e.eval(p.root)
+
+	// Sub-expressions in reading order, outer first
+	sort.SliceStable(e.traces, func(i, j int) bool {
+		if e.traces[i].start != e.traces[j].start {
+			return e.traces[i].start < e.traces[j].start
+		}
+		return e.traces[i].end > e.traces[j].end
+	})
+	if err != nil {
+		return false, e.traces, err
+	}
+	b, isBool := v.(bool)
+	if !isBool {
+		return false, e.traces, fmt.Errorf("expression result is not a bool: %s", formatValue(v, Any))
+	}
+	return b, e.traces, nil
+}
+
+// formatValue prints a value for a Trace, long values are truncated.
+func formatValue(v interface{}, t Type) string {
+
+	var s string
+	switch val := v.(type) {
+	case nil:
+		s = "null"
+	case string:
+		s = fmt.Sprintf("%q", val)
+	case float64:
+		if t == Duration {
+			s = time.Duration(val).String()
+		} else {
+			s = fmt.Sprint(val)
+		}
+	case bool:
+		s = fmt.Sprint(val)
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			s = fmt.Sprintf("%v", val)
+		} else {
+			s = string(b)
+		}
+	}
+
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return s
+}
@@ -0,0 +1,129 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+// answerTypes mimics the answer of the http probe
+var answerTypes = map[string]Type{
+	"httpcode":                    Number,
+	"body":                        String,
+	"bodyjson":                    Any,
+	"headers":                     Object,
+	"probeinfo":                   Object,
+	"probeinfo.responsetime":      Duration,
+	"responses_time":              Object,
+	"responses_time.tlshandshake": Duration,
+	"responses_time.total":        Duration,
+}
+
+func testResolver(path []string) (Type, bool) {
+	if path[0] == "bodyjson" {
+		return Any, true
+	}
+	if path[0] == "headers" && len(path) == 2 {
+		return String, true
+	}
+	t, found := answerTypes[strings.Join(path, ".")]
+	return t, found
+}
+
+func testAnswer() map[string]interface{} {
+	return map[string]interface{}{
+		"httpcode": float64(200),
+		"body":     "hello world",
+		"bodyjson": map[string]interface{}{"items": []interface{}{"a", "b"}, "count": float64(2)},
+		"headers":  map[string]interface{}{"Server": "nginx", "Content-Length": "11"},
+		"probeinfo": map[string]interface{}{
+			"responsetime": "120ms",
+		},
+		"responses_time": map[string]interface{}{
+			"tlshandshake": float64(12e6),
+			"total":        float64(100e6),
+		},
+	}
+}
+
+func TestCompile(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr string
+	}{
+		{name: "cross_field", expr: `responses_time.tlshandshake < responses_time.total * 0.3 && headers["Server"] != ""`},
+		{name: "duration_literal", expr: `probeinfo.responsetime < 600ms`},
+		{name: "functions", expr: `len(body) > 3 && startsWith(body, "hello") && matches(headers["Server"], "^nginx")`},
+		{name: "any_subfield", expr: `bodyjson.items[0] == "a"`},
+		{name: "has", expr: `has(headers["X-Cache"]) || httpcode == 200`},
+		{name: "unknown_field", expr: `httpcod == 200`, wantErr: `field "httpcod" does not exist`},
+		{name: "unknown_subfield", expr: `responses_time.tls < 1s`, wantErr: `field "responses_time.tls" does not exist`},
+		{name: "type_mismatch", expr: `body < 3`, wantErr: "operator < cannot be used"},
+		{name: "string_number_equal", expr: `headers["Content-Length"] == 11`, wantErr: "operator == cannot be used"},
+		{name: "not_a_bool", expr: `httpcode + 1`, wantErr: "must be a bool"},
+		{name: "unknown_function", expr: `exec("rm") == 1`, wantErr: "unknown function exec()"},
+		{name: "bad_regexp", expr: `matches(body, "(")`, wantErr: "invalid pattern"},
+		{name: "syntax", expr: `httpcode == `, wantErr: "unexpected end"},
+		{name: "unterminated_string", expr: `body == "abc`, wantErr: "unterminated string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.expr, testResolver)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Compile() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		expr       string
+		want       bool
+		wantErr    bool
+		wantTraces []string
+	}{
+		{name: "cross_field_ok", expr: `responses_time.tlshandshake < responses_time.total * 0.3 && headers["Server"] != ""`, want: true},
+		{name: "cross_field_ko", expr: `responses_time.tlshandshake < responses_time.total * 0.1`, want: false,
+			wantTraces: []string{"responses_time.tlshandshake = 12ms", "responses_time.total * 0.1 = 10ms"}},
+		{name: "duration_string", expr: `probeinfo.responsetime < 600ms`, want: true},
+		{name: "number_from_string", expr: `number(headers["Content-Length"]) == len(body)`, want: true},
+		{name: "any_number_equal", expr: `bodyjson.count == "2"`, want: true},
+		{name: "contains_array", expr: `contains(bodyjson.items, "b")`, want: true},
+		{name: "has_missing", expr: `!has(headers["X-Cache"])`, want: true},
+		{name: "short_circuit", expr: `httpcode == 500 && headers["X-Cache"] == "HIT"`, want: false},
+		{name: "missing_field", expr: `headers["X-Cache"] == "HIT"`, wantErr: true},
+		{name: "division_by_zero", expr: `httpcode / 0 > 1`, wantErr: true},
+		{name: "in_list", expr: `contains([200, 201], httpcode)`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Compile(tt.expr, testResolver)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			got, traces, err := p.Eval(testAnswer())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Eval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Eval() got = %v, want %v", got, tt.want)
+			}
+			for i, want := range tt.wantTraces {
+				if i >= len(traces) || traces[i].String() != want {
+					t.Errorf("Eval() traces = %v, want %v", traces, tt.wantTraces)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type function struct {
+	args   []Type
+	result Type
+	call   func(args []interface{}) (interface{}, error)
+}
+
+// functions available in expressions, has() and matches() are handled by the checker.
+var functions = map[string]function{
+	"len": {args: []Type{Any}, result: Number, call: func(a []interface{}) (interface{}, error) {
+		switch v := a[0].(type) {
+		case string:
+			return float64(len(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		}
+		return nil, fmt.Errorf("len() cannot be used on %s", formatValue(a[0], Any))
+	}},
+	"contains": {args: []Type{Any, Any}, result: Bool, call: func(a []interface{}) (interface{}, error) {
+		switch v := a[0].(type) {
+		case string:
+			sub, isStr := a[1].(string)
+			if !isStr {
+				return nil, fmt.Errorf("contains() on a string needs a string, got %s", formatValue(a[1], Any))
+			}
+			return strings.Contains(v, sub), nil
+		case []interface{}:
+			for _, elem := range v {
+				if equal(elem, a[1]) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			key, isStr := a[1].(string)
+			if !isStr {
+				return nil, fmt.Errorf("contains() on an object needs a string key, got %s", formatValue(a[1], Any))
+			}
+			_, found := v[key]
+			return found, nil
+		}
+		return nil, fmt.Errorf("contains() cannot be used on %s", formatValue(a[0], Any))
+	}},
+	"startsWith": {args: []Type{String, String}, result: Bool, call: stringFunc(func(s, p string) interface{} { return strings.HasPrefix(s, p) })},
+	"endsWith":   {args: []Type{String, String}, result: Bool, call: stringFunc(func(s, p string) interface{} { return strings.HasSuffix(s, p) })},
+	"lower":      {args: []Type{String}, result: String, call: stringFunc(func(s, _ string) interface{} { return strings.ToLower(s) })},
+	"upper":      {args: []Type{String}, result: String, call: stringFunc(func(s, _ string) interface{} { return strings.ToUpper(s) })},
+	"trim":       {args: []Type{String}, result: String, call: stringFunc(func(s, _ string) interface{} { return strings.TrimSpace(s) })},
+	"number": {args: []Type{Any}, result: Number, call: func(a []interface{}) (interface{}, error) {
+		return toNumber(a[0])
+	}},
+	"string": {args: []Type{Any}, result: String, call: func(a []interface{}) (interface{}, error) {
+		if s, isStr := a[0].(string); isStr {
+			return s, nil
+		}
+		return formatValue(a[0], Any), nil
+	}},
+	"abs": {args: []Type{Number}, result: Number, call: numberFunc(func(x, _ float64) float64 { return math.Abs(x) })},
+	"min": {args: []Type{Number, Number}, result: Number, call: numberFunc(math.Min)},
+	"max": {args: []Type{Number, Number}, result: Number, call: numberFunc(math.Max)},
+}
+
+// stringFunc wraps a function of one or two strings.
+func stringFunc(f func(s, p string) interface{}) func(a []interface{}) (interface{}, error) {
+	return func(a []interface{}) (interface{}, error) {
+		strs := []string{"", ""}
+		for i, v := range a {
+			s, isStr := v.(string)
+			if !isStr {
+				return nil, fmt.Errorf("%s is not a string", formatValue(v, Any))
+			}
+			strs[i] = s
+		}
+		return f(strs[0], strs[1]), nil
+	}
+}
+
+// numberFunc wraps a function of one or two numbers.
+func numberFunc(f func(x, y float64) float64) func(a []interface{}) (interface{}, error) {
+	return func(a []interface{}) (interface{}, error) {
+		nums := []float64{0, 0}
+		for i, v := range a {
+			num, err := toNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			nums[i] = num
+		}
+		return f(nums[0], nums[1]), nil
+	}
+}
@@ -0,0 +1,117 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int8
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokDuration
+	tokString
+	tokIdent
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string  // Punct, Ident
+	num  float64 // Number, Duration (ns)
+	str  string  // String
+	pos  int
+	end  int
+}
+
+// Longest operators first
+var puncts = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "(", ")", "[", "]", ".", ","}
+
+func lex(src string) ([]token, error) {
+
+	toks := make([]token, 0)
+	i := 0
+
+	for i < len(src) {
+		c := rune(src[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			// A unit right after a number makes it a duration (600ms, 1m30s)
+			if i < len(src) && isLetter(src[i]) {
+				for i < len(src) && (isLetter(src[i]) || isDigit(src[i]) || src[i] == '.') {
+					i++
+				}
+				d, err := time.ParseDuration(src[start:i])
+				if err != nil {
+					return nil, fmt.Errorf("invalid duration %q at %d", src[start:i], start)
+				}
+				toks = append(toks, token{kind: tokDuration, num: float64(d), pos: start, end: i})
+				continue
+			}
+			num, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", src[start:i], start)
+			}
+			toks = append(toks, token{kind: tokNumber, num: num, pos: start, end: i})
+
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(src) && rune(src[i]) != c; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				sb.WriteByte(src[i])
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			toks = append(toks, token{kind: tokString, str: sb.String(), pos: start, end: i})
+
+		case isLetter(src[i]) || c == '_':
+			start := i
+			for i < len(src) && (isLetter(src[i]) || isDigit(src[i]) || src[i] == '_') {
+				i++
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[start:i], pos: start, end: i})
+
+		default:
+			found := false
+			for _, p := range puncts {
+				if strings.HasPrefix(src[i:], p) {
+					toks = append(toks, token{kind: tokPunct, text: p, pos: i, end: i + len(p)})
+					i += len(p)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+		}
+	}
+
+	return append(toks, token{kind: tokEOF, pos: len(src), end: len(src)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
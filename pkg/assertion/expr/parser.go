@@ -0,0 +1,289 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+)
+
+type nodeKind int8
+
+const (
+	nodeLiteral nodeKind = iota
+	nodeIdent
+	nodeMember // x.name
+	nodeIndex  // x[expr]
+	nodeUnary
+	nodeBinary
+	nodeCall
+	nodeList
+)
+
+type node struct {
+	kind  nodeKind
+	op    string      // Unary and Binary operator
+	name  string      // Ident, Member, Call
+	value interface{} // Literal
+	args  []*node     // Operands, object and index, call arguments, list elements
+	start int
+	end   int
+
+	typ Type           // Static type, set by check
+	re  *regexp.Regexp // Pattern of matches(), compiled by check
+}
+
+type parser struct {
+	src  string
+	toks []token
+	i    int
+}
+
+func parse(src string) (*node, error) {
+
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, toks: toks}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", p.src[t.pos:t.end], t.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the puncts ops.
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokPunct {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.i++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		if t.kind == tokEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at %d, got %q", op, t.pos, p.src[t.pos:t.end])
+	}
+	return nil
+}
+
+// binary parses a left associative level of binary operators.
+func (p *parser) binary(operand func() (*node, error), ops ...string) (*node, error) {
+
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &node{kind: nodeBinary, op: op, args: []*node{left, right}, start: left.start, end: right.end}
+	}
+}
+
+func (p *parser) parseOr() (*node, error) {
+	return p.binary(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (*node, error) {
+	return p.binary(p.parseEquality, "&&")
+}
+
+func (p *parser) parseEquality() (*node, error) {
+	return p.binary(p.parseRelational, "==", "!=")
+}
+
+func (p *parser) parseRelational() (*node, error) {
+	return p.binary(p.parseAdditive, "<=", ">=", "<", ">")
+}
+
+func (p *parser) parseAdditive() (*node, error) {
+	return p.binary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *parser) parseMultiplicative() (*node, error) {
+	return p.binary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseUnary() (*node, error) {
+
+	start := p.peek().pos
+	if op, ok := p.accept("!", "-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeUnary, op: op, args: []*node{operand}, start: start, end: operand.end}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (*node, error) {
+
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch op, _ := p.accept(".", "["); op {
+		case ".":
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, fmt.Errorf("expected a field name after '.' at %d", t.pos)
+			}
+			n = &node{kind: nodeMember, name: t.text, args: []*node{n}, start: n.start, end: t.end}
+		case "[":
+			idx, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			end := p.peek().end
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &node{kind: nodeIndex, args: []*node{n, idx}, start: n.start, end: end}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+
+	t := p.next()
+
+	switch t.kind {
+	case tokNumber:
+		return &node{kind: nodeLiteral, value: t.num, typ: Number, start: t.pos, end: t.end}, nil
+
+	case tokDuration:
+		return &node{kind: nodeLiteral, value: t.num, typ: Duration, start: t.pos, end: t.end}, nil
+
+	case tokString:
+		return &node{kind: nodeLiteral, value: t.str, typ: String, start: t.pos, end: t.end}, nil
+
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			return &node{kind: nodeLiteral, value: t.text == "true", typ: Bool, start: t.pos, end: t.end}, nil
+		case "null":
+			return &node{kind: nodeLiteral, value: nil, typ: Null, start: t.pos, end: t.end}, nil
+		}
+		if _, isCall := p.accept("("); isCall {
+			args, end, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return &node{kind: nodeCall, name: t.text, args: args, start: t.pos, end: end}, nil
+		}
+		return &node{kind: nodeIdent, name: t.text, start: t.pos, end: t.end}, nil
+
+	case tokPunct:
+		switch t.text {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "[":
+			elems, end, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &node{kind: nodeList, args: elems, start: t.pos, end: end}, nil
+		}
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+
+	default:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+}
+
+// parseList parses comma separated expressions until closing.
+func (p *parser) parseList(closing string) ([]*node, int, error) {
+
+	elems := make([]*node, 0)
+	if t := p.peek(); t.kind == tokPunct && t.text == closing {
+		p.next()
+		return elems, t.end, nil
+	}
+	for {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, 0, err
+		}
+		elems = append(elems, e)
+		if _, more := p.accept(","); more {
+			continue
+		}
+		end := p.peek().end
+		if err := p.expect(closing); err != nil {
+			return nil, 0, err
+		}
+		return elems, end, nil
+	}
+}
+
+// path returns the field path of an ident, member or literal index chain
+// (headers["Server"] => [headers Server]).
+func (n *node) path() ([]string, bool) {
+
+	switch n.kind {
+	case nodeIdent:
+		return []string{n.name}, true
+	case nodeMember:
+		parent, ok := n.args[0].path()
+		if !ok {
+			return nil, false
+		}
+		return append(parent, n.name), true
+	case nodeIndex:
+		parent, ok := n.args[0].path()
+		if !ok || n.args[1].kind != nodeLiteral {
+			return nil, false
+		}
+		switch key := n.args[1].value.(type) {
+		case string:
+			return append(parent, key), true
+		case float64:
+			return append(parent, fmt.Sprint(key)), true
+		}
+	}
+	return nil, false
+}
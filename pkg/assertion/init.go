@@ -8,24 +8,63 @@ import (
 	"strings"
 	"time"
 
+	"github.com/vincoll/vigie/pkg/assertion/expr"
+	"github.com/vincoll/vigie/pkg/probe"
 	"github.com/vincoll/vigie/pkg/utils"
 )
 
+// AssertionError is returned when a raw assertion cannot be imported.
+// Index locates it among the assertions of its TestStep.
+type AssertionError struct {
+	Assertion string
+	Index     int // Position of the raw assertion in its list
+	Err       error
+}
+
+func (e *AssertionError) Error() string {
+	return fmt.Sprintf("assertion %q: %s", e.Assertion, e.Err)
+}
+
+func (e *AssertionError) Unwrap() error {
+	return e.Err
+}
+
 // GetCleanAsserts returns a structured TestStep Assertion Slice
 // from raw assert string.
-// this assertion slice has been validate.
-func GetCleanAsserts(rawAsserts []string) ([]Assert, error) {
+// this assertion slice has been validate against the probe answer schema.
+func GetCleanAsserts(rawAsserts []RawAssert, schema *probe.Schema) ([]Assert, error) {
 
 	nAssertions := make([]Assert, 0, len(rawAsserts))
 
-	for _, rawAssert := range rawAsserts {
-		// initAssert format les assertions sous forme d'une simple liste aisement traitable par la suite
+	for i, raw := range rawAsserts {
+
+		rawAssert := raw.Assertion
+		severity, err := ParseSeverity(raw.Severity)
+		if err != nil {
+			return nil, &AssertionError{Assertion: rawAssert, Index: i, Err: err}
+		}
+
+		// "expr:" assertion, compiled and checked against the probe answer
+		if src := strings.TrimSpace(rawAssert); strings.HasPrefix(src, Expression.Symbol) {
+			asrt, err := initExprAssert(strings.TrimPrefix(src, Expression.Symbol), schema)
+			if err != nil {
+				return nil, &AssertionError{Assertion: rawAssert, Index: i, Err: err}
+			}
+			asrt.Severity = severity
+			nAssertions = append(nAssertions, asrt)
+			continue
+		}
 
+		// initAssert format les assertions sous forme d'une simple liste aisement traitable par la suite
 		nAssert, err := initAssert(rawAssert)
 		if err != nil {
-			return nil, fmt.Errorf("cannot import assertion : %s", err)
+			return nil, &AssertionError{Assertion: rawAssert, Index: i, Err: err}
 		}
 		for _, asrt := range nAssert {
+			if err := asrt.Validate(schema); err != nil {
+				return nil, &AssertionError{Assertion: rawAssert, Index: i, Err: err}
+			}
+			asrt.Severity = severity
 			nAssertions = append(nAssertions, asrt)
 		}
 	}
@@ -33,6 +72,105 @@ func GetCleanAsserts(rawAsserts []string) ([]Assert, error) {
 	return nAssertions, nil
 }
 
+// initExprAssert compiles an expression assertion,
+// the fields used are checked with the probe answer schema.
+func initExprAssert(src string, schema *probe.Schema) (Assert, error) {
+
+	var resolve expr.Resolver
+	if schema != nil {
+		resolve = func(path []string) (expr.Type, bool) {
+			for i, p := range path {
+				path[i] = strings.Replace(p, ".", `\.`, -1)
+			}
+			field, found := schema.Lookup(strings.Join(path, "."))
+			if !found {
+				return expr.Any, false
+			}
+			return exprType(field.Type), true
+		}
+	}
+
+	program, err := expr.Compile(src, resolve)
+	if err != nil {
+		return Assert{}, fmt.Errorf("invalid expression: %s", err)
+	}
+
+	return Assert{
+		Method:     Expression,
+		Expression: program.String(),
+		program:    program,
+	}, nil
+}
+
+func exprType(ft probe.FieldType) expr.Type {
+	switch ft {
+	case probe.FieldString, probe.FieldTime:
+		return expr.String
+	case probe.FieldNumber:
+		return expr.Number
+	case probe.FieldBool:
+		return expr.Bool
+	case probe.FieldDuration:
+		return expr.Duration
+	case probe.FieldArray:
+		return expr.Array
+	case probe.FieldObject:
+		return expr.Object
+	default:
+		return expr.Any
+	}
+}
+
+// Validate checks the assertion against the answer schema of a probe:
+// the key must exist and the method must be usable with the field type.
+func (a Assert) Validate(schema *probe.Schema) error {
+
+	if schema == nil || a.Expression != "" {
+		return nil
+	}
+
+	field, found := schema.Lookup(a.Key)
+	if !found {
+		return fmt.Errorf("key %q does not exist in the probe answer (available keys: %s)", a.Key, strings.Join(schema.Keys(), ", "))
+	}
+
+	switch {
+	case field.Type == probe.FieldAny:
+		return nil
+
+	case a.Method.IsNumericType:
+		switch field.Type {
+		case probe.FieldNumber, probe.FieldDuration, probe.FieldTime:
+			return nil
+		case probe.FieldString:
+			// A string can hold a value with a unit (headers.Content-Length < 2MiB)
+			if a.Typed.Type != Untyped {
+				return nil
+			}
+		}
+
+	case a.Method.IsContainType, a.Values != nil:
+		if field.Type == probe.FieldArray {
+			return nil
+		}
+
+	default:
+		// Equality on a JSON object
+		if _, isObject := a.Value.(map[string]interface{}); isObject {
+			if field.Type == probe.FieldObject {
+				return nil
+			}
+			break
+		}
+		// Equality on a single value
+		if field.Type != probe.FieldArray && field.Type != probe.FieldObject {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s cannot be used on %q which is a %s", a.Method.LongName, a.Key, field.Type)
+}
+
 // Fonctionement moyen, conversion à chier : va falloir trancher dans les régles d'import des Assertions !
 func initAssert(rawAssert string) ([]Assert, error) {
 
@@ -79,6 +217,7 @@ func initAssert(rawAssert string) ([]Assert, error) {
 			// Faut-il re switch dans le cas ou l'utilisateur présente sciemment des strings ??
 			if utils.IsDuration(aStrValue) {
 				asrt.Value, _ = time.ParseDuration(aStrValue)
+				asrt.Typed = TypedValue{Type: DurationValue, Raw: aStrValue}
 			} else {
 				asrt.Value = aStrValue
 			}
@@ -94,11 +233,14 @@ func initAssert(rawAssert string) ([]Assert, error) {
 			return allAsserts, nil
 		}
 
-	case utils.IsDuration(aVal):
+		// Value with a unit: Duration (20ms), Byte Size (10MB, 2MiB),
+		// Percentage (1%), Timestamp (now+30d, 2021-01-02T15:04:05Z)
+	case isTypedValue(aVal):
 		{
-			asrt.Value, _ = time.ParseDuration(aVal)
-			// Add type hint for assert printing (1s, 20ms format)
-			asrt.Method.IsDuration = true
+			asrt.Value, asrt.Typed, _ = parseTypedValue(aVal)
+			if asrt.Method.IsContainType && asrt.Typed.Type != DurationValue {
+				return nil, fmt.Errorf("%s assertion cannot be used with a %s value %q", asrt.Method.LongName, asrt.Typed.Type, aVal)
+			}
 			allAsserts = append(allAsserts, asrt)
 			return allAsserts, nil
 		}
@@ -149,6 +291,21 @@ func initAssert(rawAssert string) ([]Assert, error) {
 				return allAsserts, nil
 			}
 		}
+		// JSON Object, only for an equality (bodyjson == {"status": "ok"})
+	case strings.HasPrefix(aVal, "{"):
+		{
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(aVal), &obj); err != nil {
+				return nil, fmt.Errorf("cannot unmarshall %q: %s", aVal, err)
+			}
+			if asrt.Method.ShortName != Equal.ShortName && asrt.Method.ShortName != OrderedEqual.ShortName {
+				return nil, fmt.Errorf("%s assertion cannot be used with a JSON object", asrt.Method.LongName)
+			}
+			asrt.Value = obj
+			allAsserts = append(allAsserts, asrt)
+			return allAsserts, nil
+		}
+
 		// Nested Array
 	case utils.IsNestedArray(aVal) && utils.IsArray(aVal):
 		{
@@ -0,0 +1,91 @@
+package assertion
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Severity of a failed assertion.
+// Only a Critical failure sets the TestStep in failure,
+// a Warning failure sets the TestStep in warning,
+// an Info failure is only reported.
+type Severity int8
+
+const (
+	Critical Severity = iota
+	Warning
+	Info
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Info:
+		return "info"
+	default:
+		return "critical"
+	}
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Severity) UnmarshalJSON(data []byte) error {
+
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	sev, err := ParseSeverity(str)
+	if err != nil {
+		return err
+	}
+	*s = sev
+	return nil
+}
+
+// ParseSeverity returns the Severity from its name, empty is Critical.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "critical":
+		return Critical, nil
+	case "warning":
+		return Warning, nil
+	case "info":
+		return Info, nil
+	default:
+		return Critical, fmt.Errorf("unknown severity %q (critical, warning, info)", s)
+	}
+}
+
+// RawAssert is an assertion as written in a test file,
+// either a simple string or an object with a severity:
+//
+//	assertions:
+//	  - httpcode == 200
+//	  - assertion: endcertificate.daybeforeexpiration > 30
+//	    severity: warning
+type RawAssert struct {
+	Assertion string `json:"assertion"`
+	Severity  string `json:"severity"`
+}
+
+func (ra *RawAssert) UnmarshalJSON(data []byte) error {
+
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*ra = RawAssert{Assertion: str}
+		return nil
+	}
+
+	type rawAssert RawAssert
+	var obj rawAssert
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("an assertion must be a string or an object {assertion, severity}: %s", err)
+	}
+	*ra = RawAssert(obj)
+	return nil
+}
@@ -0,0 +1,59 @@
+package assertion
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRawAssert_UnmarshalJSON(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		data    string
+		want    RawAssert
+		wantErr bool
+	}{
+		{name: "string", data: `"httpcode == 200"`, want: RawAssert{Assertion: "httpcode == 200"}},
+		{name: "object", data: `{"assertion": "httpcode == 200", "severity": "warning"}`, want: RawAssert{Assertion: "httpcode == 200", Severity: "warning"}},
+		{name: "object_without_severity", data: `{"assertion": "httpcode == 200"}`, want: RawAssert{Assertion: "httpcode == 200"}},
+		{name: "number", data: `200`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got RawAssert
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("UnmarshalJSON() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+
+	tests := []struct {
+		severity string
+		want     Severity
+		wantErr  bool
+	}{
+		{severity: "", want: Critical},
+		{severity: "critical", want: Critical},
+		{severity: "Warning", want: Warning},
+		{severity: "info", want: Info},
+		{severity: "major", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			got, err := ParseSeverity(tt.severity)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeverity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeverity() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,7 +12,6 @@ type AssertMethod struct {
 	IsEqualType   bool
 	IsContainType bool
 	IsOrdered     bool
-	IsDuration    bool
 	AssertFunc    func(actualValue interface{}, actualValues []string, expectValue interface{}, expectValueValues []string) (bool, string) `hash:"ignore"`
 }
 
@@ -26,6 +25,11 @@ var (
 	LessThanOrEq    = AssertMethod{AssertFunc: assertion.LessThanOrEq, LongName: "LessThanOrEqual", ShortName: "LTE", Symbol: "<=", IsContainType: false, IsNumericType: true}
 	GreaterThanOrEq = AssertMethod{AssertFunc: assertion.GreaterThanOrEq, LongName: "GreaterThanOrEqual", ShortName: "GTE", Symbol: ">=", IsContainType: false, IsNumericType: true}
 )
+
+// Expression is the method of the "expr:" assertions,
+// the whole assertion is an expression evaluated over the probe answer.
+var Expression = AssertMethod{LongName: "Expression", ShortName: "EXPR", Symbol: "expr:"}
+
 var NewAliasAsserts = []*AssertMethod{
 	&Equal,
 	&NotEqual,
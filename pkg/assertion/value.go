@@ -0,0 +1,245 @@
+package assertion
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+// ValueType is the unit type of an expected value, detected when the assertion is imported.
+// Untyped values (strings, numbers, bools, arrays) are asserted as is.
+type ValueType int8
+
+const (
+	Untyped ValueType = iota
+	DurationValue
+	ByteSizeValue
+	PercentValue
+	TimeValue
+)
+
+func (vt ValueType) String() string {
+	switch vt {
+	case DurationValue:
+		return "duration"
+	case ByteSizeValue:
+		return "bytesize"
+	case PercentValue:
+		return "percent"
+	case TimeValue:
+		return "time"
+	default:
+		return "untyped"
+	}
+}
+
+// TypedValue keeps how an expected value has been written in the assertion,
+// probe values are converted and printed back in the same unit.
+type TypedValue struct {
+	Type     ValueType
+	Raw      string        // Value as written in the assertion (10MB, 1%, now+30d)
+	Unit     string        // Unit used in the assertion (MB, MiB, %)
+	Relative bool          // Timestamp relative to the time of the assertion (now+30d)
+	Offset   time.Duration // Offset of a relative timestamp
+}
+
+var byteUnits = map[string]float64{
+	"B":   1,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"PB":  1e15,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+	"PiB": 1 << 50,
+}
+
+var (
+	byteSizeRE = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s?([a-zA-Z]+)$`)
+	percentRE  = regexp.MustCompile(`^(-?[0-9]+(?:\.[0-9]+)?)\s?%$`)
+	relTimeRE  = regexp.MustCompile(`^now(?:\s?([+-])\s?([0-9a-z.]+))?$`)
+)
+
+// parseTypedValue detects if s is a value with a unit
+// (duration, byte size, percentage or timestamp) and returns
+// its numerical value to assert.
+func parseTypedValue(s string) (interface{}, TypedValue, error) {
+
+	tv := TypedValue{Raw: s}
+
+	// Duration: 20ms, 1s, 30d
+	if d, err := parseDuration(s); err == nil {
+		tv.Type = DurationValue
+		return d, tv, nil
+	}
+
+	// Byte Size: 10MB, 2MiB
+	if size, unit, err := parseByteSize(s); err == nil {
+		tv.Type = ByteSizeValue
+		tv.Unit = unit
+		return size, tv, nil
+	}
+
+	// Percentage: 1%, 99.9%
+	if m := percentRE.FindStringSubmatch(s); m != nil {
+		pct, _ := strconv.ParseFloat(m[1], 64)
+		tv.Type = PercentValue
+		tv.Unit = "%"
+		return pct, tv, nil
+	}
+
+	// Timestamp relative to now: now, now+30d, now-1h
+	if m := relTimeRE.FindStringSubmatch(s); m != nil {
+		tv.Type = TimeValue
+		tv.Relative = true
+		if m[2] != "" {
+			offset, err := parseDuration(m[2])
+			if err != nil {
+				return nil, tv, fmt.Errorf("invalid relative time %q: %s", s, err)
+			}
+			if m[1] == "-" {
+				offset = -offset
+			}
+			tv.Offset = offset
+		}
+		return nil, tv, nil
+	}
+
+	// Absolute timestamp: 2021-01-02T15:04:05Z, 2021-01-02
+	if t, err := parseTime(s); err == nil {
+		tv.Type = TimeValue
+		return float64(t.UnixNano()), tv, nil
+	}
+
+	return nil, tv, fmt.Errorf("%q is not a typed value", s)
+}
+
+// isTypedValue tells if s is a value with a unit.
+func isTypedValue(s string) bool {
+	_, _, err := parseTypedValue(s)
+	return err == nil
+}
+
+// parseDuration accepts Go durations (1h30m) and the longer units (d, w, y).
+func parseDuration(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	return utils.ParseDuration(s)
+}
+
+// parseByteSize returns the number of bytes of s and its normalized unit.
+// SI units (KB, MB) are power of 1000, IEC units (KiB, MiB) power of 1024.
+func parseByteSize(s string) (float64, string, error) {
+
+	m := byteSizeRE.FindStringSubmatch(s)
+	if m == nil {
+		return 0, "", fmt.Errorf("%q is not a byte size", s)
+	}
+
+	unit := strings.Replace(strings.ToUpper(m[2]), "IB", "iB", 1)
+	factor, ok := byteUnits[unit]
+	if !ok {
+		return 0, "", fmt.Errorf("unknown byte size unit %q", m[2])
+	}
+	num, _ := strconv.ParseFloat(m[1], 64)
+	return num * factor, unit, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a timestamp", s)
+}
+
+// expected returns the value to assert at time now.
+// Relative timestamps are resolved at each assertion.
+func (tv TypedValue) expected(value interface{}, now time.Time) interface{} {
+	if tv.Type == TimeValue && tv.Relative {
+		return float64(now.Add(tv.Offset).UnixNano())
+	}
+	return value
+}
+
+// convert returns the probe value in the same unit as the expected value.
+// Unconvertible values are returned as is, the assertion will then fail.
+func (tv TypedValue) convert(probeValue interface{}) interface{} {
+
+	str, isStr := probeValue.(string)
+
+	switch tv.Type {
+	case ByteSizeValue:
+		if isStr {
+			if size, _, err := parseByteSize(str); err == nil {
+				return size
+			}
+			// Number of bytes without unit (headers.Content-Length)
+			if num, err := strconv.ParseFloat(str, 64); err == nil {
+				return num
+			}
+		}
+	case PercentValue:
+		if isStr {
+			if m := percentRE.FindStringSubmatch(str); m != nil {
+				pct, _ := strconv.ParseFloat(m[1], 64)
+				return pct
+			}
+			if num, err := strconv.ParseFloat(str, 64); err == nil {
+				return num
+			}
+		}
+	case TimeValue:
+		if isStr {
+			if t, err := parseTime(str); err == nil {
+				return float64(t.UnixNano())
+			}
+			return probeValue
+		}
+		// Numerical timestamps are Unix epoch in seconds
+		if sec, err := utils.GetFloat(probeValue); err == nil {
+			return sec * float64(time.Second)
+		}
+	}
+	return probeValue
+}
+
+// format prints a probe value (already converted) in the unit of the expected value.
+func (tv TypedValue) format(v interface{}) string {
+
+	num, err := utils.GetFloat(v)
+	if err != nil {
+		if d, isDur := v.(time.Duration); isDur {
+			num = float64(d)
+		} else {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+
+	switch tv.Type {
+	case DurationValue:
+		return time.Duration(num).String()
+	case ByteSizeValue:
+		return formatNum(num/byteUnits[tv.Unit]) + tv.Unit
+	case PercentValue:
+		return formatNum(num) + "%"
+	case TimeValue:
+		return time.Unix(0, int64(num)).UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func formatNum(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
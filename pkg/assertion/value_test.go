@@ -0,0 +1,95 @@
+package assertion
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTypedValue(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		raw      string
+		wantType ValueType
+		want     interface{}
+		wantUnit string
+		wantErr  bool
+	}{
+		{name: "duration", raw: "20ms", wantType: DurationValue, want: 20 * time.Millisecond},
+		{name: "duration_days", raw: "30d", wantType: DurationValue, want: 30 * 24 * time.Hour},
+		{name: "bytes_si", raw: "10MB", wantType: ByteSizeValue, want: float64(10e6), wantUnit: "MB"},
+		{name: "bytes_iec", raw: "2MiB", wantType: ByteSizeValue, want: float64(2 << 20), wantUnit: "MiB"},
+		{name: "bytes_lowercase", raw: "512kb", wantType: ByteSizeValue, want: float64(512e3), wantUnit: "KB"},
+		{name: "percent", raw: "1%", wantType: PercentValue, want: float64(1), wantUnit: "%"},
+		{name: "percent_decimal", raw: "99.9%", wantType: PercentValue, want: 99.9, wantUnit: "%"},
+		{name: "time_absolute", raw: "2021-01-02T15:04:05Z", wantType: TimeValue, want: float64(time.Date(2021, 1, 2, 15, 4, 5, 0, time.UTC).UnixNano())},
+		{name: "time_relative", raw: "now+30d", wantType: TimeValue, want: nil},
+		{name: "unknown_unit", raw: "10XB", wantErr: true},
+		{name: "number", raw: "10", wantErr: true},
+		{name: "string", raw: "hello", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, tv, err := parseTypedValue(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTypedValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseTypedValue() got = %v, want %v", got, tt.want)
+			}
+			if tv.Type != tt.wantType {
+				t.Errorf("parseTypedValue() type = %v, want %v", tv.Type, tt.wantType)
+			}
+			if tv.Unit != tt.wantUnit {
+				t.Errorf("parseTypedValue() unit = %v, want %v", tv.Unit, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestTypedValueExpected(t *testing.T) {
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, tv, _ := parseTypedValue("now+30d")
+	got := tv.expected(nil, now)
+	want := float64(now.Add(30 * 24 * time.Hour).UnixNano())
+	if got != want {
+		t.Errorf("expected() got = %v, want %v", got, want)
+	}
+
+	_, tv, _ = parseTypedValue("now-1h")
+	got = tv.expected(nil, now)
+	want = float64(now.Add(-time.Hour).UnixNano())
+	if got != want {
+		t.Errorf("expected() got = %v, want %v", got, want)
+	}
+}
+
+func TestTypedValueFormat(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		raw        string
+		probeValue interface{}
+		want       string
+	}{
+		{name: "duration", raw: "1s", probeValue: float64(1500 * time.Millisecond), want: "1.5s"},
+		{name: "bytes_iec", raw: "2MiB", probeValue: float64(3 << 20), want: "3MiB"},
+		{name: "bytes_si", raw: "10MB", probeValue: "12.5MB", want: "12.5MB"},
+		{name: "percent", raw: "1%", probeValue: 1.234, want: "1.23%"},
+		{name: "time", raw: "now", probeValue: "2021-01-02T15:04:05Z", want: "2021-01-02T15:04:05Z"},
+		{name: "time_epoch", raw: "now", probeValue: float64(1609459200), want: "2021-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, tv, _ := parseTypedValue(tt.raw)
+			if got := tv.format(tv.convert(tt.probeValue)); got != tt.want {
+				t.Errorf("format() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,12 +9,13 @@ import (
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	log "github.com/sirupsen/logrus"
 	"github.com/vincoll/vigie/pkg/ha"
-	"github.com/vincoll/vigie/pkg/probe/probetable"
 	"github.com/vincoll/vigie/pkg/teststruct"
 	"github.com/vincoll/vigie/pkg/utils"
 	"github.com/vincoll/vigie/pkg/utils/timeutils"
 	"net/http"
 	"os"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -23,13 +24,15 @@ type ImportManager struct {
 	git           ConfGit
 	testFiles     ConfTestfiles
 	variables     ConfVariables
+	templates     ConfTemplates
 	ConsulClient  *ha.ConsulClient
 	OutgoingTests chan map[uint64]*teststruct.TestSuite
+	state         *importState
 }
 
 func InitImportManager(ci ConfImport, cc *ha.ConsulClient) (ImportManager, error) {
 
-	impMgr := ImportManager{}
+	impMgr := ImportManager{state: newImportState()}
 
 	// Validator
 	if ci.Testfiles.Included == nil || len(ci.Testfiles.Included) == 0 {
@@ -57,6 +60,7 @@ func InitImportManager(ci ConfImport, cc *ha.ConsulClient) (ImportManager, error
 	impMgr.git = ci.Git
 	impMgr.testFiles = ci.Testfiles
 	impMgr.variables = ci.Variables
+	impMgr.templates = ci.Templates
 	// ImportManager will be able to
 	impMgr.ConsulClient = cc
 
@@ -65,7 +69,7 @@ func InitImportManager(ci ConfImport, cc *ha.ConsulClient) (ImportManager, error
 
 func InitImportManager2(ci ConfImport) (ImportManager, error) {
 
-	impMgr := ImportManager{}
+	impMgr := ImportManager{state: newImportState()}
 
 	// Validator
 	if ci.Testfiles.Included == nil || len(ci.Testfiles.Included) == 0 {
@@ -93,6 +97,7 @@ func InitImportManager2(ci ConfImport) (ImportManager, error) {
 	impMgr.git = ci.Git
 	impMgr.testFiles = ci.Testfiles
 	impMgr.variables = ci.Variables
+	impMgr.templates = ci.Templates
 
 	return impMgr, nil
 }
@@ -124,7 +129,9 @@ func (im *ImportManager) reloadTicker() {
 			case <-importTicker.C:
 				TSs, err := f()
 				if err != nil {
+					// Keep the running TestSuites
 					utils.Log.Errorf("Error while loading TestSuites: %s", err)
+					continue
 				}
 				im.OutgoingTests <- TSs
 			}
@@ -147,7 +154,7 @@ func (im *ImportManager) LoadKVTestSuites() (map[uint64]*teststruct.TestSuite, e
 
 	start := time.Now()
 
-	testsFiles, varsFiles, err := im.importFileandVars()
+	testsFiles, varsFiles, tplFiles, err := im.importFileandVars()
 	if err != nil {
 		return nil, err
 	} else {
@@ -160,36 +167,8 @@ func (im *ImportManager) LoadKVTestSuites() (map[uint64]*teststruct.TestSuite, e
 		}).Debugf("List new tests: %s", elapsed)
 	}
 
-	// Super important, TODO Comment visibility
-	probeTable := probetable.AvailableProbes
-
-	umt, err := NewUnMarshallTool(varsFiles, probeTable)
-	if err != nil {
-		return nil, err
-	}
-	newTSs := make(map[uint64]*teststruct.TestSuite, len(testsFiles))
-
 	start = time.Now()
-	// loop on each TestSuite files
-	for _, f := range testsFiles {
-
-		// importingFileToVigie each Tests Files as TestSuite
-		ts, err := umt.ImportTestSuite(f)
-		if err != nil {
-			utils.Log.WithFields(log.Fields{
-				"error": err.Error(),
-				"type":  "info",
-				"file":  f,
-			}).Error("Cannot load this file.")
-			return nil, fmt.Errorf("%s ", err.Error())
-
-		} else {
-			// After Validation : Append this Valid TestSuites to Vigie
-			ts.SourceFile = f
-			newTSs[ts.ID] = ts
-			utils.Log.WithFields(log.Fields{"file": f, "type": "info"}).Debug("Has been loaded.")
-		}
-	}
+	newTSs := im.importTestSuites(testsFiles, varsFiles, tplFiles)
 
 	elapsed := time.Since(start)
 
@@ -213,7 +192,7 @@ func (im *ImportManager) LoadTestSuites() (map[uint64]*teststruct.TestSuite, err
 
 	start := time.Now()
 
-	testsFiles, varsFiles, err := im.importFileandVars()
+	testsFiles, varsFiles, tplFiles, err := im.importFileandVars()
 	if err != nil {
 		return nil, err
 	} else {
@@ -226,56 +205,148 @@ func (im *ImportManager) LoadTestSuites() (map[uint64]*teststruct.TestSuite, err
 		}).Debugf("List new tests: %s", elapsed)
 	}
 
-	// Super important, TODO Comment visibility
-	probeTable := probetable.AvailableProbes
+	start = time.Now()
+	newTSs := im.importTestSuites(testsFiles, varsFiles, tplFiles)
 
-	umt, err := NewUnMarshallTool(varsFiles, probeTable)
-	if err != nil {
-		return nil, err
+	elapsed := time.Since(start)
+	utils.Log.WithFields(log.Fields{
+		"package": "load",
+		"desc":    "file import and test generation",
+		"type":    "perf_measurement",
+		"value":   elapsed.Seconds(),
+	}).Debugf("File import and test generation duration: %s", elapsed)
+
+	return newTSs, nil
+}
+
+// importTestSuites imports each TestSuite file, an invalid file keeps its
+// last valid version (if any) and its error is recorded for the API.
+func (im *ImportManager) importTestSuites(testsFiles, varsFiles, tplFiles []string) map[uint64]*teststruct.TestSuite {
+
+	allFiles := make([]string, 0, len(testsFiles)+len(varsFiles)+len(tplFiles))
+	allFiles = append(append(append(allFiles, testsFiles...), varsFiles...), tplFiles...)
+
+	im.state.begin()
+	defer im.state.commit(allFiles)
+
+	// Variables: the environment (if enabled), then the files merged in order
+	variables := make(map[string]interface{})
+	if im.variables.Fromenv {
+		for _, kv := range os.Environ() {
+			if i := strings.Index(kv, "="); i > 0 {
+				variables[kv[:i]] = kv[i+1:]
+			}
+		}
+	}
+	for _, f := range varsFiles {
+
+		vars, err := readVariableFile(f)
+		if err != nil {
+			lastVars, keep := im.state.lastVariables(f)
+			im.logFileError(f, err, keep)
+			im.state.fail(f, err, keep)
+			if !keep {
+				continue
+			}
+			vars = lastVars
+		} else {
+			im.state.setVariables(f, vars)
+		}
+
+		for key, value := range vars {
+			variables[key] = value
+		}
 	}
+
+	// Templates: a name can only be defined once
+	templates := make(teststruct.Templates)
+	definedIn := make(map[string]string)
+	for _, f := range tplFiles {
+
+		tpls, err := readTemplateFile(f)
+		if err != nil {
+			lastTpls, keep := im.state.lastTemplates(f)
+			im.logFileError(f, err, keep)
+			im.state.fail(f, err, keep)
+			if !keep {
+				continue
+			}
+			tpls = lastTpls
+		} else {
+			im.state.setTemplates(f, tpls)
+		}
+
+		for name, tpl := range tpls {
+			if other, exists := definedIn[name]; exists {
+				err := fmt.Errorf("template %q is already defined in %q", name, other)
+				im.logFileError(f, err, false)
+				im.state.fail(f, err, false)
+				continue
+			}
+			definedIn[name] = f
+			templates[name] = tpl
+		}
+	}
+	teststruct.SetSharedTemplates(templates)
+
+	umt := NewUnMarshallTool(variables)
 	newTSs := make(map[uint64]*teststruct.TestSuite, len(testsFiles))
 
-	start = time.Now()
 	// loop on each TestSuite files
 	for _, f := range testsFiles {
 
 		// importingFileToVigie each Tests Files as TestSuite
 		ts, err := umt.ImportTestSuite(f)
 		if err != nil {
-			utils.Log.WithFields(log.Fields{
-				"error": err.Error(),
-				"type":  "info",
-				"file":  f,
-			}).Error("Cannot load this file.")
-			return nil, fmt.Errorf("%s ", err.Error())
-
-		} else {
-			// After Validation : Append this Valid TestSuites to Vigie
-			ts.SourceFile = f
-			newTSs[ts.ID] = ts
-			utils.Log.WithFields(log.Fields{"file": f, "type": "info"}).Debug("Has been loaded.")
+			lastTS, keep := im.state.lastTestSuite(f)
+			im.logFileError(f, err, keep)
+			im.state.fail(f, err, keep)
+			if keep {
+				newTSs[lastTS.ID] = lastTS
+			}
+			continue
 		}
+
+		// After Validation : Append this Valid TestSuites to Vigie
+		ts.SourceFile = f
+		newTSs[ts.ID] = ts
+		im.state.setTestSuite(f, ts)
+		utils.Log.WithFields(log.Fields{"file": f, "type": "info"}).Debug("Has been loaded.")
 	}
 
-	elapsed := time.Since(start)
-	utils.Log.WithFields(log.Fields{
+	return newTSs
+}
+
+func (im *ImportManager) logFileError(file string, err error, keptLastGood bool) {
+
+	entry := utils.Log.WithFields(log.Fields{
 		"package": "load",
-		"desc":    "file import and test generation",
-		"type":    "perf_measurement",
-		"value":   elapsed.Seconds(),
-	}).Debugf("File import and test generation duration: %s", elapsed)
+		"error":   err.Error(),
+		"file":    file,
+	})
+	if keptLastGood {
+		entry.Error("Cannot load this file, its last valid version is kept.")
+	} else {
+		entry.Error("Cannot load this file, it is skipped.")
+	}
+}
 
-	return newTSs, nil
+// FileErrors returns the files which failed to be imported during the last reload.
+func (im *ImportManager) FileErrors() []FileError {
+	if im.state == nil {
+		return []FileError{}
+	}
+	return im.state.fileErrors()
 }
 
 // importFileandVars Charge la config d'un fichier vigieConf dans une instance Vigie
-// La fonction retourne tous les fichiers (test,vars) éligibles contenus dans les répertoires Tests et Vars
-func (im *ImportManager) importFileandVars() (testsFiles []string, varsFiles []string, err error) {
+// La fonction retourne tous les fichiers (test,vars,templates) éligibles contenus dans les répertoires Tests, Vars et Templates
+func (im *ImportManager) importFileandVars() (testsFiles []string, varsFiles []string, tplFiles []string, err error) {
 
 	if im.git.Clone {
 		errGit := im.cloneGitRepo(im.git)
 		if errGit != nil {
-			return nil, nil, fmt.Errorf("Failed to clone: %v", errGit)
+			return nil, nil, nil, fmt.Errorf("Failed to clone: %v", errGit)
 		}
 	}
 
@@ -286,13 +357,20 @@ func (im *ImportManager) importFileandVars() (testsFiles []string, varsFiles []s
 	// TODO: Gérer l'erreur ?
 	testsFiles, _ = getAllFilesInsideDir(im.testFiles.Included, im.testFiles.Excluded, defaultTestSuitePath)
 	if len(testsFiles) == 0 {
-		return nil, nil, fmt.Errorf("no files or path to import")
+		return nil, nil, nil, fmt.Errorf("no files or path to import")
 	}
 
 	// Add Var path for each file
 	varsFiles, _ = getAllFilesInsideDir(im.variables.Included, im.variables.Excluded, defaultVariablePath)
 
-	return testsFiles, varsFiles, nil
+	// Add Template path for each file
+	tplFiles, _ = getAllFilesInsideDir(im.templates.Included, im.templates.Excluded, defaultTemplatePath)
+
+	// Same merge order at each reload
+	sort.Strings(varsFiles)
+	sort.Strings(tplFiles)
+
+	return testsFiles, varsFiles, tplFiles, nil
 }
 
 // cloneGitRepo clone a git repo containing the tests and vars
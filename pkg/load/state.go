@@ -0,0 +1,145 @@
+package load
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/teststruct"
+)
+
+// FileError is the import error of a TestSuite or a variables file.
+type FileError struct {
+	File         string    `json:"file"`
+	Error        string    `json:"error"`
+	Since        time.Time `json:"since"`        // First reload with this error
+	KeptLastGood bool      `json:"keptlastgood"` // The last valid version of this file is still used
+}
+
+// importState keeps the last valid version of each file
+// and the errors of the last reload.
+type importState struct {
+	mu        sync.RWMutex
+	lastTS    map[string]*teststruct.TestSuite
+	lastVars  map[string]map[string]interface{}
+	lastTpls  map[string]teststruct.Templates
+	errors    map[string]FileError
+	newErrors map[string]FileError
+}
+
+func newImportState() *importState {
+	return &importState{
+		lastTS:    make(map[string]*teststruct.TestSuite),
+		lastVars:  make(map[string]map[string]interface{}),
+		lastTpls:  make(map[string]teststruct.Templates),
+		errors:    make(map[string]FileError),
+		newErrors: make(map[string]FileError),
+	}
+}
+
+// begin starts a new reload, errors are collected until commit.
+func (is *importState) begin() {
+	is.mu.Lock()
+	is.newErrors = make(map[string]FileError)
+	is.mu.Unlock()
+}
+
+// commit replaces the errors of the previous reload by the new ones
+// and forgets the files which are no longer imported.
+func (is *importState) commit(files []string) {
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	present := make(map[string]bool, len(files))
+	for _, f := range files {
+		present[f] = true
+	}
+	for f := range is.lastTS {
+		if !present[f] {
+			delete(is.lastTS, f)
+		}
+	}
+	for f := range is.lastVars {
+		if !present[f] {
+			delete(is.lastVars, f)
+		}
+	}
+	for f := range is.lastTpls {
+		if !present[f] {
+			delete(is.lastTpls, f)
+		}
+	}
+
+	is.errors = is.newErrors
+	is.newErrors = make(map[string]FileError)
+}
+
+// fail records the error of a file, keptLastGood tells if its last valid version is still used.
+func (is *importState) fail(file string, err error, keptLastGood bool) {
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	fe := FileError{File: file, Error: err.Error(), Since: time.Now(), KeptLastGood: keptLastGood}
+	if prev, exists := is.errors[file]; exists {
+		fe.Since = prev.Since
+	}
+	is.newErrors[file] = fe
+}
+
+// lastTestSuite returns the last valid TestSuite of a file.
+func (is *importState) lastTestSuite(file string) (*teststruct.TestSuite, bool) {
+	is.mu.RLock()
+	ts, found := is.lastTS[file]
+	is.mu.RUnlock()
+	return ts, found
+}
+
+func (is *importState) setTestSuite(file string, ts *teststruct.TestSuite) {
+	is.mu.Lock()
+	is.lastTS[file] = ts
+	is.mu.Unlock()
+}
+
+// lastVariables returns the last valid variables of a file.
+func (is *importState) lastVariables(file string) (map[string]interface{}, bool) {
+	is.mu.RLock()
+	vars, found := is.lastVars[file]
+	is.mu.RUnlock()
+	return vars, found
+}
+
+func (is *importState) setVariables(file string, vars map[string]interface{}) {
+	is.mu.Lock()
+	is.lastVars[file] = vars
+	is.mu.Unlock()
+}
+
+// lastTemplates returns the last valid templates of a file.
+func (is *importState) lastTemplates(file string) (teststruct.Templates, bool) {
+	is.mu.RLock()
+	tpls, found := is.lastTpls[file]
+	is.mu.RUnlock()
+	return tpls, found
+}
+
+func (is *importState) setTemplates(file string, tpls teststruct.Templates) {
+	is.mu.Lock()
+	is.lastTpls[file] = tpls
+	is.mu.Unlock()
+}
+
+// fileErrors returns the errors of the last reload sorted by file.
+func (is *importState) fileErrors() []FileError {
+
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+
+	fes := make([]FileError, 0, len(is.errors))
+	for _, fe := range is.errors {
+		fes = append(fes, fe)
+	}
+	sort.Slice(fes, func(i, j int) bool { return fes[i].File < fes[j].File })
+	return fes
+}
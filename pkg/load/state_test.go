@@ -0,0 +1,101 @@
+package load
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/teststruct"
+)
+
+func TestImportState(t *testing.T) {
+
+	is := newImportState()
+	ts := &teststruct.TestSuite{Name: "web"}
+
+	// First reload: web.yml is valid, db.yml is invalid
+	is.begin()
+	is.setTestSuite("web.yml", ts)
+	is.fail("db.yml", fmt.Errorf("name is missing or empty"), false)
+	is.commit([]string{"web.yml", "db.yml"})
+
+	fes := is.fileErrors()
+	if len(fes) != 1 || fes[0].File != "db.yml" || fes[0].KeptLastGood {
+		t.Fatalf("fileErrors() = %+v, want an error for db.yml", fes)
+	}
+	since := fes[0].Since
+
+	// Second reload: web.yml becomes invalid, db.yml is still invalid
+	time.Sleep(time.Millisecond)
+	is.begin()
+	last, found := is.lastTestSuite("web.yml")
+	if !found || last != ts {
+		t.Fatalf("lastTestSuite() = %v, %t, want the last valid TestSuite", last, found)
+	}
+	is.fail("web.yml", fmt.Errorf("invalid"), true)
+	is.fail("db.yml", fmt.Errorf("name is missing or empty"), false)
+	is.commit([]string{"web.yml", "db.yml"})
+
+	fes = is.fileErrors()
+	if len(fes) != 2 || fes[0].File != "db.yml" || fes[1].File != "web.yml" || !fes[1].KeptLastGood {
+		t.Fatalf("fileErrors() = %+v, want errors for db.yml and web.yml", fes)
+	}
+	if !fes[0].Since.Equal(since) {
+		t.Errorf("Since of db.yml = %s, want %s (first reload with this error)", fes[0].Since, since)
+	}
+
+	// Third reload: db.yml is fixed, web.yml has been removed
+	is.begin()
+	is.commit([]string{"db.yml"})
+
+	if fes := is.fileErrors(); len(fes) != 0 {
+		t.Errorf("fileErrors() = %+v, want no error", fes)
+	}
+	if _, found := is.lastTestSuite("web.yml"); found {
+		t.Errorf("lastTestSuite() of a removed file is still kept")
+	}
+}
+
+func TestReadVariableFile(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "vigievars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "yaml", file: "vars.yml", content: "hosts:\n  - a.com\n  - b.com\nport: 443\n", want: map[string]interface{}{"hosts": []interface{}{"a.com", "b.com"}, "port": float64(443)}},
+		{name: "json", file: "vars.json", content: `{"hosts": ["a.com"], "db": {"tls": true}}`, want: map[string]interface{}{"hosts": []interface{}{"a.com"}, "db": map[string]interface{}{"tls": true}}},
+		{name: "invalid", file: "bad.json", content: `{"hosts": "a.com"`, wantErr: true},
+		{name: "extension", file: "vars.txt", content: "hosts", wantErr: true},
+		{name: "missing", file: "missing.yml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if tt.content != "" {
+				if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := readVariableFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readVariableFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readVariableFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package load
 
 const defaultTestSuitePath = "test/testsuite"
 const defaultVariablePath = "test/variable"
+const defaultTemplatePath = "test/template"
 
 const consulTestScheduling = "service/vigie/testscheduling"
 
@@ -12,6 +13,7 @@ type ConfImport struct {
 	Git       ConfGit
 	Testfiles ConfTestfiles
 	Variables ConfVariables
+	Templates ConfTemplates
 }
 
 type ConfGit struct {
@@ -36,3 +38,8 @@ type ConfVariables struct {
 	Excluded  []string `toml:"excluded"`
 	Fromenv   bool     `toml:"fromenv"`
 }
+
+type ConfTemplates struct {
+	Included []string `toml:"included"`
+	Excluded []string `toml:"excluded"`
+}
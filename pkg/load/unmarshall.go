@@ -1,47 +1,50 @@
 package load
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ghodss/yaml"
-	"github.com/sirupsen/logrus"
-	"github.com/vincoll/vigie/pkg/probe"
 	"github.com/vincoll/vigie/pkg/teststruct"
 	"github.com/vincoll/vigie/pkg/utils"
+	yamlv3 "gopkg.in/yaml.v3"
 	"io/ioutil"
 	"path/filepath"
+	"strconv"
 )
 
 type unMarshallTool struct {
-	Variables map[string][]string
-	Probes    map[string]probe.Probe // Probe (Interface)
+	Variables map[string]interface{}
 }
 
-func NewUnMarshallTool(varPaths []string, probelist map[string]probe.Probe) (*unMarshallTool, error) {
+// NewUnMarshallTool returns an unMarshallTool using the variables
+// merged from all the valid variables files.
+func NewUnMarshallTool(variables map[string]interface{}) *unMarshallTool {
 	umt := unMarshallTool{
-		Variables: map[string][]string{},
-		Probes:    probelist,
-	}
-
-	// ConfImport Variables from var Paths
-	err := umt.importVariables(varPaths)
-	if err != nil {
-		return nil, err
+		Variables: variables,
 	}
 
 	// Global VAR
 	utils.ALLVARS = umt.Variables
 
-	return &umt, nil
+	return &umt
 }
 
 // ImportAllTestSuites returns a TestSuite Vigie Struct
 // ImportAllTestSuites unMarshall, apply any variables, validate data
 // on each sub TestSuite sub-element (TestCase and TestStep)
-func (umt *unMarshallTool) ImportTestSuite(tsFile string) (*teststruct.TestSuite, error) {
+func (umt *unMarshallTool) ImportTestSuite(tsFile string) (ts *teststruct.TestSuite, err error) {
+
+	// An invalid file must not stop Vigie
+	defer func() {
+		if r := recover(); r != nil {
+			ts, err = nil, fmt.Errorf("cannot unmarshall %q: %v", tsFile, r)
+		}
+	}()
 
 	// ConfImport TestSuites and Raw TC,TStep
-	ts, err := umt.unmarshalTestSuiteFile(tsFile)
+	ts, err = umt.unmarshalTestSuiteFile(tsFile)
 	if err != nil {
 		return nil, fmt.Errorf("cannot unmarshall %q: %s", tsFile, err.Error())
 	}
@@ -67,7 +70,7 @@ func (umt *unMarshallTool) unmarshalTestSuiteFile(file string) (*teststruct.Test
 		}
 		err = json.Unmarshal(jdat, &ts)
 		if err != nil {
-			return nil, fmt.Errorf("Cannot while unmarshal this file. Err: %v", err)
+			return nil, fmt.Errorf("Cannot while unmarshal this file. Err: %v", withLine(file, err, yamlLine(dat)))
 		}
 
 	case ".json":
@@ -75,7 +78,7 @@ func (umt *unMarshallTool) unmarshalTestSuiteFile(file string) (*teststruct.Test
 		// utilise une interface UnmarshalJSON afin d'init spécialiser l'objet fils en fonction du pére.
 		err = json.Unmarshal(dat, &ts)
 		if err != nil {
-			return nil, fmt.Errorf("Cannot while unmarshal this file. Err: %v", err)
+			return nil, fmt.Errorf("Cannot while unmarshal this file. Err: %v", withLine(file, err, jsonLine(dat)))
 		}
 
 	default:
@@ -86,33 +89,191 @@ func (umt *unMarshallTool) unmarshalTestSuiteFile(file string) (*teststruct.Test
 
 }
 
-func (umt *unMarshallTool) importVariables(varPaths []string) error {
+// withLine prefixes an error located in the TestSuite (teststruct.PathError)
+// with its file and line (file.yml:12). Other errors are returned as is.
+func withLine(file string, err error, lineOf func(path []string) int) error {
+
+	var pErr *teststruct.PathError
+	if !errors.As(err, &pErr) {
+		return err
+	}
+	if line := lineOf(pErr.Path); line > 0 {
+		return fmt.Errorf("%s:%d: %w", file, line, err)
+	}
+	return fmt.Errorf("%s: %w", file, err)
+}
 
-	for _, varFile := range varPaths {
+// yamlLine returns the line of a path in a YAML document, from the positions of its nodes.
+// A path partly found gives the line of its deepest element, 0 if none.
+func yamlLine(dat []byte) func(path []string) int {
+	return func(path []string) int {
 
-		varFileMap := make(map[string][]string)
-		bytes, err := ioutil.ReadFile(varFile)
-		if err != nil {
-			utils.Log.WithFields(logrus.Fields{}).Fatalf(err.Error())
+		var doc yamlv3.Node
+		if err := yamlv3.Unmarshal(dat, &doc); err != nil || len(doc.Content) == 0 {
+			return 0
 		}
 
-		switch filepath.Ext(varFile) {
-		case ".json":
-			err = json.Unmarshal(bytes, &varFileMap)
-		case ".yaml", ".yml":
-			err = yaml.Unmarshal(bytes, &varFileMap)
-		default:
-			//		log.Fatal("unsupported varFile format")
+		node, line := doc.Content[0], 0
+		for _, key := range path {
+			for node.Kind == yamlv3.AliasNode {
+				node = node.Alias
+			}
+
+			var next *yamlv3.Node
+			switch node.Kind {
+			case yamlv3.MappingNode:
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					if node.Content[i].Value == key {
+						next = node.Content[i+1]
+						break
+					}
+				}
+			case yamlv3.SequenceNode:
+				if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+					next = node.Content[i]
+				}
+			}
+			if next == nil {
+				break
+			}
+			node, line = next, next.Line
 		}
-		if err != nil {
-			utils.Log.WithFields(logrus.Fields{}).Fatalf(err.Error())
+		return line
+	}
+}
+
+// jsonLine returns the line of a path in a JSON document, from the offsets of its tokens.
+// A path partly found gives the line of its deepest element, 0 if none.
+func jsonLine(dat []byte) func(path []string) int {
+	return func(path []string) int {
 
+		dec := json.NewDecoder(bytes.NewReader(dat))
+		offset := int64(-1)
+		for depth := 0; ; depth++ {
+			start := dec.InputOffset()
+			if depth > 0 {
+				offset = start
+			}
+			if depth == len(path) {
+				break
+			}
+			if !seekJSON(dec, path[depth]) {
+				break
+			}
 		}
+		if offset < 0 {
+			return 0
+		}
+
+		// The offset is the end of the previous token: the value comes after the separators
+		for offset < int64(len(dat)) && bytes.IndexByte([]byte(" \t\r\n:,"), dat[offset]) >= 0 {
+			offset++
+		}
+		return bytes.Count(dat[:offset], []byte("\n")) + 1
+	}
+}
+
+// seekJSON reads the opening of an object or an array until its element key
+// (a key or an index), the decoder is then at the start of this element.
+func seekJSON(dec *json.Decoder, key string) bool {
+
+	tok, err := dec.Token()
+	if err != nil {
+		return false
+	}
 
-		for key, value := range varFileMap {
-			(umt.Variables)[key] = value
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return false
+			}
+			if k == key {
+				return true
+			}
+			if err := skipJSON(dec); err != nil {
+				return false
+			}
+		}
+	case json.Delim('['):
+		i, err := strconv.Atoi(key)
+		if err != nil {
+			return false
+		}
+		for n := 0; dec.More(); n++ {
+			if n == i {
+				return true
+			}
+			if err := skipJSON(dec); err != nil {
+				return false
+			}
 		}
 	}
-	return nil
+	return false
+}
+
+// skipJSON reads the next value of the decoder.
+func skipJSON(dec *json.Decoder) error {
+	var skipped json.RawMessage
+	return dec.Decode(&skipped)
+}
+
+// readVariableFile reads the variables of a JSON or YAML file,
+// a variable can be a string, a number, a boolean, a list or a map.
+func readVariableFile(varFile string) (map[string]interface{}, error) {
+
+	varFileMap := make(map[string]interface{})
+	bytes, err := ioutil.ReadFile(varFile)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := filepath.Ext(varFile); ext {
+	case ".json":
+		err = json.Unmarshal(bytes, &varFileMap)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(bytes, &varFileMap)
+	default:
+		return nil, fmt.Errorf("Unsupported variables file extension: %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshall %q: %s", varFile, err)
+	}
+
+	return varFileMap, nil
+}
+
+// readTemplateFile reads the templates of a JSON or YAML file:
+// a "templates" map of reusable steps by name.
+func readTemplateFile(tplFile string) (teststruct.Templates, error) {
+
+	var tplFileMap struct {
+		Templates teststruct.Templates `json:"templates"`
+	}
+
+	dat, err := ioutil.ReadFile(tplFile)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := filepath.Ext(tplFile); ext {
+	case ".json":
+	case ".yaml", ".yml":
+		dat, err = yaml.YAMLToJSON(dat)
+		if err != nil {
+			return nil, fmt.Errorf("Err %s while converting YAML to JSON", err)
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported templates file extension: %q", ext)
+	}
+
+	if err := json.Unmarshal(dat, &tplFileMap); err != nil {
+		return nil, fmt.Errorf("cannot unmarshall %q: %s", tplFile, err)
+	}
+	if len(tplFileMap.Templates) == 0 {
+		return nil, fmt.Errorf("no templates detected in %q", tplFile)
+	}
 
+	return tplFileMap.Templates, nil
 }
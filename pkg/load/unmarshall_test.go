@@ -0,0 +1,103 @@
+package load
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+// The invalid assertion "nokey == 1" is also the name of the first step
+const invalidSuiteYAML = `name: web
+config:
+  frequency:
+    http: 1m
+templates:
+  checked:
+    assertions:
+    - nokey == 2
+testcases:
+- name: api
+  steps:
+  - name: nokey == 1
+    probe: {type: http, url: "https://vigie.dev"}
+    assertions:
+    - httpcode == 200
+  - name: second
+    probe: {type: http, url: "https://vigie.dev"}
+    assertions:
+    - httpcode == 200
+    - nokey == 1
+`
+
+const invalidSuiteJSON = `{
+  "name": "web",
+  "config": {"frequency": {"http": "1m"}},
+  "testcases": [
+    {
+      "name": "api",
+      "steps": [
+        {"name": "nokey == 1", "probe": {"type": "http", "url": "https://vigie.dev"}, "assertions": ["httpcode == 200"]},
+        {
+          "name": "second",
+          "probe": {"type": "http", "url": "https://vigie.dev"},
+          "assertions": [
+            "httpcode == 200",
+            "nokey == 1"
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestImportTestSuite_line(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	dir, err := ioutil.TempDir("", "vigie-line")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{name: "yaml assertion", file: "web.yml", content: invalidSuiteYAML, want: "web.yml:20:"},
+		{name: "json assertion", file: "web.json", content: invalidSuiteJSON, want: "web.json:14:"},
+		{
+			name:    "yaml invalid probe",
+			file:    "probe.yml",
+			content: strings.Replace(invalidSuiteYAML, "{type: http, url: \"https://vigie.dev\"}\n    assertions:\n    - httpcode == 200\n  - name: second", "{type: nope}\n  - name: second", 1),
+			want:    "probe.yml:12:",
+		},
+		{
+			name:    "yaml assertion of a template",
+			file:    "template.yml",
+			content: strings.Replace(invalidSuiteYAML, "  - name: second\n", "  - name: second\n    extends: checked\n", 1),
+			want:    "template.yml:16:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, tt.file)
+			if err := ioutil.WriteFile(file, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewUnMarshallTool(nil).ImportTestSuite(file)
+			if err == nil {
+				t.Fatal("ImportTestSuite() expected an error")
+			}
+			if !strings.Contains(err.Error(), file[:len(file)-len(tt.file)]+tt.want) {
+				t.Errorf("ImportTestSuite() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
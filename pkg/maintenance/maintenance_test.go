@@ -0,0 +1,113 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow_Active(t *testing.T) {
+
+	nightly, err := WindowJSON{Name: "nightly", Cron: "0 2 * * *", Duration: "1h", Timezone: "UTC"}.ToWindow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migration, err := WindowJSON{Name: "migration", Start: "2021-03-01T10:00:00Z", End: "2021-03-01T12:00:00Z"}.ToWindow()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		window Window
+		now    time.Time
+		want   bool
+	}{
+		{name: "cron start", window: nightly, now: time.Date(2021, 3, 1, 2, 0, 0, 0, time.UTC), want: true},
+		{name: "cron within", window: nightly, now: time.Date(2021, 3, 1, 2, 59, 59, 0, time.UTC), want: true},
+		{name: "cron end", window: nightly, now: time.Date(2021, 3, 1, 3, 0, 0, 0, time.UTC), want: false},
+		{name: "cron before", window: nightly, now: time.Date(2021, 3, 1, 1, 59, 0, 0, time.UTC), want: false},
+		{name: "range within", window: migration, now: time.Date(2021, 3, 1, 11, 0, 0, 0, time.UTC), want: true},
+		{name: "range end", window: migration, now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "range before", window: migration, now: time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Active(tt.now); got != tt.want {
+				t.Errorf("Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowJSON_ToWindow(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		wj      WindowJSON
+		wantErr bool
+	}{
+		{name: "cron", wj: WindowJSON{Name: "a", Cron: "0 2 * * *", Duration: "2h"}},
+		{name: "range", wj: WindowJSON{Name: "a", Start: "2021-03-01T10:00:00Z", End: "2021-03-01T12:00:00Z"}},
+		{name: "no name", wj: WindowJSON{Cron: "0 2 * * *", Duration: "2h"}, wantErr: true},
+		{name: "cron without duration", wj: WindowJSON{Name: "a", Cron: "0 2 * * *"}, wantErr: true},
+		{name: "cron and range", wj: WindowJSON{Name: "a", Cron: "0 2 * * *", Duration: "2h", Start: "2021-03-01T10:00:00Z"}, wantErr: true},
+		{name: "end before start", wj: WindowJSON{Name: "a", Start: "2021-03-01T12:00:00Z", End: "2021-03-01T10:00:00Z"}, wantErr: true},
+		{name: "invalid timezone", wj: WindowJSON{Name: "a", Cron: "0 2 * * *", Duration: "2h", Timezone: "Mars/Olympus"}, wantErr: true},
+		{name: "empty", wj: WindowJSON{Name: "a"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.wj.ToWindow()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ToWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManager_Check(t *testing.T) {
+
+	m := Manager{silences: make(map[string]Silence)}
+	now := time.Now()
+
+	s, err := m.AddSilence(Silence{Matchers: map[string]string{"testsuite": "billing-*", "env": "prod"}, End: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pause, err := WindowJSON{Name: "migration", Start: now.Add(-time.Minute).Format(time.RFC3339), End: now.Add(time.Hour).Format(time.RFC3339), Pause: true}.ToWindow()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		windows []Window
+		want    Match
+		wantIn  bool
+	}{
+		{name: "silenced", labels: map[string]string{"testsuite": "billing-api", "env": "prod"}, want: Match{Reason: "silence:" + s.ID}, wantIn: true},
+		{name: "partial match", labels: map[string]string{"testsuite": "billing-api", "env": "dev"}},
+		{name: "missing label", labels: map[string]string{"testsuite": "billing-api"}},
+		{name: "step window", labels: map[string]string{}, windows: []Window{pause}, want: Match{Reason: "window:migration", Pause: true}, wantIn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, in := m.Check(tt.labels, tt.windows, time.Now())
+			if in != tt.wantIn || got != tt.want {
+				t.Errorf("Check() = %+v, %v, want %+v, %v", got, in, tt.want, tt.wantIn)
+			}
+		})
+	}
+
+	if err := m.ExpireSilence(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Silences()) != 0 {
+		t.Error("Silences() expected no silence after ExpireSilence")
+	}
+}
@@ -0,0 +1,183 @@
+package maintenance
+
+import (
+	"crypto/rand"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConfMaintenance are the global maintenance windows of vigie.toml, applied to every TestStep.
+type ConfMaintenance struct {
+	Windows []Window `toml:"windows"`
+}
+
+// Silence mutes the alerts of the TestSteps matching all its matchers until its end.
+// Matchers are labels: testsuite, testcase, teststep or a tag, values can be globs (billing-*).
+type Silence struct {
+	ID        string            `json:"id"`
+	Matchers  map[string]string `json:"matchers"`
+	Start     time.Time         `json:"start"`
+	End       time.Time         `json:"end"`
+	Comment   string            `json:"comment"`
+	CreatedBy string            `json:"createdby"`
+}
+
+// matches tells if the labels of a TestStep match the silence.
+func (s Silence) matches(labels map[string]string) bool {
+
+	for k, pattern := range s.Matchers {
+		value, found := labels[k]
+		if !found {
+			return false
+		}
+		if ok, err := path.Match(pattern, value); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Active tells if the silence is active at this time.
+func (s Silence) Active(now time.Time) bool {
+	return !now.Before(s.Start) && now.Before(s.End)
+}
+
+// Match is the reason why a TestStep is in maintenance.
+type Match struct {
+	Reason string // window:<name> or silence:<id>
+	Pause  bool   // The TestStep is not run
+}
+
+// Manager holds the global maintenance windows and the silences added at runtime.
+type Manager struct {
+	sync.RWMutex
+	windows  []Window
+	silences map[string]Silence
+}
+
+// Mgr is the maintenance manager of Vigie.
+var Mgr = Manager{silences: make(map[string]Silence)}
+
+// InitManager validates and loads the global maintenance windows.
+func InitManager(conf ConfMaintenance) error {
+
+	windows := make([]Window, 0, len(conf.Windows))
+	for _, w := range conf.Windows {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+		windows = append(windows, w)
+	}
+
+	Mgr.Lock()
+	Mgr.windows = windows
+	Mgr.Unlock()
+	return nil
+}
+
+// Windows returns the global maintenance windows.
+func (m *Manager) Windows() []Window {
+
+	m.RLock()
+	defer m.RUnlock()
+	return append([]Window(nil), m.windows...)
+}
+
+// AddSilence adds a silence, starting now if its start is not set.
+func (m *Manager) AddSilence(s Silence) (Silence, error) {
+
+	now := time.Now()
+	if s.Start.IsZero() {
+		s.Start = now
+	}
+	if !s.End.After(s.Start) {
+		return Silence{}, fmt.Errorf("the end of a silence must be after its start")
+	}
+	if !s.End.After(now) {
+		return Silence{}, fmt.Errorf("the silence is already over")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Silence{}, err
+	}
+	s.ID = id
+
+	m.Lock()
+	if m.silences == nil {
+		m.silences = make(map[string]Silence)
+	}
+	m.silences[s.ID] = s
+	m.Unlock()
+
+	return s, nil
+}
+
+// ExpireSilence removes a silence.
+func (m *Manager) ExpireSilence(id string) error {
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, found := m.silences[id]; !found {
+		return fmt.Errorf("silence %q does not exist", id)
+	}
+	delete(m.silences, id)
+	return nil
+}
+
+// Silences returns the active and upcoming silences, sorted by end.
+// The silences which are over are removed.
+func (m *Manager) Silences() []Silence {
+
+	now := time.Now()
+
+	m.Lock()
+	defer m.Unlock()
+
+	silences := make([]Silence, 0, len(m.silences))
+	for id, s := range m.silences {
+		if !now.Before(s.End) {
+			delete(m.silences, id)
+			continue
+		}
+		silences = append(silences, s)
+	}
+	sort.Slice(silences, func(i, j int) bool { return silences[i].End.Before(silences[j].End) })
+	return silences
+}
+
+// Check tells if a TestStep is in maintenance: one of its windows, a global window
+// or a silence matching its labels is active.
+func (m *Manager) Check(labels map[string]string, stepWindows []Window, now time.Time) (Match, bool) {
+
+	if w, active := ActiveWindow(stepWindows, now); active {
+		return Match{Reason: "window:" + w.Name, Pause: w.Pause}, true
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+
+	if w, active := ActiveWindow(m.windows, now); active {
+		return Match{Reason: "window:" + w.Name, Pause: w.Pause}, true
+	}
+
+	for _, s := range m.silences {
+		if s.Active(now) && s.matches(labels) {
+			return Match{Reason: "silence:" + s.ID}, true
+		}
+	}
+
+	return Match{}, false
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", b), nil
+}
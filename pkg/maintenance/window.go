@@ -0,0 +1,147 @@
+// Package maintenance holds the maintenance windows and the silences,
+// during which the failures of the TestSteps are not alerted.
+package maintenance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/utils/timeutils"
+)
+
+// Window is a maintenance window, recurrent (cron + duration) or absolute (start, end).
+// During a window the TestSteps keep running, or are paused,
+// and their failures are tagged maintenance instead of being alerted.
+type Window struct {
+	Name     string        `json:"name" toml:"name"`
+	Cron     string        `json:"cron,omitempty" toml:"cron"`         // Start of a recurrent window: "0 2 * * *"
+	Duration time.Duration `json:"duration,omitempty" toml:"duration"` // Duration of a recurrent window
+	Start    string        `json:"start,omitempty" toml:"start"`       // Absolute window: RFC3339
+	End      string        `json:"end,omitempty" toml:"end"`
+	Timezone string        `json:"timezone,omitempty" toml:"timezone"` // Timezone of the cron, Local by default
+	Pause    bool          `json:"pause,omitempty" toml:"pause"`       // The TestSteps are not run during the window
+
+	cron       timeutils.Cron
+	start, end time.Time
+	loc        *time.Location
+}
+
+// WindowJSON is a Window as written in a test file, with a duration string (2h, 30m).
+type WindowJSON struct {
+	Name     string `json:"name"`
+	Cron     string `json:"cron"`
+	Duration string `json:"duration"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+	Pause    bool   `json:"pause"`
+}
+
+// ToWindow validates a window of a test file.
+func (wj WindowJSON) ToWindow() (Window, error) {
+
+	w := Window{
+		Name:     wj.Name,
+		Cron:     wj.Cron,
+		Start:    wj.Start,
+		End:      wj.End,
+		Timezone: wj.Timezone,
+		Pause:    wj.Pause,
+	}
+	if wj.Duration != "" {
+		dur, err := time.ParseDuration(wj.Duration)
+		if err != nil {
+			return Window{}, fmt.Errorf("maintenance window %q: %s", wj.Name, err)
+		}
+		w.Duration = dur
+	}
+	if err := w.Validate(); err != nil {
+		return Window{}, err
+	}
+	return w, nil
+}
+
+// Validate checks the window and prepares it for Active.
+func (w *Window) Validate() error {
+
+	if w.Name == "" {
+		return fmt.Errorf("maintenance window name is missing")
+	}
+
+	w.loc = time.Local
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return fmt.Errorf("maintenance window %q: %s", w.Name, err)
+		}
+		w.loc = loc
+	}
+
+	switch {
+	case w.Cron != "" && (w.Start != "" || w.End != ""):
+		return fmt.Errorf("maintenance window %q: cron and start/end cannot be used together", w.Name)
+
+	case w.Cron != "":
+		if w.Duration <= 0 {
+			return fmt.Errorf("maintenance window %q: a cron window needs a duration", w.Name)
+		}
+		c, err := timeutils.ParseCron(w.Cron)
+		if err != nil {
+			return fmt.Errorf("maintenance window %q: %s", w.Name, err)
+		}
+		w.cron = c
+
+	case w.Start != "" && w.End != "":
+		var err error
+		if w.start, err = time.Parse(time.RFC3339, w.Start); err != nil {
+			return fmt.Errorf("maintenance window %q start: %s", w.Name, err)
+		}
+		if w.end, err = time.Parse(time.RFC3339, w.End); err != nil {
+			return fmt.Errorf("maintenance window %q end: %s", w.Name, err)
+		}
+		if !w.end.After(w.start) {
+			return fmt.Errorf("maintenance window %q: end must be after start", w.Name)
+		}
+
+	default:
+		return fmt.Errorf("maintenance window %q needs a cron and a duration or a start and an end", w.Name)
+	}
+
+	return nil
+}
+
+// Active tells if the window is active at this time.
+func (w Window) Active(now time.Time) bool {
+
+	if !w.cron.IsZero() {
+		// The last start of the window is within its duration
+		loc := w.loc
+		if loc == nil {
+			loc = time.Local
+		}
+		start := w.cron.Next(now.Add(-w.Duration).In(loc))
+		return !start.IsZero() && !start.After(now)
+	}
+
+	if !w.start.IsZero() {
+		return !now.Before(w.start) && now.Before(w.end)
+	}
+
+	return false
+}
+
+// Expired tells if an absolute window is over, it will never be active again.
+func (w Window) Expired(now time.Time) bool {
+	return !w.end.IsZero() && !now.Before(w.end)
+}
+
+// ActiveWindow returns the first active window at this time.
+func ActiveWindow(windows []Window, now time.Time) (Window, bool) {
+
+	for _, w := range windows {
+		if w.Active(now) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
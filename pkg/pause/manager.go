@@ -0,0 +1,247 @@
+package pause
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+// ConfPause is the file of vigie.toml keeping the pauses across restarts,
+// pauses.json in the temp folder of Vigie if not set.
+type ConfPause struct {
+	File string `toml:"file"`
+}
+
+// Pause stops the runs of a TestSuite, a TestCase or a TestStep by its UID (6, 6-87 or 6-87-230)
+// until it is resumed or its expiry.
+type Pause struct {
+	UID      string    `json:"uid"`
+	Reason   string    `json:"reason"`
+	PausedBy string    `json:"pausedby"`
+	Start    time.Time `json:"start"`
+	Expire   time.Time `json:"expire,omitempty"`
+}
+
+// Active tells if the pause is active at this time, a pause without expiry lasts until resumed.
+func (p Pause) Active(now time.Time) bool {
+	return p.Expire.IsZero() || now.Before(p.Expire)
+}
+
+// Manager holds the pauses, each change is saved into its file.
+type Manager struct {
+	sync.RWMutex
+	file   string
+	pauses map[string]Pause
+}
+
+// Mgr is the pause manager of Vigie.
+var Mgr = Manager{pauses: make(map[string]Pause)}
+
+// InitManager loads the pauses saved by a previous run, the pauses expired are dropped.
+func InitManager(conf ConfPause) error {
+
+	file := conf.File
+	if file == "" {
+		file = filepath.Join(utils.TEMPPATH, "pauses.json")
+	}
+
+	pauses, err := load(file)
+	if err != nil {
+		return err
+	}
+
+	Mgr.Lock()
+	Mgr.file = file
+	Mgr.pauses = pauses
+	Mgr.Unlock()
+	return nil
+}
+
+// load reads the pauses saved in the file, none if the file does not exist yet.
+func load(file string) (map[string]Pause, error) {
+
+	pauses := make(map[string]Pause)
+
+	raw, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return pauses, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the pauses: %s", err)
+	}
+
+	var saved []Pause
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		return nil, fmt.Errorf("cannot read the pauses from %s: %s", file, err)
+	}
+
+	now := time.Now()
+	for _, p := range saved {
+		if p.Active(now) {
+			pauses[p.UID] = p
+		}
+	}
+	return pauses, nil
+}
+
+// save writes the pauses into the file (m must be locked), a failure is logged:
+// the pauses still apply until a restart.
+func (m *Manager) save() {
+
+	if m.file == "" {
+		return
+	}
+	if err := write(m.file, m.pauses); err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"package": "pause", "file": m.file,
+		}).Errorf("Pauses will not survive a restart: %s", err)
+	}
+}
+
+// write saves the pauses through a temp file renamed, in order not to leave a truncated file.
+func write(file string, saved map[string]Pause) error {
+
+	pauses := make([]Pause, 0, len(saved))
+	for _, p := range saved {
+		pauses = append(pauses, p)
+	}
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i].UID < pauses[j].UID })
+
+	raw, err := json.MarshalIndent(pauses, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("cannot save the pauses: %s", err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return fmt.Errorf("cannot save the pauses: %s", err)
+	}
+	return nil
+}
+
+// Add pauses the UID starting now, a pause already present is replaced.
+func (m *Manager) Add(p Pause) (Pause, error) {
+
+	if p.UID == "" {
+		return Pause{}, fmt.Errorf("a pause must have a uid")
+	}
+	p.Start = time.Now()
+	if !p.Expire.IsZero() && !p.Expire.After(p.Start) {
+		return Pause{}, fmt.Errorf("the pause is already over")
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.pauses == nil {
+		m.pauses = make(map[string]Pause)
+	}
+	m.pauses[p.UID] = p
+	m.save()
+	return p, nil
+}
+
+// Resume removes the pause of the UID.
+func (m *Manager) Resume(uID string) error {
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, found := m.pauses[uID]; !found {
+		return fmt.Errorf("%q is not paused", uID)
+	}
+	delete(m.pauses, uID)
+	m.save()
+	return nil
+}
+
+// Rekey moves the pauses from their UID to a new one after a reload changed the UIDs
+// (a UID is a hash of its TestStruct), an empty new UID drops the pause of a removed TestStruct.
+// The pauses of the UIDs absent from uIDs are kept. Returns the count of pauses moved or dropped.
+func (m *Manager) Rekey(uIDs map[string]string) (changed int) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	// All removed before any is added: a new UID can be the old one of another pause
+	moved := make([]Pause, 0)
+	for oldUID, newUID := range uIDs {
+		p, found := m.pauses[oldUID]
+		if !found || oldUID == newUID {
+			continue
+		}
+		delete(m.pauses, oldUID)
+		if newUID != "" {
+			p.UID = newUID
+			moved = append(moved, p)
+		}
+		changed++
+	}
+	for _, p := range moved {
+		m.pauses[p.UID] = p
+	}
+	if changed > 0 {
+		m.save()
+	}
+	return changed
+}
+
+// Pauses returns the active pauses, sorted by UID.
+// The pauses which are over are removed.
+func (m *Manager) Pauses() []Pause {
+
+	now := time.Now()
+
+	m.Lock()
+	defer m.Unlock()
+
+	pauses := make([]Pause, 0, len(m.pauses))
+	expired := false
+	for uID, p := range m.pauses {
+		if !p.Active(now) {
+			delete(m.pauses, uID)
+			expired = true
+			continue
+		}
+		pauses = append(pauses, p)
+	}
+	if expired {
+		m.save()
+	}
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i].UID < pauses[j].UID })
+	return pauses
+}
+
+// Check returns the active pause of the first UID paused: the UIDs of a TestStep
+// are the ones of its TestSuite, its TestCase and itself.
+func (m *Manager) Check(uIDs []string, now time.Time) (Pause, bool) {
+
+	m.RLock()
+	defer m.RUnlock()
+
+	for _, uID := range uIDs {
+		if p, found := m.pauses[uID]; found && p.Active(now) {
+			return p, true
+		}
+	}
+	return Pause{}, false
+}
+
+// IsPaused tells if one of the UIDs is paused now.
+func (m *Manager) IsPaused(uIDs []string) bool {
+
+	_, paused := m.Check(uIDs, time.Now())
+	return paused
+}
@@ -0,0 +1,110 @@
+package pause
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManager_Check(t *testing.T) {
+
+	m := Manager{pauses: make(map[string]Pause)}
+	now := time.Now()
+
+	if _, err := m.Add(Pause{UID: "6-87", Reason: "migration", PausedBy: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add(Pause{UID: "7", Expire: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		uIDs    []string
+		at      time.Time
+		wantUID string
+		want    bool
+	}{
+		{name: "testcase paused", uIDs: []string{"6", "6-87", "6-87-230"}, at: now, wantUID: "6-87", want: true},
+		{name: "other testcase", uIDs: []string{"6", "6-88", "6-88-230"}, at: now},
+		{name: "testsuite paused", uIDs: []string{"7", "7-1", "7-1-2"}, at: now, wantUID: "7", want: true},
+		{name: "expired", uIDs: []string{"7", "7-1", "7-1-2"}, at: now.Add(2 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, paused := m.Check(tt.uIDs, tt.at)
+			if paused != tt.want || got.UID != tt.wantUID {
+				t.Errorf("Check() = %q, %v, want %q, %v", got.UID, paused, tt.wantUID, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.Add(Pause{UID: "8", Expire: now.Add(-time.Minute)}); err == nil {
+		t.Error("Add() expected an error for a pause already over")
+	}
+	if err := m.Resume("6-87"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Resume("6-87"); err == nil {
+		t.Error("Resume() expected an error for a UID not paused")
+	}
+	if len(m.Pauses()) != 1 {
+		t.Errorf("Pauses() = %v, expected only the pause of 7", m.Pauses())
+	}
+}
+
+func TestManager_restart(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "pauses.json")
+
+	m := Manager{file: file, pauses: make(map[string]Pause)}
+	if _, err := m.Add(Pause{UID: "6", Reason: "migration", PausedBy: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add(Pause{UID: "7", Expire: time.Now().Add(50 * time.Millisecond)}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// The pauses expired while Vigie was down are dropped
+	pauses, err := load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, found := pauses["6"]
+	if len(pauses) != 1 || !found || p.Reason != "migration" || p.PausedBy != "ops" {
+		t.Errorf("load() = %v, expected the pause of 6 only", pauses)
+	}
+
+	if pauses, err := load(filepath.Join(t.TempDir(), "none.json")); err != nil || len(pauses) != 0 {
+		t.Errorf("load() = %v, %v, expected no pause without file", pauses, err)
+	}
+}
+
+func TestManager_Rekey(t *testing.T) {
+
+	m := Manager{pauses: make(map[string]Pause)}
+	for _, uID := range []string{"1", "2", "3", "4"} {
+		if _, err := m.Add(Pause{UID: uID, Reason: "reason " + uID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 1 and 2 swap their UIDs, 3 is removed, 4 and 5 are absent or not paused
+	changed := m.Rekey(map[string]string{"1": "2", "2": "1", "3": "", "5": "6"})
+	if changed != 3 {
+		t.Errorf("Rekey() = %d, want 3", changed)
+	}
+
+	want := map[string]string{"1": "reason 2", "2": "reason 1", "4": "reason 4"}
+	pauses := m.Pauses()
+	if len(pauses) != len(want) {
+		t.Fatalf("Pauses() = %v, want %v", pauses, want)
+	}
+	for _, p := range pauses {
+		if want[p.UID] != p.Reason {
+			t.Errorf("pause %s has the reason %q, want %q", p.UID, p.Reason, want[p.UID])
+		}
+	}
+}
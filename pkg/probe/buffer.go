@@ -0,0 +1,24 @@
+package probe
+
+import "bytes"
+
+// LimitedBuffer is a buffer dropping what is written after Max bytes,
+// it captures the output of a command without growing indefinitely.
+type LimitedBuffer struct {
+	bytes.Buffer
+	Max       int
+	Truncated bool
+}
+
+func (lb *LimitedBuffer) Write(p []byte) (int, error) {
+
+	room := lb.Max - lb.Len()
+	if len(p) > room {
+		lb.Truncated = true
+		if room > 0 {
+			lb.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return lb.Buffer.Write(p)
+}
@@ -0,0 +1,21 @@
+package probe
+
+import (
+	"context"
+	osexec "os/exec"
+)
+
+// KillOnDone kills the process group of the started command once ctx is done
+// (see SetProcessGroup). The returned func stops the watch, to call once the command has exited.
+func KillOnDone(ctx context.Context, cmd *osexec.Cmd) func() {
+
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-exited:
+		}
+	}()
+	return func() { close(exited) }
+}
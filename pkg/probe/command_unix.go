@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package probe
+
+import (
+	osexec "os/exec"
+	"syscall"
+)
+
+// SetProcessGroup runs the command in its own process group,
+// so the processes it forks can be killed with it.
+func SetProcessGroup(cmd *osexec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the command and every process of its group.
+func killProcessGroup(cmd *osexec.Cmd) {
+	if cmd.Process != nil {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
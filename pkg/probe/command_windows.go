@@ -0,0 +1,15 @@
+package probe
+
+import (
+	osexec "os/exec"
+)
+
+// SetProcessGroup does nothing: Windows has no process group to kill at once.
+func SetProcessGroup(cmd *osexec.Cmd) {}
+
+// killProcessGroup kills the command only.
+func killProcessGroup(cmd *osexec.Cmd) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
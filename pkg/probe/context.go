@@ -0,0 +1,105 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Phases of a probe in flight, reported when it times out.
+const (
+	PhaseDNS       = "dns"
+	PhaseConnect   = "connect"
+	PhaseTLS       = "tls"
+	PhaseFirstByte = "first_byte" // request sent, waiting for the answer
+	PhaseTransfer  = "transfer"
+	PhaseRun       = "run" // probe without finer phases (command, ping...)
+)
+
+type phaseKey struct{}
+
+// PhaseTracker records the phase in flight of a probe,
+// a tracker created inside another one updates its parent too.
+type PhaseTracker struct {
+	mu     sync.Mutex
+	phase  string
+	parent *PhaseTracker
+}
+
+// WithPhaseTracker returns a context recording the phases set by the probe.
+func WithPhaseTracker(ctx context.Context) (context.Context, *PhaseTracker) {
+
+	pt := &PhaseTracker{}
+	pt.parent, _ = ctx.Value(phaseKey{}).(*PhaseTracker)
+	return context.WithValue(ctx, phaseKey{}, pt), pt
+}
+
+// Phase returns the phase in flight, empty if none has been set.
+func (pt *PhaseTracker) Phase() string {
+
+	if pt == nil {
+		return ""
+	}
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.phase
+}
+
+func (pt *PhaseTracker) set(phase string) {
+
+	for ; pt != nil; pt = pt.parent {
+		pt.mu.Lock()
+		pt.phase = phase
+		pt.mu.Unlock()
+	}
+}
+
+// SetPhase records the phase entered by the probe, if the context tracks it.
+func SetPhase(ctx context.Context, phase string) {
+
+	pt, _ := ctx.Value(phaseKey{}).(*PhaseTracker)
+	pt.set(phase)
+}
+
+// CurrentPhase returns the phase in flight recorded in the context.
+func CurrentPhase(ctx context.Context) string {
+
+	pt, _ := ctx.Value(phaseKey{}).(*PhaseTracker)
+	return pt.Phase()
+}
+
+// TimedOut tells if the deadline of the probe is exceeded.
+func TimedOut(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// Remaining returns the time left before the deadline of the probe, 0 if none.
+func Remaining(ctx context.Context) time.Duration {
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	if left := time.Until(deadline); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// TimeoutInfo is the ProbeInfo of a probe which has not finished before its deadline.
+func TimeoutInfo(ctx context.Context, ip string, elapsed time.Duration) ProbeInfo {
+
+	phase := CurrentPhase(ctx)
+	if phase == "" {
+		phase = PhaseRun
+	}
+	return ProbeInfo{
+		Status:       Timeout,
+		Error:        fmt.Sprintf("timeout after %s during %s", elapsed.Round(time.Millisecond), phase),
+		ResponseTime: elapsed,
+		IPresolved:   ip,
+		Phase:        phase,
+	}
+}
@@ -0,0 +1,51 @@
+package probe
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPhaseTracker(t *testing.T) {
+
+	ctx, run := WithPhaseTracker(context.Background())
+	ipCtx, ip := WithPhaseTracker(ctx)
+
+	SetPhase(ctx, PhaseDNS)
+	if run.Phase() != PhaseDNS || ip.Phase() != "" {
+		t.Errorf("Phase() = %q, %q, want %q, empty", run.Phase(), ip.Phase(), PhaseDNS)
+	}
+
+	// The phase of a request updates the run
+	SetPhase(ipCtx, PhaseTLS)
+	if run.Phase() != PhaseTLS || CurrentPhase(ipCtx) != PhaseTLS {
+		t.Errorf("Phase() = %q, %q, want %q", run.Phase(), CurrentPhase(ipCtx), PhaseTLS)
+	}
+
+	// Without tracker
+	SetPhase(context.Background(), PhaseConnect)
+	if got := CurrentPhase(context.Background()); got != "" {
+		t.Errorf("CurrentPhase() = %q, want empty", got)
+	}
+}
+
+func TestTimeoutInfo(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	ctx, _ = WithPhaseTracker(ctx)
+	SetPhase(ctx, PhaseFirstByte)
+	<-ctx.Done()
+
+	if !TimedOut(ctx) {
+		t.Fatal("TimedOut() = false, want true")
+	}
+	if Remaining(ctx) != 0 {
+		t.Errorf("Remaining() = %s, want 0", Remaining(ctx))
+	}
+
+	pi := TimeoutInfo(ctx, "10.0.0.1", 5*time.Second)
+	if pi.Status != Timeout || pi.Phase != PhaseFirstByte || pi.Error != "timeout after 5s during first_byte" {
+		t.Errorf("TimeoutInfo() = %+v", pi)
+	}
+}
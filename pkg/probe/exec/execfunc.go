@@ -0,0 +1,81 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/probe"
+)
+
+// maxOutputSize is the max size kept of stdout and stderr.
+const maxOutputSize = 64 << 10
+
+func (p *Probe) process(ctx context.Context) ProbeExecReturnInterface {
+
+	stdout := probe.LimitedBuffer{Max: maxOutputSize}
+	stderr := probe.LimitedBuffer{Max: maxOutputSize}
+
+	// Not CommandContext: it kills the direct child only, and a plugin forking
+	// a child that keeps stdout open would block the probe until the child exits
+	cmd := osexec.Command(p.Command[0], p.Command[1:]...)
+	probe.SetProcessGroup(cmd)
+	cmd.Dir = p.Dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if len(p.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range p.Env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+
+	probe.SetPhase(ctx, probe.PhaseRun)
+	start := time.Now()
+	err := cmd.Start()
+	if err == nil {
+		stop := probe.KillOnDone(ctx, cmd)
+		err = cmd.Wait()
+		stop()
+	}
+	elapsed := time.Since(start)
+
+	pa := ProbeExecReturnInterface{
+		ProbeInfo: probe.ProbeInfo{Status: probe.Success, ResponseTime: elapsed},
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Duration:  elapsed,
+	}
+
+	var exitErr *osexec.ExitError
+	switch {
+	case probe.TimedOut(ctx):
+		pa.ProbeInfo = probe.TimeoutInfo(ctx, "", elapsed)
+		pa.ProbeInfo.Error = fmt.Sprintf("command %q has not finished: %s", p.Command[0], pa.ProbeInfo.Error)
+		return pa
+
+	case errors.As(err, &exitErr):
+		// A non zero exit code is a result to assert
+		pa.ExitCode = exitErr.ExitCode()
+
+	case err != nil:
+		// The command cannot be started (not found, permission ...)
+		pa.ProbeInfo.Status = probe.Failure
+		pa.ProbeInfo.Error = err.Error()
+		return pa
+	}
+
+	if p.Nagios {
+		no, err := parseNagios(pa.Stdout, pa.ExitCode)
+		if err != nil {
+			pa.ProbeInfo.Status = probe.Error
+			pa.ProbeInfo.Error = fmt.Sprintf("invalid Nagios plugin output: %s", err)
+		}
+		pa.Nagios = &no
+	}
+
+	return pa
+}
@@ -0,0 +1,59 @@
+//go:build !windows
+// +build !windows
+
+package exec
+
+import (
+	"context"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/probe"
+)
+
+func TestProbe_Run_KillsForkedChildren(t *testing.T) {
+
+	// The background sleep keeps stdout open: only the kill of the group ends the probe
+	p := Probe{Command: []string{"sh", "-c", "sleep 60 & echo $!; wait"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	pa := p.process(ctx)
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("process() returned after %s, want the forked child killed at the timeout", elapsed)
+	}
+	if pa.ProbeInfo.Status != probe.Timeout {
+		t.Fatalf("process() status = %v (%s), want %v", pa.ProbeInfo.Status, pa.ProbeInfo.Error, probe.Timeout)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(pa.Stdout))
+	if err != nil {
+		t.Fatalf("cannot read the pid of the child from %q: %s", pa.Stdout, err)
+	}
+	// Once killed, the orphan child is gone or left to init as a zombie
+	deadline := time.Now().Add(2 * time.Second)
+	for syscall.Kill(pid, 0) == nil && !zombie(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("forked child %d is still running", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// zombie tells if the process has exited but is not reaped yet (Linux only, false elsewhere).
+func zombie(pid int) bool {
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	// The state follows the command name in parentheses: "12 (sleep) Z ..."
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
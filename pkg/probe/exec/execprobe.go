@@ -0,0 +1,150 @@
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/probe"
+)
+
+// Name of the probe
+const Name = "exec"
+
+func init() {
+	probe.Register(Name, New)
+}
+
+// New returns a new Probe
+func New() probe.Probe {
+	return &Probe{}
+}
+
+// Return Probe Name
+func (Probe) GetName() string {
+	return Name
+}
+
+func (Probe) GetDefaultTimeout() time.Duration {
+	return time.Second * 10
+}
+
+func (Probe) GetDefaultFrequency() time.Duration {
+	return time.Second * 60
+}
+
+// AnswerSchema returns the fields of ProbeExecReturnInterface
+func (Probe) AnswerSchema() *probe.Schema {
+	return probe.SchemaOf(ProbeExecReturnInterface{})
+}
+
+// Probe runs a local command (script, Nagios plugin)
+// All attributes must be Public
+type Probe struct {
+	Command []string          `json:"command"` // Executable and its arguments
+	Env     map[string]string `json:"env"`     // Added to the environment of Vigie
+	Dir     string            `json:"dir"`     // Working directory
+	Nagios  bool              `json:"nagios"`  // Parse the output as a Nagios plugin
+}
+
+func (p Probe) Labels() map[string]string {
+
+	lbl := make(map[string]string)
+
+	lbl["probe"] = p.GetName()
+	lbl["command"] = p.Command[0]
+
+	return lbl
+}
+
+// ProbeExecReturnInterface is the returned result after the run
+// All attributes must be Public
+// ProbeInfo is Mandatory => Détail l'execution de la probe
+type ProbeExecReturnInterface struct {
+	ProbeInfo probe.ProbeInfo `json:"probeinfo"`
+	ExitCode  int             `json:"exitcode"`
+	Stdout    string          `json:"stdout"`
+	Stderr    string          `json:"stderr"`
+	Duration  time.Duration   `json:"duration"`
+	Nagios    *NagiosOutput   `json:"nagios,omitempty"` // Only if nagios: true
+}
+
+func (pa ProbeExecReturnInterface) StructAnswer() interface{} {
+	return pa
+}
+
+func (pa ProbeExecReturnInterface) DumpAnswer() map[string]interface{} {
+	aswDump, err := probe.ToMap(pa)
+	if err != nil {
+	}
+	return aswDump
+}
+
+func (pa ProbeExecReturnInterface) GetProbeInfo() probe.ProbeInfo {
+	return pa.ProbeInfo
+}
+
+func (pa ProbeExecReturnInterface) Labels() map[string]string {
+	return map[string]string{}
+}
+
+// Values returns the exit code, the duration and the Nagios perfdata (perf_<label>)
+func (pa ProbeExecReturnInterface) Values() map[string]interface{} {
+
+	values := map[string]interface{}{
+		"status":   pa.ProbeInfo.Status,
+		"exitcode": pa.ExitCode,
+		"duration": pa.Duration,
+	}
+
+	if pa.Nagios != nil {
+		for label, pd := range pa.Nagios.PerfData {
+			values["perf_"+fieldName(label)] = pd.Value
+		}
+	}
+
+	return values
+}
+
+// fieldName converts a perfdata label into a TSDB field name.
+func fieldName(label string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, label)
+}
+
+// GenerateTStepName return a tstep name if non existent
+func (p *Probe) GenerateTStepName() string {
+	return fmt.Sprintf("%s_%s", p.GetName(), strings.Join(p.Command, " "))
+}
+
+// Initialize Probe struct data
+func (p *Probe) Initialize(step probe.StepProbe) error {
+
+	// Decode Probe Struct from TestStep
+	raw, err := json.Marshal(step)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, p); err != nil {
+		return err
+	}
+
+	if len(p.Command) == 0 || p.Command[0] == "" {
+		return fmt.Errorf("command is mandatory")
+	}
+
+	return nil
+}
+
+// Start the probe request
+func (p *Probe) Run(ctx context.Context) (probeReturns []probe.ProbeReturnInterface) {
+	return []probe.ProbeReturnInterface{p.process(ctx)}
+}
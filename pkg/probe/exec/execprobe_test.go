@@ -0,0 +1,60 @@
+package exec
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/probe"
+)
+
+func TestProbe_Run(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		probe        Probe
+		timeout      time.Duration
+		wantStatus   probe.Status
+		wantExitCode int
+		wantStdout   string
+		wantPerf     map[string]interface{}
+		wantPhase    string
+	}{
+		{name: "stdout", probe: Probe{Command: []string{"sh", "-c", "printf $GREETING", "x"}, Env: map[string]string{"GREETING": "hello"}},
+			wantStatus: probe.Success, wantStdout: "hello"},
+		{name: "dir", probe: Probe{Command: []string{"pwd"}, Dir: "/"}, wantStatus: probe.Success, wantStdout: "/\n"},
+		{name: "exit_code", probe: Probe{Command: []string{"sh", "-c", "exit 2"}}, wantStatus: probe.Success, wantExitCode: 2},
+		{name: "nagios", probe: Probe{Command: []string{"sh", "-c", "echo 'LOAD WARNING | load1=4.2;4;8;0'; exit 1"}, Nagios: true},
+			wantStatus: probe.Success, wantExitCode: 1, wantStdout: "LOAD WARNING | load1=4.2;4;8;0\n", wantPerf: map[string]interface{}{"perf_load1": 4.2}},
+		{name: "not_found", probe: Probe{Command: []string{"/nonexistent/check"}}, wantStatus: probe.Failure},
+		{name: "timeout", probe: Probe{Command: []string{"sleep", "2"}}, timeout: 50 * time.Millisecond, wantStatus: probe.Timeout, wantPhase: probe.PhaseRun},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.timeout == 0 {
+				tt.timeout = time.Second
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			pa := tt.probe.process(ctx)
+			if pa.ProbeInfo.Status != tt.wantStatus {
+				t.Fatalf("process() status = %v (%s), want %v", pa.ProbeInfo.Status, pa.ProbeInfo.Error, tt.wantStatus)
+			}
+			if pa.ProbeInfo.Phase != tt.wantPhase {
+				t.Errorf("process() phase = %q, want %q", pa.ProbeInfo.Phase, tt.wantPhase)
+			}
+			if pa.ExitCode != tt.wantExitCode {
+				t.Errorf("process() exitcode = %d, want %d", pa.ExitCode, tt.wantExitCode)
+			}
+			if tt.wantStdout != "" && pa.Stdout != tt.wantStdout {
+				t.Errorf("process() stdout = %q, want %q", pa.Stdout, tt.wantStdout)
+			}
+			values := pa.Values()
+			for k, v := range tt.wantPerf {
+				if values[k] != v {
+					t.Errorf("Values()[%s] = %v, want %v", k, values[k], v)
+				}
+			}
+		})
+	}
+}
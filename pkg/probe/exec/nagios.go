@@ -0,0 +1,165 @@
+package exec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NagiosOutput is the output of a Nagios plugin:
+// https://nagios-plugins.org/doc/guidelines.html#AEN200
+type NagiosOutput struct {
+	Status   string              `json:"status"`   // OK, WARNING, CRITICAL, UNKNOWN (from the exit code)
+	Output   string              `json:"output"`   // Status line, without perfdata
+	LongText string              `json:"longtext"` // Following lines, without perfdata
+	PerfData map[string]PerfData `json:"perfdata"` // By label
+}
+
+// PerfData is a performance data of a Nagios plugin:
+// 'label'=value[UOM];[warn];[crit];[min];[max]
+type PerfData struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"` // s, ms, us, %, B, KB, MB, TB, c or empty
+	Warn  string  `json:"warn"` // Range (10, 10:, ~:10, 10:20, @10:20)
+	Crit  string  `json:"crit"` // Range
+	Min   string  `json:"min"`
+	Max   string  `json:"max"`
+}
+
+// nagiosStatus converts a plugin exit code.
+func nagiosStatus(exitCode int) string {
+	switch exitCode {
+	case 0:
+		return "OK"
+	case 1:
+		return "WARNING"
+	case 2:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// perfValueRE matches a perfdata value and its unit
+var perfValueRE = regexp.MustCompile(`^(-?[0-9]*\.?[0-9]+(?:[eE][-+]?[0-9]+)?)([a-zA-Z%]*)$`)
+
+// parseNagios parses the output of a Nagios plugin,
+// perfdata are after a | on the first line or on the following lines.
+func parseNagios(stdout string, exitCode int) (NagiosOutput, error) {
+
+	no := NagiosOutput{
+		Status:   nagiosStatus(exitCode),
+		PerfData: make(map[string]PerfData),
+	}
+
+	lines := strings.Split(strings.TrimRight(stdout, "\n"), "\n")
+
+	// First line: TEXT OUTPUT | OPTIONAL PERFDATA
+	perfs := make([]string, 0, 2)
+	first := strings.SplitN(lines[0], "|", 2)
+	no.Output = strings.TrimSpace(first[0])
+	if len(first) == 2 {
+		perfs = append(perfs, first[1])
+	}
+
+	// Following lines: LONG TEXT | PERFDATA (continued on the next lines)
+	longText := make([]string, 0, len(lines))
+	inPerf := false
+	for _, line := range lines[1:] {
+		if inPerf {
+			perfs = append(perfs, line)
+			continue
+		}
+		if i := strings.Index(line, "|"); i >= 0 {
+			longText = append(longText, line[:i])
+			perfs = append(perfs, line[i+1:])
+			inPerf = true
+			continue
+		}
+		longText = append(longText, line)
+	}
+	no.LongText = strings.TrimSpace(strings.Join(longText, "\n"))
+
+	for _, perf := range perfs {
+		if err := parsePerfData(perf, no.PerfData); err != nil {
+			return no, err
+		}
+	}
+
+	return no, nil
+}
+
+// parsePerfData parses space separated perfdata: 'label'=value[UOM];[warn];[crit];[min];[max]
+func parsePerfData(s string, perfData map[string]PerfData) error {
+
+	s = strings.TrimSpace(s)
+	for s != "" {
+
+		// Label, can be quoted to contain spaces ('' is an escaped quote)
+		var label string
+		if strings.HasPrefix(s, "'") {
+			end := 1
+			for {
+				i := strings.Index(s[end:], "'")
+				if i < 0 {
+					return fmt.Errorf("unterminated quoted label in perfdata %q", s)
+				}
+				end += i
+				if end+1 < len(s) && s[end+1] == '\'' {
+					end += 2
+					continue
+				}
+				break
+			}
+			label = strings.Replace(s[1:end], "''", "'", -1)
+			s = s[end+1:]
+		} else {
+			i := strings.Index(s, "=")
+			if i < 0 {
+				return fmt.Errorf("perfdata %q has no value", s)
+			}
+			label = s[:i]
+			s = s[i:]
+		}
+
+		if !strings.HasPrefix(s, "=") {
+			return fmt.Errorf("perfdata %q has no value", label)
+		}
+		s = s[1:]
+
+		// Value;warn;crit;min;max
+		var field string
+		if i := strings.IndexAny(s, " \t"); i >= 0 {
+			field, s = s[:i], strings.TrimSpace(s[i:])
+		} else {
+			field, s = s, ""
+		}
+
+		parts := strings.Split(field, ";")
+		m := perfValueRE.FindStringSubmatch(parts[0])
+		if m == nil {
+			// "U" is an undetermined value
+			if parts[0] == "U" {
+				continue
+			}
+			return fmt.Errorf("perfdata %q has an invalid value %q", label, parts[0])
+		}
+		value, _ := strconv.ParseFloat(m[1], 64)
+		pd := PerfData{Value: value, Unit: m[2]}
+		for i, p := range parts[1:] {
+			switch i {
+			case 0:
+				pd.Warn = p
+			case 1:
+				pd.Crit = p
+			case 2:
+				pd.Min = p
+			case 3:
+				pd.Max = p
+			}
+		}
+		perfData[label] = pd
+	}
+	return nil
+}
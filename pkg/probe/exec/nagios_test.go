@@ -0,0 +1,62 @@
+package exec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNagios(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		stdout   string
+		exitCode int
+		want     NagiosOutput
+		wantErr  bool
+	}{
+		{
+			name:     "status_line",
+			stdout:   "DISK OK - free space: / 3326 MB (56%);\n",
+			exitCode: 0,
+			want:     NagiosOutput{Status: "OK", Output: "DISK OK - free space: / 3326 MB (56%);", PerfData: map[string]PerfData{}},
+		},
+		{
+			name:     "perfdata",
+			stdout:   "PING WARNING - Packet loss = 10%, RTA = 80.5 ms | rta=80.500ms;50.000;100.000;0; pl=10%;5;20;;",
+			exitCode: 1,
+			want: NagiosOutput{Status: "WARNING", Output: "PING WARNING - Packet loss = 10%, RTA = 80.5 ms", PerfData: map[string]PerfData{
+				"rta": {Value: 80.5, Unit: "ms", Warn: "50.000", Crit: "100.000", Min: "0"},
+				"pl":  {Value: 10, Unit: "%", Warn: "5", Crit: "20"},
+			}},
+		},
+		{
+			name:     "long_text",
+			stdout:   "DISK CRITICAL | '/ used'=2643MB;5948;5958;0;5968\n/ 15272 MB (77%);\n/boot 68 MB (69%); | /boot=68MB;88;93;0;98\n/home=69357MB;253404;253409;0;253414",
+			exitCode: 2,
+			want: NagiosOutput{Status: "CRITICAL", Output: "DISK CRITICAL", LongText: "/ 15272 MB (77%);\n/boot 68 MB (69%);", PerfData: map[string]PerfData{
+				"/ used": {Value: 2643, Unit: "MB", Warn: "5948", Crit: "5958", Min: "0", Max: "5968"},
+				"/boot":  {Value: 68, Unit: "MB", Warn: "88", Crit: "93", Min: "0", Max: "98"},
+				"/home":  {Value: 69357, Unit: "MB", Warn: "253404", Crit: "253409", Min: "0", Max: "253414"},
+			}},
+		},
+		{
+			name:     "undetermined",
+			stdout:   "UNKNOWN | 'it''s'=U time=0.5s",
+			exitCode: 3,
+			want:     NagiosOutput{Status: "UNKNOWN", Output: "UNKNOWN", PerfData: map[string]PerfData{"time": {Value: 0.5, Unit: "s"}}},
+		},
+		{name: "invalid_value", stdout: "OK | load=high", wantErr: true},
+		{name: "unterminated_label", stdout: "OK | 'load=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNagios(tt.stdout, tt.exitCode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNagios() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNagios() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
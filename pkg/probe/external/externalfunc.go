@@ -0,0 +1,183 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/probe"
+)
+
+// maxResponseSize is the max size of a response of an external probe.
+const maxResponseSize = 1 << 20
+
+// request is sent to the external probe (stdin or POST body).
+type request struct {
+	Params  map[string]interface{} `json:"params"`
+	Timeout string                 `json:"timeout"`
+}
+
+// response is returned by the external probe (stdout or response body).
+type response struct {
+	Results []result `json:"results"`
+}
+
+// result is the result of the external probe for one target.
+type result struct {
+	Status       string                 `json:"status"` // success (default), error, timeout, failure
+	Error        string                 `json:"error"`
+	ProbeCode    int                    `json:"probecode"`
+	IP           string                 `json:"ip"`
+	ResponseTime string                 `json:"responsetime"` // Duration, the call duration if empty
+	Answer       map[string]interface{} `json:"answer"`       // Asserted values
+	Labels       map[string]string      `json:"labels"`       // Added to the TSDB tags
+	Values       map[string]interface{} `json:"values"`       // Written to the TSDB
+}
+
+func (p *Probe) process(ctx context.Context) []probe.ProbeReturnInterface {
+
+	req, err := json.Marshal(request{Params: p.Params, Timeout: probe.Remaining(ctx).String()})
+	if err != nil {
+		return failed(probe.Failure, fmt.Errorf("cannot encode the request: %s", err))
+	}
+
+	probe.SetPhase(ctx, probe.PhaseRun)
+	start := time.Now()
+	var out []byte
+	if p.URL != "" {
+		out, err = p.callSidecar(ctx, req)
+	} else {
+		out, err = p.execCommand(ctx, req)
+	}
+	elapsed := time.Since(start)
+
+	if probe.TimedOut(ctx) {
+		pi := probe.TimeoutInfo(ctx, "", elapsed)
+		pi.Error = fmt.Sprintf("external probe has not answered: %s", pi.Error)
+		return []probe.ProbeReturnInterface{ProbeExternalReturnInterface{ProbeInfo: pi}}
+	}
+	if err != nil {
+		return failed(probe.Failure, err)
+	}
+
+	return decodeResponse(out, elapsed)
+}
+
+// execCommand runs the command, the request is written on stdin
+// and the response is read on stdout.
+func (p *Probe) execCommand(ctx context.Context, req []byte) ([]byte, error) {
+
+	stdout := probe.LimitedBuffer{Max: maxResponseSize}
+	stderr := probe.LimitedBuffer{Max: 4096}
+	// Not CommandContext: it kills the direct child only, and a wrapper script forking
+	// a child that keeps stdout open would block the probe until the child exits
+	cmd := exec.Command(p.Command[0], p.Command[1:]...)
+	probe.SetProcessGroup(cmd)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Start()
+	if err == nil {
+		stop := probe.KillOnDone(ctx, cmd)
+		err = cmd.Wait()
+		stop()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("command %q failed: %s %s", p.Command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// callSidecar POSTs the request to the sidecar.
+func (p *Probe) callSidecar(ctx context.Context, req []byte) ([]byte, error) {
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("sidecar %q cannot be reached: %s", p.URL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the response of %q: %s", p.URL, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("sidecar %q returned %s", p.URL, resp.Status)
+	}
+	return body, nil
+}
+
+// decodeResponse converts the response of the external probe.
+func decodeResponse(out []byte, elapsed time.Duration) []probe.ProbeReturnInterface {
+
+	var resp response
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return failed(probe.Failure, fmt.Errorf("invalid response of the external probe: %s", err))
+	}
+	if len(resp.Results) == 0 {
+		return failed(probe.Failure, fmt.Errorf("the external probe returned no result"))
+	}
+
+	probeAnswers := make([]probe.ProbeReturnInterface, 0, len(resp.Results))
+	for _, r := range resp.Results {
+
+		pi := probe.ProbeInfo{
+			Error:        r.Error,
+			ProbeCode:    r.ProbeCode,
+			IPresolved:   r.IP,
+			ResponseTime: elapsed,
+		}
+
+		switch r.Status {
+		case "", "success":
+			pi.Status = probe.Success
+		case "error":
+			pi.Status = probe.Error
+		case "timeout":
+			pi.Status = probe.Timeout
+		case "failure":
+			pi.Status = probe.Failure
+		default:
+			pi.Status = probe.Failure
+			pi.Error = fmt.Sprintf("unknown status %q returned by the external probe", r.Status)
+		}
+
+		if r.ResponseTime != "" {
+			rt, err := time.ParseDuration(r.ResponseTime)
+			if err != nil {
+				pi.Status = probe.Failure
+				pi.Error = fmt.Sprintf("invalid responsetime %q returned by the external probe", r.ResponseTime)
+			} else {
+				pi.ResponseTime = rt
+			}
+		}
+
+		probeAnswers = append(probeAnswers, ProbeExternalReturnInterface{
+			ProbeInfo: pi,
+			Answer:    r.Answer,
+			labels:    r.Labels,
+			values:    r.Values,
+		})
+	}
+
+	return probeAnswers
+}
+
+func failed(status probe.Status, err error) []probe.ProbeReturnInterface {
+	pi := probe.ProbeInfo{Status: status, Error: err.Error()}
+	return []probe.ProbeReturnInterface{ProbeExternalReturnInterface{ProbeInfo: pi}}
+}
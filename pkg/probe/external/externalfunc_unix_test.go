@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package external
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/probe"
+)
+
+func TestProbe_Run_KillsForkedChildren(t *testing.T) {
+
+	// A wrapper script: its background sleep keeps stdout open, only the kill of the group ends the probe
+	p := Probe{Command: []string{"sh", "-c", "cat > /dev/null; sleep 60 & wait"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	prs := p.Run(ctx)
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Run() returned after %s, want the forked child killed at the timeout", elapsed)
+	}
+	if pi := prs[0].GetProbeInfo(); pi.Status != probe.Timeout {
+		t.Fatalf("Run() status = %v (%s), want %v", pi.Status, pi.Error, probe.Timeout)
+	}
+}
@@ -0,0 +1,165 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/probe"
+)
+
+// Name of the probe
+const Name = "external"
+
+func init() {
+	probe.Register(Name, New)
+}
+
+// New returns a new Probe
+func New() probe.Probe {
+	return &Probe{}
+}
+
+// Return Probe Name
+func (Probe) GetName() string {
+	return Name
+}
+
+func (Probe) GetDefaultTimeout() time.Duration {
+	return time.Second * 10
+}
+
+func (Probe) GetDefaultFrequency() time.Duration {
+	return time.Second * 60
+}
+
+// AnswerSchema is unknown before the run: any key can be asserted
+func (Probe) AnswerSchema() *probe.Schema {
+	return &probe.Schema{Type: probe.FieldAny}
+}
+
+// Probe runs an external probe: a subprocess or an HTTP sidecar
+// speaking the JSON protocol described in Readme.md.
+// All attributes must be Public
+type Probe struct {
+	Command []string               `json:"command"` // Subprocess: executable and its arguments
+	URL     string                 `json:"url"`     // HTTP sidecar: endpoint receiving a POST
+	Params  map[string]interface{} `json:"params"`  // Sent as is to the external probe
+}
+
+func (p Probe) Labels() map[string]string {
+
+	lbl := make(map[string]string)
+
+	lbl["probe"] = p.GetName()
+	if p.URL != "" {
+		lbl["url"] = p.URL
+	} else {
+		lbl["command"] = p.Command[0]
+	}
+
+	return lbl
+}
+
+// GenerateTStepName return a tstep name if non existent
+func (p *Probe) GenerateTStepName() string {
+	if p.URL != "" {
+		return fmt.Sprintf("%s_%s", p.GetName(), p.URL)
+	}
+	return fmt.Sprintf("%s_%s", p.GetName(), strings.Join(p.Command, " "))
+}
+
+// Initialize Probe struct data
+func (p *Probe) Initialize(step probe.StepProbe) error {
+
+	// Decode Probe Struct from TestStep
+	raw, err := json.Marshal(step)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, p); err != nil {
+		return err
+	}
+
+	switch {
+	case len(p.Command) > 0 && p.URL != "":
+		return fmt.Errorf("command and url are both set, please choose only one")
+
+	case len(p.Command) > 0:
+		if p.Command[0] == "" {
+			return fmt.Errorf("command executable is empty")
+		}
+
+	case p.URL != "":
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			return fmt.Errorf("cannot parse URL %q : %s", p.URL, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("url %q must be http or https", p.URL)
+		}
+
+	default:
+		return fmt.Errorf("command or url is mandatory")
+	}
+
+	return nil
+}
+
+// Start the probe request
+func (p *Probe) Run(ctx context.Context) (probeReturns []probe.ProbeReturnInterface) {
+	return p.process(ctx)
+}
+
+// ProbeExternalReturnInterface is a result of an external probe.
+// The answer is asserted as is, probeinfo is added.
+type ProbeExternalReturnInterface struct {
+	ProbeInfo probe.ProbeInfo
+	Answer    map[string]interface{}
+	labels    map[string]string
+	values    map[string]interface{}
+}
+
+func (pa ProbeExternalReturnInterface) StructAnswer() interface{} {
+	return pa.DumpAnswer()
+}
+
+func (pa ProbeExternalReturnInterface) DumpAnswer() map[string]interface{} {
+
+	aswDump := make(map[string]interface{}, len(pa.Answer)+1)
+	for k, v := range pa.Answer {
+		aswDump[k] = v
+	}
+	aswDump["probeinfo"], _ = probe.ToMap(pa.ProbeInfo)
+	return aswDump
+}
+
+func (pa ProbeExternalReturnInterface) GetProbeInfo() probe.ProbeInfo {
+	return pa.ProbeInfo
+}
+
+func (pa ProbeExternalReturnInterface) Labels() map[string]string {
+
+	labels := map[string]string{
+		"ip": pa.ProbeInfo.IPresolved,
+	}
+	for k, v := range pa.labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+func (pa ProbeExternalReturnInterface) Values() map[string]interface{} {
+
+	values := map[string]interface{}{
+		"status":       pa.ProbeInfo.Status,
+		"responsetime": pa.ProbeInfo.ResponseTime,
+	}
+	for k, v := range pa.values {
+		values[k] = v
+	}
+	return values
+}
@@ -0,0 +1,87 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/probe"
+)
+
+func TestProbe_Initialize(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		step    probe.StepProbe
+		wantErr bool
+	}{
+		{name: "command", step: probe.StepProbe{"type": Name, "command": []interface{}{"/bin/check", "--fast"}}},
+		{name: "url", step: probe.StepProbe{"type": Name, "url": "http://127.0.0.1:9000/probe", "params": map[string]interface{}{"target": "db"}}},
+		{name: "none", step: probe.StepProbe{"type": Name}, wantErr: true},
+		{name: "both", step: probe.StepProbe{"type": Name, "command": []interface{}{"/bin/check"}, "url": "http://127.0.0.1"}, wantErr: true},
+		{name: "bad_scheme", step: probe.StepProbe{"type": Name, "url": "ftp://127.0.0.1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Probe{}
+			if err := p.Initialize(tt.step); (err != nil) != tt.wantErr {
+				t.Errorf("Initialize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProbe_Run(t *testing.T) {
+
+	// The sidecar answers with the params it received
+	sidecar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(response{Results: []result{{IP: "10.0.0.1", Answer: req.Params}}})
+	}))
+	defer sidecar.Close()
+
+	tests := []struct {
+		name       string
+		probe      Probe
+		timeout    time.Duration
+		wantStatus probe.Status
+		wantAnswer map[string]interface{}
+	}{
+		{name: "command", probe: Probe{Command: []string{"sh", "-c", `cat > /dev/null; echo '{"results": [{"answer": {"healthy": true}, "responsetime": "5ms"}]}'`}},
+			timeout: time.Second, wantStatus: probe.Success, wantAnswer: map[string]interface{}{"healthy": true}},
+		{name: "command_error", probe: Probe{Command: []string{"sh", "-c", `echo '{"results": [{"status": "error", "probecode": 2}]}'`}},
+			timeout: time.Second, wantStatus: probe.Error},
+		{name: "command_exit", probe: Probe{Command: []string{"sh", "-c", "exit 3"}}, timeout: time.Second, wantStatus: probe.Failure},
+		{name: "command_invalid", probe: Probe{Command: []string{"sh", "-c", "echo ok"}}, timeout: time.Second, wantStatus: probe.Failure},
+		{name: "command_timeout", probe: Probe{Command: []string{"sleep", "2"}}, timeout: 50 * time.Millisecond, wantStatus: probe.Timeout},
+		{name: "sidecar", probe: Probe{URL: sidecar.URL, Params: map[string]interface{}{"target": "db"}},
+			timeout: time.Second, wantStatus: probe.Success, wantAnswer: map[string]interface{}{"target": "db"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			prs := tt.probe.Run(ctx)
+			if len(prs) != 1 {
+				t.Fatalf("Run() returned %d results, want 1", len(prs))
+			}
+			pi := prs[0].GetProbeInfo()
+			if pi.Status != tt.wantStatus {
+				t.Fatalf("Run() status = %v (%s), want %v", pi.Status, pi.Error, tt.wantStatus)
+			}
+			answer := prs[0].DumpAnswer()
+			for k, v := range tt.wantAnswer {
+				if answer[k] != v {
+					t.Errorf("Run() answer[%s] = %v, want %v", k, answer[k], v)
+				}
+			}
+			if _, found := answer["probeinfo"]; !found {
+				t.Errorf("Run() answer has no probeinfo")
+			}
+		})
+	}
+}
@@ -10,7 +10,7 @@ import (
 // GetIPsFromHostname returns a array of IPs resolved by a Hostname
 // Ipv4 or Ipv6
 // This func must be divided WIP
-func GetIPsFromHostname(host string, ipv int) ([]string, error) {
+func GetIPsFromHostname(ctx context.Context, host string, ipv int) ([]string, error) {
 
 	// If a Hostname is provided:
 	// The probe will check every IPs behind this DNS record
@@ -52,7 +52,8 @@ func GetIPsFromHostname(host string, ipv int) ([]string, error) {
 	// Get all the IPs behind a DNS record
 	//
 
-	addrs, err := core.VigieServer.CacheDNS.LookupHost(context.Background(), host, ipv)
+	SetPhase(ctx, PhaseDNS)
+	addrs, err := core.VigieServer.CacheDNS.LookupHost(ctx, host, ipv)
 	if err != nil {
 		return nil, fmt.Errorf("error while DNS resolution of %q : %s", host, err)
 	}
@@ -79,9 +80,9 @@ func GetIPsFromHostname(host string, ipv int) ([]string, error) {
 
 }
 
-func GetIPsWithPort(host string, port int, ipv int) ([]string, error) {
+func GetIPsWithPort(ctx context.Context, host string, port int, ipv int) ([]string, error) {
 
-	ips, err := GetIPsFromHostname(host, ipv)
+	ips, err := GetIPsFromHostname(ctx, host, ipv)
 	if err != nil {
 		return nil, err
 	}
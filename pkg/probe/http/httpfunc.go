@@ -25,10 +25,15 @@ import (
 	"time"
 )
 
-func (p *Probe) process(timeout time.Duration) (probeAnswers []probe.ProbeReturnInterface) {
+func (p *Probe) process(ctx context.Context) (probeAnswers []probe.ProbeReturnInterface) {
 
 	// Resolve only some IPv
-	ips, err := probe.GetIPsFromHostname(p.host, p.IpVersion)
+	start := time.Now()
+	ips, err := probe.GetIPsFromHostname(ctx, p.host, p.IpVersion)
+	if probe.TimedOut(ctx) {
+		pi := probe.TimeoutInfo(ctx, "", time.Since(start))
+		return []probe.ProbeReturnInterface{&ProbeHTTPReturnInterface{ProbeInfo: pi}}
+	}
 	if err != nil {
 		pi := probe.ProbeInfo{Status: probe.Error, Error: err.Error()}
 		probeAnswers = make([]probe.ProbeReturnInterface, 0)
@@ -54,8 +59,8 @@ func (p *Probe) process(timeout time.Duration) (probeAnswers []probe.ProbeReturn
 	for i, ip := range ips {
 
 		go func(i int, ip string) {
-			pa, errReq := p.sendTheRequest(ip, timeout)
-			if errReq != nil {
+			pa, errReq := p.sendTheRequest(ctx, ip)
+			if errReq != nil && pa.ProbeInfo.Status != probe.Timeout {
 				pi := probe.ProbeInfo{Status: probe.Error, IPresolved: ip, Error: errReq.Error()}
 				pa = ProbeHTTPReturnInterface{ProbeInfo: pi}
 			}
@@ -115,8 +120,9 @@ func (p *Probe) generateHTTPRequest(completeURL string) (*http.Request, error) {
 	return req, err
 }
 
-func (p *Probe) sendTheRequestX(ip string, timeout time.Duration) (ProbeHTTPReturnInterface, error) {
+func (p *Probe) sendTheRequestX(ctx context.Context, ip string) (ProbeHTTPReturnInterface, error) {
 
+	timeout := probe.Remaining(ctx)
 	transport, errReq := p.generateTransport(p.request, ip, timeout)
 	if errReq != nil {
 		pi := probe.ProbeInfo{Status: probe.Error, Error: errReq.Error()}
@@ -149,7 +155,7 @@ func (p *Probe) sendTheRequestX(ip string, timeout time.Duration) (ProbeHTTPRetu
 		TLSHandshakeDone:     func(_ tls.ConnectionState, _ error) { t6TLSDone = time.Now() },
 	}
 
-	request := p.request.WithContext(httptrace.WithClientTrace(context.Background(), trace))
+	request := p.request.WithContext(httptrace.WithClientTrace(ctx, trace))
 
 	// Set Client
 	client := &http.Client{
@@ -234,9 +240,12 @@ func (p *Probe) sendTheRequestX(ip string, timeout time.Duration) (ProbeHTTPRetu
 	return pa, nil
 }
 
-func (p *Probe) sendTheRequest(ip string, timeout time.Duration) (ProbeHTTPReturnInterface, error) {
+func (p *Probe) sendTheRequest(ctx context.Context, ip string) (ProbeHTTPReturnInterface, error) {
 
-	transport, errReq := p.generateTransport(p.request, ip, timeout)
+	// Phases of the request to this IP
+	ctx, _ = probe.WithPhaseTracker(ctx)
+
+	transport, errReq := p.generateTransport(p.request, ip, probe.Remaining(ctx))
 	if errReq != nil {
 		pi := probe.ProbeInfo{Status: probe.Error, Error: errReq.Error()}
 		pa := ProbeHTTPReturnInterface{ProbeInfo: pi}
@@ -251,10 +260,9 @@ func (p *Probe) sendTheRequest(ip string, timeout time.Duration) (ProbeHTTPRetur
 	var dnsDuration, connDuration, resDuration, reqDuration, delayDuration time.Duration
 	var req *http.Request
 
-	// Set Client
+	// Set Client, the timeout is the deadline of ctx
 	client := &http.Client{
 		Transport: transport,
-		Timeout:   timeout,
 	}
 
 	if p.DontFollowRedirects == true {
@@ -266,14 +274,19 @@ func (p *Probe) sendTheRequest(ip string, timeout time.Duration) (ProbeHTTPRetur
 
 	trace := &httptrace.ClientTrace{
 		DNSStart: func(info httptrace.DNSStartInfo) {
+			probe.SetPhase(ctx, probe.PhaseDNS)
 			dnsStart = time.Since(startTime)
 		},
 		DNSDone: func(dnsInfo httptrace.DNSDoneInfo) {
 			dnsDuration = time.Since(startTime) - dnsStart
 		},
 		GetConn: func(h string) {
+			probe.SetPhase(ctx, probe.PhaseConnect)
 			connStart = time.Since(startTime)
 		},
+		TLSHandshakeStart: func() {
+			probe.SetPhase(ctx, probe.PhaseTLS)
+		},
 		GotConn: func(connInfo httptrace.GotConnInfo) {
 			if !connInfo.Reused {
 				connDuration = time.Since(startTime) - connStart
@@ -281,16 +294,18 @@ func (p *Probe) sendTheRequest(ip string, timeout time.Duration) (ProbeHTTPRetur
 			reqStart = time.Since(startTime)
 		},
 		WroteRequest: func(w httptrace.WroteRequestInfo) {
+			probe.SetPhase(ctx, probe.PhaseFirstByte)
 			reqDuration = time.Since(startTime) - reqStart
 			delayStart = time.Since(startTime)
 		},
 		GotFirstResponseByte: func() {
+			probe.SetPhase(ctx, probe.PhaseTransfer)
 			delayDuration = time.Since(startTime) - delayStart
 			resStart = time.Since(startTime)
 		},
 	}
 
-	req = p.request.WithContext(httptrace.WithClientTrace(p.request.Context(), trace))
+	req = p.request.WithContext(httptrace.WithClientTrace(ctx, trace))
 
 	// QUICK FIX to add probe body
 	// https://stackoverflow.com/questions/31337891/net-http-http-contentlength-222-with-body-length-0
@@ -300,8 +315,7 @@ func (p *Probe) sendTheRequest(ip string, timeout time.Duration) (ProbeHTTPRetur
 	if errReq == nil {
 		//size = resp.ContentLength
 		//code = resp.StatusCode
-		_, _ = io.Copy(ioutil.Discard, resp.Body)
-		_, _ = ioutil.ReadAll(resp.Body)
+		_, errReq = io.Copy(ioutil.Discard, resp.Body)
 		resp.Body.Close()
 	}
 
@@ -309,6 +323,12 @@ func (p *Probe) sendTheRequest(ip string, timeout time.Duration) (ProbeHTTPRetur
 
 	rst := genResponsesTime(req.URL.Scheme, startTime, startTime, startTime, startTime, startTime, startTime, startTime, startTime)
 
+	// Deadline exceeded: the phase in flight is reported
+	if probe.TimedOut(ctx) {
+		pa := ProbeHTTPReturnInterface{ProbeInfo: probe.TimeoutInfo(ctx, ip, time.Since(startTime)), ResponsesTime: rst}
+		return pa, fmt.Errorf("%s", pa.ProbeInfo.Error)
+	}
+
 	// Error
 	if errReq != nil {
 
@@ -7,6 +7,7 @@ package http
 //
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -23,6 +24,10 @@ const Name = "http"
 const timeout = time.Second * 30
 const defaultHTTPSport = 80
 
+func init() {
+	probe.Register(Name, New)
+}
+
 // New returns a new Probe
 func New() probe.Probe {
 	return &Probe{}
@@ -41,6 +46,11 @@ func (Probe) GetDefaultFrequency() time.Duration {
 	return time.Second * 45
 }
 
+// AnswerSchema returns the fields of ProbeHTTPReturnInterface
+func (Probe) AnswerSchema() *probe.Schema {
+	return probe.SchemaOf(ProbeHTTPReturnInterface{})
+}
+
 // Headers represents header HTTP for Request
 type headers map[string]string
 
@@ -151,16 +161,16 @@ func (pa ProbeHTTPReturnInterface) Values() map[string]interface{} {
 }
 
 type responsesTime struct {
-	DnsLookup        time.Duration
-	TcpConnection    time.Duration
-	TlsHandshake     time.Duration
-	ServerProcessing time.Duration
-	ContentTransfert time.Duration
-	Namelookup       time.Duration
-	Connect          time.Duration
-	Pretransfert     time.Duration
-	Starttransfert   time.Duration
-	Total            time.Duration
+	DnsLookup        time.Duration `json:"dnslookup"`
+	TcpConnection    time.Duration `json:"tcpconnection"`
+	TlsHandshake     time.Duration `json:"tlshandshake"`
+	ServerProcessing time.Duration `json:"serverprocessing"`
+	ContentTransfert time.Duration `json:"contenttransfert"`
+	Namelookup       time.Duration `json:"namelookup"`
+	Connect          time.Duration `json:"connect"`
+	Pretransfert     time.Duration `json:"pretransfert"`
+	Starttransfert   time.Duration `json:"starttransfert"`
+	Total            time.Duration `json:"total"`
 }
 
 // GenerateTStepName return a tstep name if non existent
@@ -221,10 +231,10 @@ func (p *Probe) Initialize(step probe.StepProbe) error {
 }
 
 // Start the probe request
-func (p *Probe) Run(timeout time.Duration) (probeReturns []probe.ProbeReturnInterface) {
+func (p *Probe) Run(ctx context.Context) (probeReturns []probe.ProbeReturnInterface) {
 
 	// Start the Request
-	probeAnswers := p.work(timeout)
+	probeAnswers := p.work(ctx)
 
 	return probeAnswers
 
@@ -232,9 +242,9 @@ func (p *Probe) Run(timeout time.Duration) (probeReturns []probe.ProbeReturnInte
 
 // work déclenche l'appel "metier" de la probe.
 // Le switch sert à appeller une fonction particuliére en fonction des info de la probe.
-func (p *Probe) work(timeout time.Duration) []probe.ProbeReturnInterface {
+func (p *Probe) work(ctx context.Context) []probe.ProbeReturnInterface {
 
-	res := p.process(timeout)
+	res := p.process(ctx)
 
 	return res
 
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/assertion"
+)
+
+func TestProbe_AnswerSchema_responsesTime(t *testing.T) {
+
+	asserts, err := assertion.GetCleanAsserts([]assertion.RawAssert{
+		{Assertion: "expr: responses_time.tlshandshake < responses_time.total * 0.3"},
+		{Assertion: "responses_time.total < 1s"},
+	}, Probe{}.AnswerSchema())
+	if err != nil {
+		t.Fatalf("GetCleanAsserts() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		tlsHandshake time.Duration
+		want         bool
+	}{
+		{name: "fast handshake", tlsHandshake: 10 * time.Millisecond, want: true},
+		{name: "slow handshake", tlsHandshake: 50 * time.Millisecond, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer := ProbeHTTPReturnInterface{
+				HTTPcode:      200,
+				ResponsesTime: responsesTime{TlsHandshake: tt.tlsHandshake, Total: 100 * time.Millisecond},
+			}
+			if got, cause, _ := assertion.ApplyAssert(answer, &asserts[0]); got != tt.want {
+				t.Errorf("ApplyAssert(%q) = %v (%s), want %v", asserts[0].AssertConditionsLong(), got, cause, tt.want)
+			}
+			if got, cause, _ := assertion.ApplyAssert(answer, &asserts[1]); !got {
+				t.Errorf("ApplyAssert(%q) = false (%s)", asserts[1].AssertConditionsLong(), cause)
+			}
+		})
+	}
+}
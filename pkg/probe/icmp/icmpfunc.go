@@ -1,6 +1,7 @@
 package icmp
 
 import (
+	"context"
 	"fmt"
 	"github.com/sparrc/go-ping"
 	"github.com/vincoll/vigie/pkg/probe"
@@ -8,10 +9,15 @@ import (
 )
 
 // Ping
-func (p *Probe) process(timeout time.Duration) (probeAnswers []probe.ProbeReturnInterface) {
+func (p *Probe) process(ctx context.Context) (probeAnswers []probe.ProbeReturnInterface) {
 
 	// Resolve only some IPv
-	ips, err := probe.GetIPsFromHostname(p.Host, p.IPversion)
+	start := time.Now()
+	ips, err := probe.GetIPsFromHostname(ctx, p.Host, p.IPversion)
+	if probe.TimedOut(ctx) {
+		pi := probe.TimeoutInfo(ctx, "", time.Since(start))
+		return []probe.ProbeReturnInterface{&ProbeICMPReturnInterface{ProbeInfo: pi}}
+	}
 	if err != nil {
 		pi := probe.ProbeInfo{Status: probe.Error, Error: err.Error()}
 		probeAnswers = make([]probe.ProbeReturnInterface, 0)
@@ -50,7 +56,7 @@ func (p *Probe) process(timeout time.Duration) (probeAnswers []probe.ProbeReturn
 		}
 		wg.Wait()
 	*/
-	pa, errReq := p.sendICMP(ips[0], timeout)
+	pa, errReq := p.sendICMP(ctx, ips[0])
 	if errReq != nil {
 		// print(errReq)
 	}
@@ -59,7 +65,7 @@ func (p *Probe) process(timeout time.Duration) (probeAnswers []probe.ProbeReturn
 	return probeAnswers
 }
 
-func (p Probe) sendICMP(ip string, timeout time.Duration) (ProbeICMPReturnInterface, error) {
+func (p Probe) sendICMP(ctx context.Context, ip string) (ProbeICMPReturnInterface, error) {
 
 	// Create a Custom Pinger
 	pinger, err := ping.NewPinger(ip)
@@ -69,11 +75,25 @@ func (p Probe) sendICMP(ip string, timeout time.Duration) (ProbeICMPReturnInterf
 	} else {
 		// Need setcap cap_net_raw=+ep on vigie binary
 		pinger.SetPrivileged(true)
-		pinger.Timeout = timeout
+		if left := probe.Remaining(ctx); left > 0 {
+			pinger.Timeout = left
+		}
 		pinger.Interval = p.Interval
 		pinger.Size = p.PayloadSize
 		pinger.Count = p.Count
 
+		// Stop the ping when ctx is done
+		probe.SetPhase(ctx, probe.PhaseRun)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				pinger.Stop()
+			case <-done:
+			}
+		}()
+
 		// Launch Ping
 		pinger.Run()
 
@@ -1,6 +1,7 @@
 package icmp
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -37,6 +38,11 @@ func (Probe) GetDefaultFrequency() time.Duration {
 	return time.Second * 10
 }
 
+// AnswerSchema returns the fields of ProbeICMPReturnInterface
+func (Probe) AnswerSchema() *probe.Schema {
+	return probe.SchemaOf(ProbeICMPReturnInterface{})
+}
+
 // Probe struct. Json and yaml descriptor are used for json output
 type Probe struct {
 	Name        string        `json:"name"`
@@ -178,7 +184,9 @@ func (p *Probe) Initialize(step probe.StepProbe) error {
 	}
 
 	// Test if ICMP Capable
-	_, errICMP := p.sendICMP("127.0.0.1", time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, errICMP := p.sendICMP(ctx, "127.0.0.1")
 	if errICMP != nil {
 		return fmt.Errorf("No icmp packet can't be sent (tested on localhost). Linux required some system tweak to send icmp (cf: https://github.com/sparrc/go-ping#note-on-linux-support).")
 	}
@@ -187,10 +195,10 @@ func (p *Probe) Initialize(step probe.StepProbe) error {
 	return nil
 }
 
-func (p *Probe) Run(timeout time.Duration) (probeReturns []probe.ProbeReturnInterface) {
+func (p *Probe) Run(ctx context.Context) (probeReturns []probe.ProbeReturnInterface) {
 
 	// Start the Request
-	probeAnswers := p.process(timeout)
+	probeAnswers := p.process(ctx)
 	return probeAnswers
 
 }
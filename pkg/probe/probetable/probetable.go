@@ -1,21 +1,19 @@
+// Package probetable imports the probes built into Vigie,
+// each probe registers itself with probe.Register.
+// Third-party probes are imported the same way.
 package probetable
 
 import (
-	"github.com/vincoll/vigie/pkg/probe"
-	"github.com/vincoll/vigie/pkg/probe/http"
-)
-
-var AvailableProbes = map[string]probe.Probe{
-
 	/*
-		x509.Name:  x509.New(),
-		hash.Name:  hash.New(),
-		dns.Name:   dns.New(),
-		port.Name:  port.New(),
-		debug.Name: debug.New(),
-		icmp.Name:  icmp.New(),
-
+		_ "github.com/vincoll/vigie/pkg/probe/x509"
+		_ "github.com/vincoll/vigie/pkg/probe/hash"
+		_ "github.com/vincoll/vigie/pkg/probe/dns"
+		_ "github.com/vincoll/vigie/pkg/probe/port"
+		_ "github.com/vincoll/vigie/pkg/probe/debug"
+		_ "github.com/vincoll/vigie/pkg/probe/icmp"
 	*/
 	// NEW VIGIE TIME SERIES SYSTEM
-	http.Name: http.New(),
-}
+	_ "github.com/vincoll/vigie/pkg/probe/exec"
+	_ "github.com/vincoll/vigie/pkg/probe/external"
+	_ "github.com/vincoll/vigie/pkg/probe/http"
+)
@@ -0,0 +1,62 @@
+package probe
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory returns a new Probe, initialized later with the values of a step.
+type Factory func() Probe
+
+var registry = struct {
+	sync.RWMutex
+	factories map[string]Factory
+}{factories: make(map[string]Factory)}
+
+// Register makes a probe type available in the test files.
+// It is meant to be called in the init() of the probe package,
+// it panics if the name is empty, already registered or if factory is nil.
+func Register(name string, factory Factory) {
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if name == "" {
+		panic("probe: Register with an empty name")
+	}
+	if factory == nil {
+		panic(fmt.Sprintf("probe: Register %q with a nil factory", name))
+	}
+	if _, dup := registry.factories[name]; dup {
+		panic(fmt.Sprintf("probe: Register called twice for probe %q", name))
+	}
+	registry.factories[name] = factory
+}
+
+// New returns a new Probe of this type.
+func New(name string) (Probe, error) {
+
+	registry.RLock()
+	factory, found := registry.factories[name]
+	registry.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("probe type %q is not implemented in Vigie (available: %v)", name, Registered())
+	}
+	return factory(), nil
+}
+
+// Registered returns the names of the registered probes.
+func Registered() []string {
+
+	registry.RLock()
+	names := make([]string, 0, len(registry.factories))
+	for name := range registry.factories {
+		names = append(names, name)
+	}
+	registry.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,53 @@
+package probe
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeProbe struct{}
+
+func (fakeProbe) Run(ctx context.Context) []ProbeReturnInterface { return nil }
+func (fakeProbe) GetName() string                                { return "fake" }
+func (fakeProbe) Initialize(StepProbe) error                     { return nil }
+func (fakeProbe) GenerateTStepName() string                      { return "fake" }
+func (fakeProbe) GetDefaultTimeout() time.Duration               { return time.Second }
+func (fakeProbe) GetDefaultFrequency() time.Duration             { return time.Second }
+func (fakeProbe) Labels() map[string]string                      { return nil }
+func (fakeProbe) AnswerSchema() *Schema                          { return nil }
+
+func TestRegister(t *testing.T) {
+
+	Register("fake", func() Probe { return &fakeProbe{} })
+
+	p, err := New("fake")
+	if err != nil || p.GetName() != "fake" {
+		t.Fatalf("New() = %v, %v", p, err)
+	}
+
+	if _, err := New("unknown"); err == nil || !strings.Contains(err.Error(), "fake") {
+		t.Errorf("New() error = %v, want the list of available probes", err)
+	}
+
+	tests := []struct {
+		name    string
+		probe   string
+		factory Factory
+	}{
+		{name: "duplicate", probe: "fake", factory: func() Probe { return &fakeProbe{} }},
+		{name: "empty_name", probe: "", factory: func() Probe { return &fakeProbe{} }},
+		{name: "nil_factory", probe: "other", factory: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register() did not panic")
+				}
+			}()
+			Register(tt.probe, tt.factory)
+		})
+	}
+}
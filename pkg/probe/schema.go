@@ -0,0 +1,214 @@
+package probe
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the type of a field in a probe answer.
+type FieldType int8
+
+const (
+	FieldAny FieldType = iota // Unknown content (interface{}, custom marshaller)
+	FieldString
+	FieldNumber
+	FieldBool
+	FieldDuration
+	FieldTime
+	FieldArray
+	FieldObject
+)
+
+func (ft FieldType) String() string {
+	switch ft {
+	case FieldString:
+		return "string"
+	case FieldNumber:
+		return "number"
+	case FieldBool:
+		return "bool"
+	case FieldDuration:
+		return "duration"
+	case FieldTime:
+		return "time"
+	case FieldArray:
+		return "array"
+	case FieldObject:
+		return "object"
+	default:
+		return "any"
+	}
+}
+
+// Schema describes the answer of a probe (field names and types),
+// it is derived from the json representation of the return struct.
+type Schema struct {
+	Type   FieldType
+	Fields map[string]*Schema // Fields of an object with known keys
+	Elem   *Schema            // Elements of an array, or values of a map
+}
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	probeDurationType = reflect.TypeOf(ProbeDuration{})
+	timeType          = reflect.TypeOf(time.Time{})
+	probeInfoType     = reflect.TypeOf(ProbeInfo{})
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// SchemaOf returns the Schema of a probe answer struct.
+func SchemaOf(answer interface{}) *Schema {
+	return schemaOfType(reflect.TypeOf(answer))
+}
+
+func schemaOfType(t reflect.Type) *Schema {
+
+	if t == nil {
+		return &Schema{Type: FieldAny}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case durationType, probeDurationType:
+		return &Schema{Type: FieldDuration}
+	case timeType:
+		return &Schema{Type: FieldTime}
+	}
+
+	// A custom MarshalJSON can output anything
+	// ProbeInfo keeps its field names.
+	if t != probeInfoType && (t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType)) {
+		return &Schema{Type: FieldAny}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: FieldString}
+	case reflect.Bool:
+		return &Schema{Type: FieldBool}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return &Schema{Type: FieldNumber}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is base64 encoded
+			return &Schema{Type: FieldString}
+		}
+		return &Schema{Type: FieldArray, Elem: schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: FieldObject, Elem: schemaOfType(t.Elem())}
+	case reflect.Struct:
+		s := &Schema{Type: FieldObject, Fields: make(map[string]*Schema, t.NumField())}
+		addStructFields(s, t)
+		return s
+	default:
+		return &Schema{Type: FieldAny}
+	}
+}
+
+// addStructFields adds the fields of struct t as encoding/json would.
+func addStructFields(s *Schema, t reflect.Type) {
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if tagName := strings.TrimSpace(strings.Split(tag, ",")[0]); tagName != "" {
+			name = tagName
+		}
+
+		// Embedded struct fields are promoted
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			addStructFields(s, f.Type)
+			continue
+		}
+		if f.PkgPath != "" {
+			// Unexported
+			continue
+		}
+		s.Fields[name] = schemaOfType(f.Type)
+	}
+}
+
+// Lookup returns the schema of the value at path (gjson syntax).
+// Paths using gjson wildcards, queries or modifiers are not checked.
+func (s *Schema) Lookup(path string) (*Schema, bool) {
+
+	cur := s
+	for _, part := range splitPath(path) {
+
+		if cur.Type == FieldAny {
+			return cur, true
+		}
+		if strings.ContainsAny(part, "*?|@") || strings.HasPrefix(part, "#(") {
+			return &Schema{Type: FieldAny}, true
+		}
+
+		switch cur.Type {
+		case FieldArray:
+			if part == "#" {
+				// Length of the array
+				return &Schema{Type: FieldNumber}, true
+			}
+			if _, err := strconv.Atoi(part); err != nil {
+				return nil, false
+			}
+			cur = cur.Elem
+		case FieldObject:
+			if cur.Fields == nil {
+				// Map with any key
+				cur = cur.Elem
+				continue
+			}
+			next, ok := cur.Fields[part]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		default:
+			// Scalar values have no child
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Keys returns the sorted top level keys of an object schema.
+func (s *Schema) Keys() []string {
+	keys := make([]string, 0, len(s.Fields))
+	for k := range s.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitPath splits a gjson path on its unescaped dots.
+func splitPath(path string) []string {
+
+	parts := make([]string, 0)
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path):
+			i++
+			sb.WriteByte(path[i])
+		case path[i] == '.':
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(path[i])
+		}
+	}
+	return append(parts, sb.String())
+}
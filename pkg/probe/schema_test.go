@@ -0,0 +1,74 @@
+package probe
+
+import (
+	"testing"
+	"time"
+)
+
+type testAnswer struct {
+	ProbeInfo ProbeInfo         `json:"probeinfo"`
+	Code      int               `json:"code"`
+	Body      string            `json:"body"`
+	BodyJSON  interface{}       `json:"bodyjson"`
+	Headers   map[string]string `json:"headers"`
+	IPs       []string          `json:"ips"`
+	NotAfter  time.Time         `json:"notafter"`
+	Timings   struct {
+		Total time.Duration
+	} `json:"timings"`
+	hidden string
+}
+
+func TestSchemaLookup(t *testing.T) {
+
+	schema := SchemaOf(testAnswer{})
+
+	tests := []struct {
+		name      string
+		path      string
+		wantFound bool
+		want      FieldType
+	}{
+		{name: "number", path: "code", wantFound: true, want: FieldNumber},
+		{name: "string", path: "body", wantFound: true, want: FieldString},
+		{name: "probeinfo_duration", path: "probeinfo.responsetime", wantFound: true, want: FieldDuration},
+		{name: "probeinfo_status", path: "probeinfo.status", wantFound: true, want: FieldNumber},
+		{name: "any_subkey", path: "bodyjson.foo.bar", wantFound: true, want: FieldAny},
+		{name: "map_key", path: "headers.Content-Type", wantFound: true, want: FieldString},
+		{name: "array", path: "ips", wantFound: true, want: FieldArray},
+		{name: "array_index", path: "ips.0", wantFound: true, want: FieldString},
+		{name: "array_length", path: "ips.#", wantFound: true, want: FieldNumber},
+		{name: "time", path: "notafter", wantFound: true, want: FieldTime},
+		{name: "untagged_field", path: "timings.Total", wantFound: true, want: FieldDuration},
+		{name: "wildcard", path: "timings.T*", wantFound: true, want: FieldAny},
+		{name: "typo", path: "cod", wantFound: false},
+		{name: "unexported", path: "hidden", wantFound: false},
+		{name: "child_of_scalar", path: "body.length", wantFound: false},
+		{name: "array_bad_index", path: "ips.first", wantFound: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := schema.Lookup(tt.path)
+			if found != tt.wantFound {
+				t.Fatalf("Lookup(%q) found = %v, want %v", tt.path, found, tt.wantFound)
+			}
+			if found && got.Type != tt.want {
+				t.Errorf("Lookup(%q) type = %v, want %v", tt.path, got.Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+
+	got := splitPath(`headers.x\.forwarded.0`)
+	want := []string{"headers", "x.forwarded", "0"}
+	if len(got) != len(want) {
+		t.Fatalf("splitPath() got = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("splitPath() got = %v, want %v", got, want)
+		}
+	}
+}
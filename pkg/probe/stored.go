@@ -0,0 +1,36 @@
+package probe
+
+import "encoding/json"
+
+// StoredReturn is a probe result restored from the state store after a restart:
+// its answer is the dump of the original result, it is exposed as such.
+type StoredReturn struct {
+	Answer    map[string]interface{}
+	ProbeInfo ProbeInfo
+	Lbls      map[string]string
+	Vals      map[string]interface{}
+}
+
+func (sr StoredReturn) StructAnswer() interface{} {
+	return sr.Answer
+}
+
+func (sr StoredReturn) DumpAnswer() map[string]interface{} {
+	return sr.Answer
+}
+
+func (sr StoredReturn) GetProbeInfo() ProbeInfo {
+	return sr.ProbeInfo
+}
+
+func (sr StoredReturn) Labels() map[string]string {
+	return sr.Lbls
+}
+
+func (sr StoredReturn) Values() map[string]interface{} {
+	return sr.Vals
+}
+
+func (sr StoredReturn) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sr.Answer)
+}
@@ -1,6 +1,7 @@
 package probe
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 )
@@ -20,11 +21,12 @@ const (
 // ProbeInfo details
 // DO NOT EDIT
 type ProbeInfo struct {
-	Error        string        `json:"error"`        // Error Details
-	Status       Status        `json:"status"`       // Probe Status (OK, KO, TO..)
-	ProbeCode    int           `json:"probecode"`    // ProbeCode for some specific error handling
-	ResponseTime time.Duration `json:"responsetime"` // ResponseTime of the request
-	IPresolved   string        `json:"ipresolved"`   // IPresolved (if multiples A / AAAA behind a FQDN)
+	Error        string        `json:"error"`           // Error Details
+	Status       Status        `json:"status"`          // Probe Status (OK, KO, TO..)
+	ProbeCode    int           `json:"probecode"`       // ProbeCode for some specific error handling
+	ResponseTime time.Duration `json:"responsetime"`    // ResponseTime of the request
+	IPresolved   string        `json:"ipresolved"`      // IPresolved (if multiples A / AAAA behind a FQDN)
+	Phase        string        `json:"phase,omitempty"` // Phase in flight when the probe timed out (dns, connect, tls, first_byte...)
 }
 
 func (pi ProbeInfo) MarshalJSON() ([]byte, error) {
@@ -39,6 +41,30 @@ func (pi ProbeInfo) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// UnmarshalJSON reads a ProbeInfo written by MarshalJSON.
+func (pi *ProbeInfo) UnmarshalJSON(data []byte) error {
+
+	type Copy ProbeInfo
+	aux := struct {
+		ResponseTime string `json:"responsetime"`
+		*Copy
+	}{Copy: (*Copy)(pi)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.ResponseTime == "" {
+		pi.ResponseTime = 0
+		return nil
+	}
+	rt, err := time.ParseDuration(aux.ResponseTime)
+	if err != nil {
+		return err
+	}
+	pi.ResponseTime = rt
+	return nil
+}
+
 type ProbeDuration struct {
 	time.Duration
 }
@@ -64,12 +90,13 @@ type ProbeReturnInterface interface {
 
 // Probe execute a testStep.
 type Probe interface {
-	// Start run a Step TStep
-	Run(timeout time.Duration) []ProbeReturnInterface
+	// Run runs a Step TStep, it must return when ctx is done (timeout)
+	Run(ctx context.Context) []ProbeReturnInterface
 	GetName() string
 	Initialize(StepProbe) error
 	GenerateTStepName() string
 	GetDefaultTimeout() time.Duration
 	GetDefaultFrequency() time.Duration
 	Labels() map[string]string
+	AnswerSchema() *Schema
 }
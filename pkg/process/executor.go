@@ -1,6 +1,7 @@
 package process
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -8,6 +9,9 @@ import (
 	"github.com/vincoll/vigie/pkg/teststruct"
 )
 
+// failsafeGrace is the time given to a probe to return once its context is done.
+const failsafeGrace = time.Second
+
 // runTestStepProbe runs a probe test
 // The probe can get an answer, or the probe can timeout.
 //
@@ -15,13 +19,18 @@ import (
 // the probe will return multiples answser for each IP
 func runTestStepProbe(pWrap *teststruct.ProbeWrap) ([]probe.ProbeReturnInterface, error) {
 
+	// The probe stops at the deadline, its results tell the phase in flight
+	ctx, cancel := context.WithTimeout(context.Background(), pWrap.Timeout)
+	defer cancel()
+	ctx, phases := probe.WithPhaseTracker(ctx)
+
 	// Create Channel for Probe ResultStatus
 	chProbeReturn := make(chan []probe.ProbeReturnInterface, 1)
 	//
 	// Goroutine to run the probe teststep
 	//
 	go func() {
-		chProbeReturn <- pWrap.Run()
+		chProbeReturn <- pWrap.Run(ctx)
 	}()
 
 	// Select dépend de l'issue de l'exec de la probe
@@ -29,23 +38,12 @@ func runTestStepProbe(pWrap *teststruct.ProbeWrap) ([]probe.ProbeReturnInterface
 	case probeRtrn := <-chProbeReturn: // Retour d'info de la probe
 		return probeRtrn, nil
 
-	// If no answer (timeout)
-	// Failsafe : timeout
-	case <-time.After(pWrap.Timeout):
-		/*
-			probeReturns := make([]probe.ProbeReturnInterface, 0)
-
-			pi := probe.ProbeInfo{
-				Error:  fmt.Sprintf("timeout after %s", pWrap.Frequency.String()),
-				Status: probe.Timeout,
-			}
-
-			pr := probe.ProbeReturn{
-				Answer:    nil,
-				ProbeInfo: pi,
-			}
-			probeReturns = append(probeReturns, pr)
-		*/
-		return nil, fmt.Errorf("FailSafe: %s", pWrap.Timeout.String())
+	// Failsafe: the probe does not honor its context
+	case <-time.After(pWrap.Timeout + failsafeGrace):
+		phase := phases.Phase()
+		if phase == "" {
+			phase = probe.PhaseRun
+		}
+		return nil, fmt.Errorf("FailSafe: timeout after %s during %s", pWrap.Timeout.String(), phase)
 	}
 }
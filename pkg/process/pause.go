@@ -0,0 +1,39 @@
+package process
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/vincoll/vigie/pkg/alertmanager"
+	"github.com/vincoll/vigie/pkg/pause"
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+// checkPause tells if the TestStep is paused, by its UID or the one of its TestCase or TestSuite.
+func checkPause(task teststruct.Task) (pause.Pause, bool) {
+	return pause.Mgr.Check(task.UIDs(), time.Now())
+}
+
+// ApplyPause sets the pause of the TestStep (an empty uID resumes it), then updates
+// its parents. A paused TestStep leaves the alerting. Returns true if it has changed.
+func ApplyPause(task teststruct.Task, uID string) bool {
+
+	if !task.TestStep.SetPaused(uID) {
+		return false
+	}
+
+	updateParentTestStruct(task, time.Now())
+
+	// Paused: the TestStep leaves the alerting. Resumed: the next run tells its state
+	if uID != "" && alertmanager.AM.IsEnabled() {
+		_ = alertmanager.AM.AddToAlertList(task)
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"package": "process", "testcase": task.TestCase.Name, "teststep": task.TestStep.Name, "testsuite": task.TestSuite.Name, "pause": uID,
+	}).Infof("TestStep state has changed to %q.", task.TestStep.GetStatus().String())
+
+	return true
+}
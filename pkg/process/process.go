@@ -1,25 +1,135 @@
 package process
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/vincoll/vigie/pkg/alertmanager"
+	"github.com/vincoll/vigie/pkg/maintenance"
 	"github.com/vincoll/vigie/pkg/probe"
 	"github.com/vincoll/vigie/pkg/teststruct"
 	"github.com/vincoll/vigie/pkg/utils"
 )
 
-// ProcessTask runs the teststep then write the result into a DB
-func ProcessTask(task teststruct.Task) *teststruct.VigieResult {
+// Outcome is the run of a TestStep, applied to the TestStep by Apply.
+type Outcome struct {
+	Result        teststruct.VigieResult
+	Paused        string            // UID of the pause stopping the step, the step is not run
+	Maintenance   maintenance.Match // Window or silence matching the step
+	InMaintenance bool
+}
+
+// RunTask runs the teststep without changing it: paused or in a maintenance pausing it,
+// the step is not run; a failing dependency skips it.
+func RunTask(task teststruct.Task) Outcome {
+
+	// Paused: the step is not run until resumed
+	if p, paused := checkPause(task); paused {
+		return Outcome{
+			Result: teststruct.VigieResult{LastAttempt: time.Now(), Status: teststruct.Paused},
+			Paused: p.UID,
+		}
+	}
+
+	// Maintenance: the failures are not alerted, or the step is not run
+	maint, inMaintenance := checkMaintenance(task)
+	out := Outcome{Maintenance: maint, InMaintenance: inMaintenance}
+
+	if maint.Pause {
+		out.Result = teststruct.VigieResult{LastAttempt: time.Now(), Maintenance: maint.Reason}
+		return out
+	}
+
+	if rootCause, failing := task.TestStep.FailingDependency(); failing {
+		// A dependency is down: the step is skipped, the root cause is alerted
+		out.Result = teststruct.VigieResult{
+			LastAttempt: time.Now(),
+			Status:      teststruct.DependencyFailed,
+			Issue:       fmt.Sprintf("dependency %s is down", rootCause),
+			RootCause:   rootCause,
+		}
+	} else {
+		out.Result = runTestStep(task.TestStep)
+	}
+	out.Result.Maintenance = maint.Reason
+	return out
+}
+
+// Apply writes the outcome of a run into the teststep, then updates its parents
+// and the alerting if its status has changed. It must have a single caller at once:
+// the state manager.
+func Apply(task teststruct.Task, out Outcome) {
+
+	if out.Paused != "" {
+		ApplyPause(task, out.Paused)
+		return
+	}
+
+	leftMaintenance := task.TestStep.SetMaintenance(out.Maintenance.Reason) != "" && !out.InMaintenance
+	if out.Maintenance.Pause {
+		return
+	}
+
+	// WriteResult write probe result into TestStep
+	// Then return if the TestStep ResultStatus has changed
+	anyStateChange, alertEvent := task.TestStep.WriteResult(out.Result)
+
+	// The state of a step leaving its maintenance is sent to the alerting
+	if leftMaintenance && alertmanager.AM.IsEnabled() {
+		_ = alertmanager.AM.AddToAlertList(task)
+	}
 
-	testResult := runTestStep(task.TestStep)
+	if !anyStateChange {
+		return
+	}
 
-	return &testResult
+	// Update TestSuites and TC state because a change occurred
+	updateParentTestStruct(task, out.Result.LastAttempt)
 
+	// The names do not change once imported
+	status := out.Result.Status
+	logger := utils.Log.WithFields(logrus.Fields{
+		"package": "process", "testcase": task.TestCase.Name, "teststep": task.TestStep.Name, "testsuite": task.TestSuite.Name,
+	})
+	if status.IsFailure() {
+		logger.Warnf("TestStep state has changed to %q.", status.String())
+	} else {
+		logger.Infof("TestStep state has changed to %q.", status.String())
+	}
+
+	// During a maintenance only the recoveries are alerted
+	if out.InMaintenance && status != teststruct.Success {
+		alertEvent = false
+	}
+
+	if alertEvent && !leftMaintenance && alertmanager.AM.IsEnabled() {
+		_ = alertmanager.AM.AddToAlertList(task)
+	}
 }
 
-// runTestStep runs Probe and Check Assertions (if no timeout or failure)
+// checkMaintenance tells if the TestStep is in a maintenance window or silenced.
+func checkMaintenance(task teststruct.Task) (maintenance.Match, bool) {
+
+	task.TestStep.Mutex.RLock()
+	windows := task.TestStep.Maintenance
+	task.TestStep.Mutex.RUnlock()
+
+	labels := task.Labels()
+	match, inMaintenance := maintenance.Mgr.Check(labels, windows, time.Now())
+	if inMaintenance {
+		utils.Log.WithFields(logrus.Fields{
+			"package": "process", "teststep": labels["teststep"], "maintenance": match.Reason,
+		}).Debug("TestStep is in maintenance")
+	}
+	return match, inMaintenance
+}
+
+// runTestStep renders the probe then runs it: retried on failure
+// up to Retry times, waiting Retrydelay (multiplied by Retrybackoff after each retry).
+// The retries hold a worker and a slot of the probe type: they end within the frequency of the step.
 func runTestStep(tStep *teststruct.TestStep) teststruct.VigieResult {
 
 	tStep.Mutex.RLock()
@@ -34,13 +144,68 @@ func runTestStep(tStep *teststruct.TestStep) teststruct.VigieResult {
 	var testRes teststruct.VigieResult
 	testRes.LastAttempt = time.Now()
 
+	// Render the probe of a step templated at each run
+	tStep.Mutex.RLock()
+	pWrap, errRender := tStep.ProbeWrap.Rendered()
+	tStep.Mutex.RUnlock()
+	if errRender != nil {
+		testRes.Status = teststruct.Failure
+		testRes.Issue = errRender.Error()
+		return testRes
+	}
+
+	delay := pWrap.Retrydelay
+	for attempt := 0; ; attempt++ {
+
+		start := time.Now()
+		testRes.TestResults, testRes.Status, testRes.Issue = runAttempt(tStep, &pWrap)
+		testRes.Attempts = append(testRes.Attempts, teststruct.Attempt{
+			Start:    start,
+			Duration: time.Since(start),
+			Status:   testRes.Status,
+			Issue:    testRes.Issue,
+		})
+
+		if !isRetryable(testRes.Status) || attempt >= pWrap.Retry {
+			break
+		}
+
+		if time.Since(testRes.LastAttempt)+delay > pWrap.Frequency {
+			utils.Log.WithFields(logrus.Fields{
+				"package":  "process",
+				"teststep": tStepName,
+				"attempt":  attempt + 1,
+			}).Debugf("TestStep %s, no retry in %s: it would end after the frequency %s", testRes.Status, delay, pWrap.Frequency)
+			break
+		}
+
+		utils.Log.WithFields(logrus.Fields{
+			"package":  "process",
+			"teststep": tStepName,
+			"attempt":  attempt + 1,
+		}).Debugf("TestStep %s, retry in %s", testRes.Status, delay)
+
+		if !waitRetry(delay) {
+			break
+		}
+		delay = nextDelay(delay, pWrap.Retrybackoff)
+	}
+
+	testRes.Recovered = len(testRes.Attempts) > 1 && !testRes.Status.IsFailure()
+
+	logStatus(tStepName, testRes)
+
+	return testRes
+}
+
+// runAttempt runs the Probe once and Check Assertions (if no timeout or failure)
+func runAttempt(tStep *teststruct.TestStep, pWrap *teststruct.ProbeWrap) ([]teststruct.TestResult, teststruct.StepStatus, string) {
+
 	// Run the Probe
-	probeReturns, issue := runTestStepProbe(&tStep.ProbeWrap)
+	probeReturns, issue := runTestStepProbe(pWrap)
 	if issue != nil {
 		// timeout: No Probe results => No need to assert any subtests
-		testRes.Status = teststruct.Timeout
-		testRes.Issue = issue.Error()
-		return testRes
+		return nil, teststruct.Timeout, issue.Error()
 	}
 
 	// Loop and check on all IPs resolved in the []ProbeReturn
@@ -72,12 +237,79 @@ func runTestStep(tStep *teststruct.TestStep) teststruct.VigieResult {
 		"value":    time.Since(start),
 	}).Tracef("Time to complete Teststep Assertion")
 
-	testRes.TestResults = vigieResults
-	testRes.Status = getFinalResultStatus(vigieResults)
+	status := getFinalResultStatus(vigieResults)
+	return vigieResults, status, timeoutIssue(status, vigieResults)
+}
 
-	logStatus(tStepName, testRes)
+// timeoutIssue returns the error of the first probe result in timeout,
+// it tells the phase in flight (dns, connect, tls, first_byte...).
+func timeoutIssue(status teststruct.StepStatus, vrs []teststruct.TestResult) string {
 
-	return testRes
+	if status != teststruct.Timeout {
+		return ""
+	}
+	for _, vr := range vrs {
+		if pi := vr.ProbeReturn.GetProbeInfo(); pi.Status == probe.Timeout {
+			return pi.Error
+		}
+	}
+	return ""
+}
+
+// isRetryable tells if a run ending with this status is retried.
+func isRetryable(status teststruct.StepStatus) bool {
+
+	switch status {
+	case teststruct.Timeout, teststruct.Error, teststruct.Failure, teststruct.AssertFailure:
+		return true
+	default:
+		return false
+	}
+}
+
+// stopping is closed by Stop: the retries waiting are given up.
+var stopping = struct {
+	sync.Mutex
+	ch chan struct{}
+}{ch: make(chan struct{})}
+
+// Stop gives up the retries waiting for their delay, at the shutdown of Vigie:
+// their TestSteps keep the result of their last attempt.
+func Stop() {
+
+	stopping.Lock()
+	defer stopping.Unlock()
+	select {
+	case <-stopping.ch:
+	default:
+		close(stopping.ch)
+	}
+}
+
+// waitRetry waits the delay before a retry, false if Vigie stops meanwhile.
+func waitRetry(delay time.Duration) bool {
+
+	stopping.Lock()
+	stopped := stopping.ch
+	stopping.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-stopped:
+		return false
+	}
+}
+
+// nextDelay returns the delay before the next retry: 1 keeps the same delay, 2 doubles it.
+func nextDelay(delay time.Duration, backoff float64) time.Duration {
+
+	if backoff <= 1 {
+		return delay
+	}
+	return time.Duration(float64(delay) * backoff)
 }
 
 // processProbeResult
@@ -132,15 +364,8 @@ func processProbeResult(tStep *teststruct.TestStep, pr probe.ProbeReturnInterfac
 	// an Error can be the expected result
 	// only if the error have been gracefully handle by the probe
 
-	assertResult, assertSuccess := tStep.AssertProbeResult(pr)
-	tr.AssertionResult = assertResult
-
-	// TestResult after Assertion
-	if assertSuccess == true {
-		tr.Status = teststruct.Success
-	} else {
-		tr.Status = teststruct.AssertFailure
-	}
+	// TestResult after Assertion (Success, Warning or AssertFailure)
+	tr.AssertionResult, tr.Status = tStep.AssertProbeResult(pr)
 
 	return tr
 }
@@ -150,6 +375,7 @@ func processProbeResult(tStep *teststruct.TestStep, pr probe.ProbeReturnInterfac
 func getFinalResultStatus(vrs []teststruct.TestResult) (finalStatus teststruct.StepStatus) {
 
 	/*
+		Warning       StepStatus = 2
 		Success       StepStatus = 1
 		NotDefined    StepStatus = 0
 		AssertFailure StepStatus = -1
@@ -166,7 +392,7 @@ func getFinalResultStatus(vrs []teststruct.TestResult) (finalStatus teststruct.S
 			return teststruct.Error
 		}
 
-		if vr.Status < finalStatus {
+		if vr.Status.WorseThan(finalStatus) {
 			finalStatus = vr.Status
 		}
 
@@ -180,6 +406,14 @@ func logStatus(tStepName string, pData teststruct.VigieResult) {
 	switch pData.Status {
 	case teststruct.Success:
 
+		if pData.Recovered {
+			utils.Log.WithFields(logrus.Fields{
+				"package":  "process",
+				"teststep": tStepName,
+			}).Debugf("TestStep OK - Assertion OK after %d attempts", len(pData.Attempts))
+			return
+		}
+
 		utils.Log.WithFields(logrus.Fields{
 			"package":  "process",
 			"teststep": tStepName,
@@ -199,6 +433,13 @@ func logStatus(tStepName string, pData teststruct.VigieResult) {
 			"teststep": tStepName,
 		}).Debugf("TestStep KO - timeout %s", pData.Issue)
 
+	case teststruct.Warning:
+
+		utils.Log.WithFields(logrus.Fields{
+			"package":  "process",
+			"teststep": tStepName,
+		}).Debugf("TestStep OK - Warning Assertion FAILED")
+
 	case teststruct.AssertFailure:
 
 		utils.Log.WithFields(logrus.Fields{
@@ -216,47 +457,3 @@ func logStatus(tStepName string, pData teststruct.VigieResult) {
 	}
 
 }
-
-/*
-func ProcessTask_OLD(task teststruct.Task) {
-
-	execTimestamp := time.Now()
-	procData := runTestStep(task.TestStep)
-
-	// LogResult write probe result into TestStep
-	// Then return if the TestStep ResultStatus has changed
-	anyStateChange, alertEvent := task.TestStep.LogResult(procData)
-	//task.WriteMetadataChanges(procData.LastAttempt)
-
-	if anyStateChange == true {
-
-		// Update TestSuites and TC state because a change occurred
-		updateParentTestStruct(task, execTimestamp)
-
-		task.RLockAll()
-		if task.TestStep.Status == teststruct.Success {
-			utils.Log.WithFields(logrus.Fields{
-				"package": "process", "testcase": task.TestCase.Name, "teststep": task.TestStep.Name, "testsuite": task.TestSuite.Name,
-			}).Infof("TestStep state has changed to %q.", task.TestStep.Status.String())
-		} else {
-			utils.Log.WithFields(logrus.Fields{
-				"package": "process", "testcase": task.TestCase.Name, "teststep": task.TestStep.Name, "testsuite": task.TestSuite.Name,
-			}).Warnf("TestStep state has changed to %q.", task.TestStep.Status.String())
-
-		}
-		task.RUnlockAll()
-
-		if alertEvent && alertmanager.AM.IsEnabled() {
-			_ = alertmanager.AM.AddToAlertList(task)
-		}
-
-	}
-
-	if tsdb.TsdbMgr.Enabled {
-		// Insert Task ResultStatus to DB
-		tsdb.TsdbMgr.WriteOnTsdbs(task)
-		// UpdateStatus TestSuite and TestCase
-		tsdb.TsdbMgr.UpdateTestStateToDB(task)
-	}
-}
-*/
@@ -1,8 +1,11 @@
 package process
 
 import (
+	"github.com/vincoll/vigie/pkg/probe/exec"
 	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
 	"testing"
+	"time"
 )
 
 func Test_getFinalResultStatus(t *testing.T) {
@@ -17,6 +20,7 @@ func Test_getFinalResultStatus(t *testing.T) {
 	vr_asrtfail := teststruct.TestResult{Status: teststruct.AssertFailure}
 	vr_nd := teststruct.TestResult{Status: teststruct.NotDefined}
 	vr_succ := teststruct.TestResult{Status: teststruct.Success}
+	vr_warn := teststruct.TestResult{Status: teststruct.Warning}
 
 	// Multiples VRS
 
@@ -76,6 +80,15 @@ func Test_getFinalResultStatus(t *testing.T) {
 	vrs_to_succ := []teststruct.TestResult{vr_to, vr_succ}
 	vrs_to_fail := []teststruct.TestResult{vr_to, vr_fail}
 
+	// warning . x
+	vrs_warn := []teststruct.TestResult{vr_warn}
+	vrs_warn_succ := []teststruct.TestResult{vr_warn, vr_succ}
+	vrs_succ_warn := []teststruct.TestResult{vr_succ, vr_warn}
+	vrs_warn_nd := []teststruct.TestResult{vr_warn, vr_nd}
+	vrs_warn_af := []teststruct.TestResult{vr_warn, vr_asrtfail}
+	vrs_af_warn := []teststruct.TestResult{vr_asrtfail, vr_warn}
+	vrs_warn_err := []teststruct.TestResult{vr_warn, vr_err}
+
 	tests := []struct {
 		name            string
 		args            arg
@@ -129,6 +142,14 @@ func Test_getFinalResultStatus(t *testing.T) {
 		{name: "vrs_to_af", args: arg{vrs_to_af}, wantFinalStatus: teststruct.Timeout},
 		{name: "vrs_to_succ", args: arg{vrs_to_succ}, wantFinalStatus: teststruct.Timeout},
 		{name: "vrs_to_fail", args: arg{vrs_to_fail}, wantFinalStatus: teststruct.Timeout},
+
+		{name: "vrs_warn", args: arg{vrs_warn}, wantFinalStatus: teststruct.Warning},
+		{name: "vrs_warn_succ", args: arg{vrs_warn_succ}, wantFinalStatus: teststruct.Warning},
+		{name: "vrs_succ_warn", args: arg{vrs_succ_warn}, wantFinalStatus: teststruct.Warning},
+		{name: "vrs_warn_nd", args: arg{vrs_warn_nd}, wantFinalStatus: teststruct.NotDefined},
+		{name: "vrs_warn_af", args: arg{vrs_warn_af}, wantFinalStatus: teststruct.AssertFailure},
+		{name: "vrs_af_warn", args: arg{vrs_af_warn}, wantFinalStatus: teststruct.AssertFailure},
+		{name: "vrs_warn_err", args: arg{vrs_warn_err}, wantFinalStatus: teststruct.Error},
 	}
 
 	for _, tt := range tests {
@@ -139,3 +160,86 @@ func Test_getFinalResultStatus(t *testing.T) {
 		})
 	}
 }
+
+func Test_nextDelay(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		backoff float64
+		want    time.Duration
+	}{
+		{name: "constant", delay: time.Second, backoff: 1, want: time.Second},
+		{name: "not set", delay: time.Second, backoff: 0, want: time.Second},
+		{name: "exponential", delay: time.Second, backoff: 2, want: 2 * time.Second},
+		{name: "fractional", delay: 2 * time.Second, backoff: 1.5, want: 3 * time.Second},
+		{name: "no delay", delay: 0, backoff: 2, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDelay(tt.delay, tt.backoff); got != tt.want {
+				t.Errorf("nextDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isRetryable(t *testing.T) {
+
+	tests := []struct {
+		status teststruct.StepStatus
+		want   bool
+	}{
+		{status: teststruct.Success, want: false},
+		{status: teststruct.Warning, want: false},
+		{status: teststruct.DependencyFailed, want: false},
+		{status: teststruct.AssertFailure, want: true},
+		{status: teststruct.Failure, want: true},
+		{status: teststruct.Timeout, want: true},
+		{status: teststruct.Error, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status.String(), func(t *testing.T) {
+			if got := isRetryable(tt.status); got != tt.want {
+				t.Errorf("isRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runTestStep_retries(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	// Each attempt is in timeout
+	timedOut := func(retryDelay, frequency time.Duration) *teststruct.TestStep {
+		return &teststruct.TestStep{ProbeWrap: teststruct.ProbeWrap{
+			Probe:      &exec.Probe{Command: []string{"sleep", "1"}},
+			Timeout:    10 * time.Millisecond,
+			Retry:      5,
+			Retrydelay: retryDelay,
+			Frequency:  frequency,
+		}}
+	}
+
+	// A second retry would end after the frequency
+	res := runTestStep(timedOut(200*time.Millisecond, 300*time.Millisecond))
+	if len(res.Attempts) != 2 || res.Status != teststruct.Timeout {
+		t.Errorf("runTestStep() made %d attempts (%s), want 2 within the frequency", len(res.Attempts), res.Status)
+	}
+
+	// Stop gives up the retry waiting
+	defer func() {
+		stopping.Lock()
+		stopping.ch = make(chan struct{})
+		stopping.Unlock()
+	}()
+	time.AfterFunc(100*time.Millisecond, Stop)
+	start := time.Now()
+	res = runTestStep(timedOut(time.Hour, 2*time.Hour))
+	if elapsed := time.Since(start); elapsed > 5*time.Second || len(res.Attempts) != 1 {
+		t.Errorf("runTestStep() made %d attempts in %s, want the retry given up at Stop", len(res.Attempts), elapsed)
+	}
+}
@@ -11,14 +11,14 @@ func updateParentTestStruct(task teststruct.Task, time time.Time) {
 
 	task.WriteMetadataChanges(time)
 
-	if task.TestStep.GetStatus() != teststruct.Success {
+	if task.TestStep.GetStatus().IsFailure() {
 
 		// If one tStep is KO => Parents are KO too
 		task.TestCase.SetStatus(teststruct.Failure)
 		task.TestSuite.SetStatus(teststruct.Failure)
 		return
 	} else {
-		// Success => Update TC (check if there is any teststep KO left in TC)
+		// Success or Warning => Update TC (check if there is any teststep KO left in TC)
 		// UpdateStatus TC
 		statusTC := task.TestCase.UpdateStatus()
 		// UpdateStatus TestSuites
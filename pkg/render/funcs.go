@@ -0,0 +1,142 @@
+package render
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/secret"
+)
+
+// funcs is the function library available in the templates.
+var funcs = template.FuncMap{
+	// Environment and files
+	"env":    os.Getenv,
+	"file":   readFile,
+	"secret": func(ref string) (string, error) { return secret.Resolve(secret.Prefix + ref) },
+
+	// Encoding
+	"b64enc": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec": b64dec,
+	"toJson": toJSON,
+
+	// Dates
+	"now":        time.Now,
+	"date":       date,
+	"unix":       func(t time.Time) int64 { return t.Unix() },
+	"dateModify": dateModify,
+
+	// Random
+	"randInt":      randInt,
+	"randAlphaNum": randAlphaNum,
+	"uuid":         uuid,
+
+	// Strings
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"replace": func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"split":   func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":    join,
+	"quote":   func(v interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(v)) },
+	"default": dflt,
+}
+
+func readFile(path string) (string, error) {
+	dat, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(dat), nil
+}
+
+func b64dec(s string) (string, error) {
+	dat, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(dat), nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	dat, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(dat), nil
+}
+
+// date formats a time with a Go layout: {{ now | date "2006-01-02" }}
+func date(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+// dateModify adds a duration to a time: {{ now | dateModify "-1h" }}
+func dateModify(d string, t time.Time) (time.Time, error) {
+	dur, err := time.ParseDuration(d)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(dur), nil
+}
+
+// randInt returns a random integer in [min, max)
+func randInt(min, max int) (int, error) {
+	if max <= min {
+		return 0, fmt.Errorf("randInt: max must be greater than min")
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max-min)))
+	if err != nil {
+		return 0, err
+	}
+	return min + int(n.Int64()), nil
+}
+
+const alphaNum = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func randAlphaNum(n int) (string, error) {
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphaNum))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphaNum[idx.Int64()]
+	}
+	return string(b), nil
+}
+
+// uuid returns a random UUID (v4)
+func uuid() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func join(sep string, v interface{}) string {
+	return strings.Join(List(v), sep)
+}
+
+// dflt returns the default value if the value is empty: {{ .port | default 443 }}
+func dflt(d, v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return d
+	case string:
+		if val == "" {
+			return d
+		}
+	}
+	return v
+}
@@ -0,0 +1,194 @@
+// Package render renders the Go templates ({{ .var }}) written in the test files
+// with the variables and the functions of Vigie.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/vincoll/vigie/pkg/secret"
+)
+
+// Vars are the variables available in a template, values can be
+// strings, numbers, booleans, lists or nested maps.
+type Vars map[string]interface{}
+
+// Merge returns the variables overridden by others, in order.
+func (v Vars) Merge(others ...Vars) Vars {
+
+	merged := make(Vars, len(v))
+	for k, val := range v {
+		merged[k] = val
+	}
+	for _, o := range others {
+		for k, val := range o {
+			merged[k] = val
+		}
+	}
+	return merged
+}
+
+// singleVarRE matches a template made of a single variable: {{ .port }}
+var singleVarRE = regexp.MustCompile(`^\{\{-?\s*\.([A-Za-z_][\w.]*)\s*-?\}\}$`)
+
+// IsTemplate tells if a string contains a template.
+func IsTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// String renders a template string, a string without template is returned as is.
+func String(s string, vars Vars) (string, error) {
+	rendered, _, err := renderString(s, vars)
+	return rendered, err
+}
+
+// renderString renders a template string and tells if a secret is part of it.
+func renderString(s string, vars Vars) (string, bool, error) {
+
+	if !IsTemplate(s) {
+		return s, false, nil
+	}
+
+	withSecret := false
+	tpl, err := template.New("").Option("missingkey=error").Funcs(funcs).Funcs(template.FuncMap{
+		"secret": func(ref string) (string, error) {
+			withSecret = true
+			return secret.Resolve(secret.Prefix + ref)
+		},
+	}).Parse(s)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid template %q: %s", s, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, map[string]interface{}(vars)); err != nil {
+		return "", false, fmt.Errorf("cannot render %q: %s", s, err)
+	}
+	return buf.String(), withSecret, nil
+}
+
+// Value renders all the strings of a decoded JSON or YAML value.
+// A string made of a single variable ({{ .port }}) is replaced by the value
+// of this variable with its type (number, boolean, list, map).
+func Value(v interface{}, vars Vars) (interface{}, error) {
+	return value(v, vars, nil, nil)
+}
+
+// value is Value, the paths of the strings holding a secret are added to secrets if not nil.
+func value(v interface{}, vars Vars, path []string, secrets *[][]string) (interface{}, error) {
+
+	switch val := v.(type) {
+	case string:
+		if secret.IsRef(val) {
+			addPath(secrets, path)
+			return secret.Resolve(val)
+		}
+		if m := singleVarRE.FindStringSubmatch(strings.TrimSpace(val)); m != nil {
+			if typed, found := lookup(vars, m[1]); found {
+				return typed, nil
+			}
+		}
+		rendered, withSecret, err := renderString(val, vars)
+		if withSecret {
+			addPath(secrets, path)
+		}
+		return rendered, err
+
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(val))
+		for k, sub := range val {
+			r, err := value(sub, vars, append(path[:len(path):len(path)], k), secrets)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", k, err)
+			}
+			rendered[k] = r
+		}
+		return rendered, nil
+
+	case []interface{}:
+		rendered := make([]interface{}, len(val))
+		for i, sub := range val {
+			r, err := value(sub, vars, append(path[:len(path):len(path)], strconv.Itoa(i)), secrets)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+
+	default:
+		return v, nil
+	}
+}
+
+func addPath(secrets *[][]string, path []string) {
+	if secrets != nil {
+		*secrets = append(*secrets, append([]string(nil), path...))
+	}
+}
+
+// Map renders all the strings of a map, see Value.
+func Map(m map[string]interface{}, vars Vars) (map[string]interface{}, error) {
+
+	if m == nil {
+		return nil, nil
+	}
+	rendered, err := Value(m, vars)
+	if err != nil {
+		return nil, err
+	}
+	return rendered.(map[string]interface{}), nil
+}
+
+// MapSecrets is Map, it also returns the paths of the values holding a secret
+// ([headers Authorization], [command 1]): they are redacted by field.
+func MapSecrets(m map[string]interface{}, vars Vars) (map[string]interface{}, [][]string, error) {
+
+	if m == nil {
+		return nil, nil, nil
+	}
+	var secrets [][]string
+	rendered, err := value(m, vars, nil, &secrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rendered.(map[string]interface{}), secrets, nil
+}
+
+// lookup returns the value of a variable, nested maps are walked with dots (.db.host).
+func lookup(vars Vars, path string) (interface{}, bool) {
+
+	var cur interface{} = map[string]interface{}(vars)
+	for _, key := range strings.Split(path, ".") {
+		m, isMap := cur.(map[string]interface{})
+		if !isMap {
+			return nil, false
+		}
+		if cur, isMap = m[key]; !isMap {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// List converts a variable into a list of strings:
+// each element of a list, or the value itself.
+func List(v interface{}) []string {
+
+	switch val := v.(type) {
+	case []string:
+		return val
+	case []interface{}:
+		list := make([]string, 0, len(val))
+		for _, elem := range val {
+			list = append(list, fmt.Sprintf("%v", elem))
+		}
+		return list
+	default:
+		return []string{fmt.Sprintf("%v", val)}
+	}
+}
@@ -0,0 +1,128 @@
+package render
+
+import (
+	"encoding/base64"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValue(t *testing.T) {
+
+	os.Setenv("VIGIE_RENDER_TEST", "prod")
+	defer os.Unsetenv("VIGIE_RENDER_TEST")
+
+	vars := Vars{
+		"host":  "vigie.dev",
+		"port":  float64(8443),
+		"tls":   true,
+		"hosts": []interface{}{"a.com", "b.com"},
+		"db":    map[string]interface{}{"host": "db.local", "port": float64(5432)},
+		"user":  "admin",
+	}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "no_template", value: "https://vigie.dev", want: "https://vigie.dev"},
+		{name: "string", value: "https://{{ .host }}:{{ .port }}/", want: "https://vigie.dev:8443/"},
+		{name: "typed_number", value: "{{ .port }}", want: float64(8443)},
+		{name: "typed_bool", value: "{{.tls}}", want: true},
+		{name: "typed_list", value: "{{ .hosts }}", want: []interface{}{"a.com", "b.com"}},
+		{name: "nested", value: "{{ .db.host }}:{{ .db.port }}", want: "db.local:5432"},
+		{name: "typed_nested", value: "{{ .db.port }}", want: float64(5432)},
+		{name: "env", value: `{{ env "VIGIE_RENDER_TEST" }}`, want: "prod"},
+		{name: "default", value: `{{ env "VIGIE_RENDER_UNSET" | default "dev" }}`, want: "dev"},
+		{name: "b64enc", value: `Basic {{ printf "%s:%s" .user "pwd" | b64enc }}`, want: "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:pwd"))},
+		{name: "join", value: `{{ join "," .hosts }}`, want: "a.com,b.com"},
+		{name: "upper", value: `{{ .host | upper }}`, want: "VIGIE.DEV"},
+		{
+			name:  "map",
+			value: map[string]interface{}{"url": "https://{{ .host }}", "headers": map[string]interface{}{"X-Env": "{{ env \"VIGIE_RENDER_TEST\" }}"}, "ports": []interface{}{"{{ .port }}", 80.0}},
+			want:  map[string]interface{}{"url": "https://vigie.dev", "headers": map[string]interface{}{"X-Env": "prod"}, "ports": []interface{}{8443.0, 80.0}},
+		},
+		{name: "missing_var", value: "{{ .missing }}", wantErr: true},
+		{name: "invalid", value: "{{ .host ", wantErr: true},
+		{name: "unknown_func", value: "{{ nope .host }}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Value(tt.value, vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Value() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Value() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuncs(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		tpl   string
+		match string
+	}{
+		{name: "date", tpl: `{{ now | date "2006-01-02" }}`, match: `^\d{4}-\d{2}-\d{2}$`},
+		{name: "dateModify", tpl: `{{ now | dateModify "-24h" | unix }}`, match: `^\d+$`},
+		{name: "randInt", tpl: `{{ randInt 10 20 }}`, match: `^1\d$`},
+		{name: "randAlphaNum", tpl: `{{ randAlphaNum 12 }}`, match: `^[a-zA-Z0-9]{12}$`},
+		{name: "uuid", tpl: `{{ uuid }}`, match: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{name: "toJson", tpl: `{{ toJson .hosts }}`, match: `^\["a.com","b.com"\]$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := String(tt.tpl, Vars{"hosts": []interface{}{"a.com", "b.com"}})
+			if err != nil {
+				t.Fatalf("String() error = %v", err)
+			}
+			if !regexp.MustCompile(tt.match).MatchString(got) {
+				t.Errorf("String() got = %q, want a match of %s", got, tt.match)
+			}
+		})
+	}
+
+	// now is rendered at each call
+	first, _ := String(`{{ now.UnixNano }}`, nil)
+	time.Sleep(time.Millisecond)
+	second, _ := String(`{{ now.UnixNano }}`, nil)
+	if first == second {
+		t.Errorf("now has been rendered once: %s", first)
+	}
+}
+
+func TestMapSecrets(t *testing.T) {
+
+	os.Setenv("VIGIE_RENDER_SECRET", "42")
+	defer os.Unsetenv("VIGIE_RENDER_SECRET")
+
+	m := map[string]interface{}{
+		"url":      "https://{{ .host }}",
+		"password": "secret://env/VIGIE_RENDER_SECRET",
+		"headers":  map[string]interface{}{"Authorization": `Basic {{ secret "env/VIGIE_RENDER_SECRET" | b64enc }}`},
+		"command":  []interface{}{"login", `{{ secret "env/VIGIE_RENDER_SECRET" }}`},
+	}
+
+	got, secrets, err := MapSecrets(m, Vars{"host": "vigie.dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["password"] != "42" || got["url"] != "https://vigie.dev" {
+		t.Errorf("MapSecrets() got = %v", got)
+	}
+
+	sort.Slice(secrets, func(i, j int) bool { return strings.Join(secrets[i], ".") < strings.Join(secrets[j], ".") })
+	want := [][]string{{"command", "1"}, {"headers", "Authorization"}, {"password"}}
+	if !reflect.DeepEqual(secrets, want) {
+		t.Errorf("MapSecrets() secrets = %v, want %v", secrets, want)
+	}
+}
@@ -0,0 +1,21 @@
+package scheduler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	queueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "vigie_scheduler_queue_depth",
+		Help: "Number of TestSteps waiting for a worker.",
+	})
+	running = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "vigie_scheduler_running",
+		Help: "Number of TestSteps running.",
+	})
+	overruns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "vigie_scheduler_overruns_total",
+		Help: "Ticks of a TestStep still queued or running, by overrun policy.",
+	}, []string{"policy"})
+)
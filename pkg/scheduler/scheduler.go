@@ -1,46 +1,340 @@
 package scheduler
 
 import (
+	"fmt"
+	"github.com/sirupsen/logrus"
 	"github.com/vincoll/vigie/pkg/process"
+	"github.com/vincoll/vigie/pkg/state"
 	"github.com/vincoll/vigie/pkg/teststruct"
 	"github.com/vincoll/vigie/pkg/tsdb"
+	"github.com/vincoll/vigie/pkg/utils"
 	"sync"
 	"time"
 )
 
+// Overrun policies: what to do with the tick of a TestStep still queued or running.
+const (
+	OverrunSkip     = "skip"     // the tick is dropped
+	OverrunCoalesce = "coalesce" // the ticks are merged into one run, after the current one
+	OverrunQueue    = "queue"    // each tick is run, one after the other
+)
+
+const (
+	defaultWorkers   = 200
+	defaultQueueSize = 1000
+	// on-demand runs waiting for a worker
+	defaultUrgentSize = 100
+)
+
+type ConfScheduler struct {
+	// Number of TestSteps running at once
+	Workers int `toml:"workers"`
+	// Number of TestSteps waiting for a worker, the tickers are blocked when the queue is full
+	QueueSize int `toml:"queuesize"`
+	// skip, coalesce or queue
+	Overrun string `toml:"overrun"`
+}
+
 type Scheduler struct {
 	mu        sync.RWMutex
 	workers   uint64
+	overrun   string
 	chProcess chan teststruct.Task
+	chDone    chan teststruct.Task // runs of the steps with a failure frequency, for the tickers
+	writer    *state.Manager       // applies the results, nil to apply them in the workers
+	queue     chan teststruct.Task
+	urgent    chan teststruct.Task              // on-demand runs, taken before the queue
+	limits    map[string]int                    // concurrency per probe type
+	active    map[string]int                    // runs holding a slot, per probe type
+	parked    map[string][]teststruct.Task      // runs waiting for a slot, per probe type
+	inflight  map[*teststruct.TestStep]*stepRun // TestSteps queued or running
 }
 
-func NewScheduler(chProcess chan teststruct.Task, maxworker uint64) *Scheduler {
+// stepRun is a TestStep queued or running, with its overrun ticks.
+type stepRun struct {
+	running bool
+	pending int                            // runs to do after the current one
+	waiters []chan *teststruct.VigieResult // on-demand runs waiting for the next run
+}
+
+func NewScheduler(chProcess, chDone chan teststruct.Task, writer *state.Manager, conf ConfScheduler) (*Scheduler, error) {
+
+	if conf.Workers < 0 || conf.QueueSize < 0 {
+		return nil, fmt.Errorf("workers and queuesize must be >= 0")
+	}
+	if conf.Workers == 0 {
+		conf.Workers = defaultWorkers
+	}
+	if conf.QueueSize == 0 {
+		conf.QueueSize = defaultQueueSize
+	}
+
+	switch conf.Overrun {
+	case "":
+		conf.Overrun = OverrunSkip
+	case OverrunSkip, OverrunCoalesce, OverrunQueue:
+	default:
+		return nil, fmt.Errorf("overrun %q is invalid, must be %s, %s or %s", conf.Overrun, OverrunSkip, OverrunCoalesce, OverrunQueue)
+	}
 
 	sched := Scheduler{
-		workers:   maxworker,
+		workers:   uint64(conf.Workers),
+		overrun:   conf.Overrun,
 		chProcess: chProcess,
+		chDone:    chDone,
+		writer:    writer,
+		queue:     make(chan teststruct.Task, conf.QueueSize),
+		urgent:    make(chan teststruct.Task, defaultUrgentSize),
+		limits:    map[string]int{},
+		active:    map[string]int{},
+		parked:    map[string][]teststruct.Task{},
+		inflight:  map[*teststruct.TestStep]*stepRun{},
 	}
 	sched.start()
 
-	return &sched
+	return &sched, nil
 
 }
 
 func (s *Scheduler) start() {
+
+	for i := uint64(0); i < s.workers; i++ {
+		go s.work()
+	}
+
 	go func() {
 		for {
 			// Continuous read (multiple senders from tickers)
 			task := <-s.chProcess
-			go func() {
-				testResult := process.ProcessTask(task)
-				// Insert Task ResultStatus to DB
-				tsdb.TsdbMgr.WriteOnTsdbs(task, testResult)
-			}()
+			if !s.admit(task) {
+				continue
+			}
+			// Blocks the tickers while the queue is full
+			s.queue <- task
+			queueDepth.Set(float64(len(s.queue)))
 		}
 	}()
 }
 
-//reSync Task in case of a
+// work runs the on-demand and queued TestSteps, then their overrun ticks.
+func (s *Scheduler) work() {
+
+	for {
+		var task teststruct.Task
+		select {
+		case task = <-s.urgent:
+		default:
+			select {
+			case task = <-s.urgent:
+			case task = <-s.queue:
+				queueDepth.Set(float64(len(s.queue)))
+			}
+		}
+
+		// A task without a slot is parked, the worker takes the next one
+		for ok := s.acquire(task); ok; task, ok = s.release(task) {
+			s.run(task)
+		}
+	}
+}
+
+// run processes the task, its slot is already acquired.
+func (s *Scheduler) run(task teststruct.Task) {
+
+	// The on-demand runs registered so far get the result of this run
+	var waiters []chan *teststruct.VigieResult
+	s.mu.Lock()
+	if sr, present := s.inflight[task.TestStep]; present {
+		sr.running = true
+		waiters, sr.waiters = sr.waiters, nil
+	}
+	s.mu.Unlock()
+
+	running.Inc()
+	defer running.Dec()
+
+	// The result is applied by the state manager, the tickers and the on-demand runs
+	// read the TestStep once it is
+	out := process.RunTask(task)
+	s.writer.Ingest(task, out)
+	testResult := &out.Result
+
+	// Insert Task ResultStatus to DB, a paused step has no result
+	if testResult.Status != teststruct.Paused {
+		tsdb.TsdbMgr.WriteOnTsdbs(task, testResult)
+	}
+
+	for _, w := range waiters {
+		w <- testResult
+	}
+
+	// The tickers move the step according to its new status, if they are busy
+	// the result is dropped: the next run tells it again.
+	if s.chDone != nil && task.TestStep.ProbeWrap.FailureFrequency > 0 {
+		select {
+		case s.chDone <- task:
+		default:
+		}
+	}
+}
+
+// admit tells if a tick must be queued, or if it is an overrun
+// handled according to the overrun policy.
+func (s *Scheduler) admit(task teststruct.Task) bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sr, present := s.inflight[task.TestStep]
+	if !present {
+		s.inflight[task.TestStep] = &stepRun{}
+		return true
+	}
+
+	overruns.WithLabelValues(s.overrun).Inc()
+	utils.Log.WithFields(logrus.Fields{
+		"package": "scheduler", "teststep": task.TestStep.ID, "overrun": s.overrun,
+	}).Debug("TestStep is still queued or running at its next tick")
+
+	switch s.overrun {
+	case OverrunCoalesce:
+		// A queued run is not started yet: it is the coalesced run
+		if sr.running {
+			sr.pending = 1
+		}
+	case OverrunQueue:
+		sr.pending++
+	}
+	return false
+}
+
+// RunNow runs the TestStep ahead of the ticks, its result is sent on the returned channel.
+// A TestStep already queued gives the result of that run, a TestStep running is run again after.
+func (s *Scheduler) RunNow(task teststruct.Task) <-chan *teststruct.VigieResult {
+
+	done := make(chan *teststruct.VigieResult, 1)
+	if s.request(task, done) {
+		s.urgent <- task
+	}
+	return done
+}
+
+// request registers an on-demand run and tells if the TestStep must be queued.
+func (s *Scheduler) request(task teststruct.Task, done chan *teststruct.VigieResult) bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sr, present := s.inflight[task.TestStep]
+	if !present {
+		s.inflight[task.TestStep] = &stepRun{waiters: []chan *teststruct.VigieResult{done}}
+		return true
+	}
+	// A run started before the request is not fresh
+	if sr.running && sr.pending == 0 {
+		sr.pending = 1
+	}
+	sr.waiters = append(sr.waiters, done)
+	return false
+}
+
+// next ends a run of the TestStep then tells if it must be run again, s.mu must be held.
+func (s *Scheduler) next(task teststruct.Task) bool {
+
+	sr, present := s.inflight[task.TestStep]
+	if !present {
+		return false
+	}
+	if sr.pending > 0 {
+		sr.pending--
+		return true
+	}
+	delete(s.inflight, task.TestStep)
+	return false
+}
+
+// acquire takes a slot of the probe type of the task, before it is run.
+// Without a free slot the task is parked until a run of its probe type ends.
+func (s *Scheduler) acquire(task teststruct.Task) bool {
+
+	probeType := task.TestStep.ProbeWrap.Probe.GetName()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit := s.limits[probeType]; limit > 0 && s.active[probeType] >= limit {
+		s.parked[probeType] = append(s.parked[probeType], task)
+		return false
+	}
+	s.active[probeType]++
+	return true
+}
+
+// release ends a run of the task and hands its slot over to the next task to run:
+// the first one parked on its probe type, its own overrun tick being parked after them.
+func (s *Scheduler) release(task teststruct.Task) (teststruct.Task, bool) {
+
+	probeType := task.TestStep.ProbeWrap.Probe.GetName()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active[probeType]--
+	if s.next(task) {
+		// Queued again, not running
+		s.inflight[task.TestStep].running = false
+		s.parked[probeType] = append(s.parked[probeType], task)
+	}
+
+	parked := s.parked[probeType]
+	if len(parked) == 0 || (s.limits[probeType] > 0 && s.active[probeType] >= s.limits[probeType]) {
+		if s.active[probeType] == 0 {
+			delete(s.active, probeType)
+		}
+		return teststruct.Task{}, false
+	}
+
+	next := parked[0]
+	if len(parked) == 1 {
+		delete(s.parked, probeType)
+	} else {
+		s.parked[probeType] = parked[1:]
+	}
+	s.active[probeType]++
+	return next, true
+}
+
+// SetLimits sets the concurrency of each probe type from the TestSteps:
+// the lowest concurrency set on a probe type is its limit.
+func (s *Scheduler) SetLimits(tss map[uint64]*teststruct.TestSuite) {
+
+	lowest := map[string]int{}
+	for _, ts := range tss {
+		for _, tc := range ts.TestCases {
+			for _, tStep := range tc.TestSteps {
+				tStep.Mutex.RLock()
+				limit, probeType := tStep.ProbeWrap.Concurrency, tStep.ProbeWrap.Probe.GetName()
+				tStep.Mutex.RUnlock()
+				if limit > 0 && (lowest[probeType] == 0 || limit < lowest[probeType]) {
+					lowest[probeType] = limit
+				}
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The runs above a lowered limit end, the parked ones wait for them
+	s.limits = lowest
+}
+
+// QueueDepth returns the number of TestSteps waiting for a worker.
+func (s *Scheduler) QueueDepth() int {
+	return len(s.queue)
+}
+
+// reSync Task in case of a
 func reSyncTask(task teststruct.Task) {
 
 	nextCheck := task.TestStep.LastAttempt.Add(task.TestStep.ProbeWrap.Frequency)
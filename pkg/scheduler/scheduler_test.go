@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/vincoll/vigie/pkg/probe"
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+func TestScheduler_overrun(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	tests := []struct {
+		name    string
+		overrun string
+		started bool // the first run is started when the other ticks come
+		ticks   int  // ticks after the first one
+		want    int  // runs after the first one
+	}{
+		{name: "skip", overrun: OverrunSkip, started: true, ticks: 3, want: 0},
+		{name: "coalesce running", overrun: OverrunCoalesce, started: true, ticks: 3, want: 1},
+		{name: "coalesce queued", overrun: OverrunCoalesce, started: false, ticks: 3, want: 0},
+		{name: "queue", overrun: OverrunQueue, started: true, ticks: 3, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Scheduler{overrun: tt.overrun, inflight: map[*teststruct.TestStep]*stepRun{}}
+			task := teststruct.Task{TestStep: &teststruct.TestStep{ID: 1}}
+
+			if !s.admit(task) {
+				t.Fatal("admit() expected the first tick to be queued")
+			}
+			s.inflight[task.TestStep].running = tt.started
+
+			for i := 0; i < tt.ticks; i++ {
+				if s.admit(task) {
+					t.Fatal("admit() expected an overrun tick not to be queued")
+				}
+			}
+
+			runs := 0
+			for s.next(task) {
+				runs++
+			}
+			if runs != tt.want {
+				t.Errorf("runs after the first one = %d, want %d", runs, tt.want)
+			}
+			if _, present := s.inflight[task.TestStep]; present {
+				t.Error("next() expected the TestStep to be done")
+			}
+		})
+	}
+}
+
+func TestNewScheduler_invalid(t *testing.T) {
+
+	tests := []struct {
+		name string
+		conf ConfScheduler
+	}{
+		{name: "overrun", conf: ConfScheduler{Overrun: "drop"}},
+		{name: "workers", conf: ConfScheduler{Workers: -1}},
+		{name: "queuesize", conf: ConfScheduler{QueueSize: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewScheduler(make(chan teststruct.Task), nil, nil, tt.conf); err == nil {
+				t.Error("NewScheduler() expected an error")
+			}
+		})
+	}
+}
+
+func TestScheduler_request(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		inflight  bool // the step is queued or running
+		running   bool
+		wantQueue bool
+		want      int // runs after the current one
+	}{
+		{name: "idle", wantQueue: true},
+		{name: "queued", inflight: true, want: 0},
+		{name: "running", inflight: true, running: true, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Scheduler{overrun: OverrunSkip, inflight: map[*teststruct.TestStep]*stepRun{}}
+			task := teststruct.Task{TestStep: &teststruct.TestStep{ID: 1}}
+			if tt.inflight {
+				s.inflight[task.TestStep] = &stepRun{running: tt.running}
+			}
+
+			done := make(chan *teststruct.VigieResult, 1)
+			if got := s.request(task, done); got != tt.wantQueue {
+				t.Errorf("request() = %v, want %v", got, tt.wantQueue)
+			}
+			sr := s.inflight[task.TestStep]
+			if sr.pending != tt.want || len(sr.waiters) != 1 {
+				t.Errorf("request() pending = %d with %d waiters, want %d with 1", sr.pending, len(sr.waiters), tt.want)
+			}
+		})
+	}
+}
+
+// typedProbe is a probe of a given type.
+type typedProbe struct {
+	probe.Probe
+	name string
+}
+
+func (p typedProbe) GetName() string { return p.name }
+
+func TestScheduler_slots(t *testing.T) {
+
+	s := Scheduler{
+		overrun:  OverrunSkip,
+		inflight: map[*teststruct.TestStep]*stepRun{},
+		limits:   map[string]int{"icmp": 1},
+		active:   map[string]int{},
+		parked:   map[string][]teststruct.Task{},
+	}
+	newTask := func(id uint64, probeType string) teststruct.Task {
+		tStep := &teststruct.TestStep{ID: id}
+		tStep.ProbeWrap.Probe = typedProbe{name: probeType}
+		task := teststruct.Task{TestStep: tStep}
+		s.admit(task)
+		return task
+	}
+	icmp1, icmp2, http1 := newTask(1, "icmp"), newTask(2, "icmp"), newTask(3, "http")
+
+	if !s.acquire(icmp1) {
+		t.Fatal("acquire() expected a free icmp slot")
+	}
+	// The limited probe type does not hold the worker: the task waits without one
+	if s.acquire(icmp2) {
+		t.Fatal("acquire() expected the icmp task to be parked")
+	}
+	if !s.acquire(http1) {
+		t.Fatal("acquire() expected the http task to run while the icmp ones wait")
+	}
+
+	if next, ok := s.release(icmp1); !ok || next.TestStep != icmp2.TestStep {
+		t.Fatalf("release() = %v, %v, want the parked icmp task", next.TestStep, ok)
+	}
+	if s.active["icmp"] != 1 {
+		t.Errorf("active icmp runs = %d, want 1", s.active["icmp"])
+	}
+	if _, ok := s.release(icmp2); ok {
+		t.Error("release() expected no parked task left")
+	}
+	if _, ok := s.release(http1); ok {
+		t.Error("release() expected no parked http task")
+	}
+	if len(s.active) != 0 || len(s.parked) != 0 || len(s.inflight) != 0 {
+		t.Errorf("expected no run left, active %v parked %v inflight %d", s.active, s.parked, len(s.inflight))
+	}
+}
@@ -0,0 +1,35 @@
+package secret
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func init() {
+	Register("file", ProviderFunc(resolveFile))
+	Register("env", ProviderFunc(resolveEnv))
+	Register("vault", ProviderFunc(resolveVault))
+}
+
+// resolveFile reads a secret from a file: secret://file//etc/vigie/smtp_password
+// The trailing newline is removed.
+func resolveFile(path string) (string, error) {
+
+	dat, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read the secret file %s: %s", path, err)
+	}
+	return strings.TrimRight(string(dat), "\r\n"), nil
+}
+
+// resolveEnv reads a secret from an environment variable: secret://env/SMTP_PASSWORD
+func resolveEnv(name string) (string, error) {
+
+	value, present := os.LookupEnv(name)
+	if !present {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
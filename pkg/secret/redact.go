@@ -0,0 +1,146 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Mask replaces the values of the resolved secrets.
+const Mask = "******"
+
+// known are the values of the resolved secrets, redacted from the outputs.
+var known = struct {
+	sync.RWMutex
+	values map[string]struct{}
+}{values: make(map[string]struct{})}
+
+func remember(value string) {
+	if value == "" {
+		return
+	}
+	known.Lock()
+	known.values[value] = struct{}{}
+	known.Unlock()
+}
+
+// Redact replaces the values of the resolved secrets in a string,
+// in their raw and JSON escaped forms.
+func Redact(s string) string {
+
+	known.RLock()
+	defer known.RUnlock()
+
+	for value := range known.values {
+		s = strings.Replace(s, value, Mask, -1)
+		if escaped, err := json.Marshal(value); err == nil {
+			esc := string(escaped[1 : len(escaped)-1])
+			if esc != value {
+				s = strings.Replace(s, esc, Mask, -1)
+			}
+		}
+	}
+	return s
+}
+
+// RedactBytes is Redact for a JSON or text payload: only the strings
+// of a JSON payload are redacted, its keys and numbers are kept.
+func RedactBytes(b []byte) []byte {
+
+	known.RLock()
+	empty := len(known.values) == 0
+	known.RUnlock()
+	if empty {
+		return b
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return []byte(Redact(string(b)))
+	}
+	redacted, err := json.Marshal(RedactValue(v))
+	if err != nil {
+		return []byte(Redact(string(b)))
+	}
+	if bytes.HasSuffix(b, []byte("\n")) {
+		redacted = append(redacted, '\n')
+	}
+	return redacted
+}
+
+// RedactValue returns a copy of a decoded value (maps, lists) with the secrets redacted.
+func RedactValue(v interface{}) interface{} {
+
+	switch val := v.(type) {
+	case string:
+		return Redact(val)
+
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(val))
+		for k, sub := range val {
+			redacted[k] = RedactValue(sub)
+		}
+		return redacted
+
+	case []interface{}:
+		redacted := make([]interface{}, len(val))
+		for i, sub := range val {
+			redacted[i] = RedactValue(sub)
+		}
+		return redacted
+
+	default:
+		return v
+	}
+}
+
+// MaskFields replaces the values at the paths ([headers Authorization], [command 1])
+// of a decoded value by the Mask, whatever their length or encoding.
+// Map keys are matched regardless of case and underscores, as the probes decode them
+// (basicauthpassword is basic_auth_password), v is modified and returned.
+func MaskFields(v interface{}, paths [][]string) interface{} {
+
+	for _, path := range paths {
+		maskField(v, path)
+	}
+	return v
+}
+
+func maskField(v interface{}, path []string) {
+
+	if len(path) == 0 {
+		return
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, sub := range val {
+			if !sameKey(k, path[0]) {
+				continue
+			}
+			if len(path) == 1 {
+				val[k] = Mask
+			} else {
+				maskField(sub, path[1:])
+			}
+		}
+
+	case []interface{}:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= len(val) {
+			return
+		}
+		if len(path) == 1 {
+			val[i] = Mask
+		} else {
+			maskField(val[i], path[1:])
+		}
+	}
+}
+
+func sameKey(a, b string) bool {
+	return strings.EqualFold(strings.Replace(a, "_", "", -1), strings.Replace(b, "_", "", -1))
+}
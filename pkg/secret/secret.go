@@ -0,0 +1,134 @@
+// Package secret resolves the secrets referenced in the test files and in vigie.toml
+// (secret://<provider>/<path>) and redacts their values from the outputs of Vigie.
+package secret
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Prefix of a secret reference: secret://env/SMTP_PASSWORD
+const Prefix = "secret://"
+
+// Provider resolves the path of a secret reference into its value.
+type Provider interface {
+	Resolve(path string) (string, error)
+}
+
+// ProviderFunc is a function used as a Provider.
+type ProviderFunc func(path string) (string, error)
+
+func (f ProviderFunc) Resolve(path string) (string, error) {
+	return f(path)
+}
+
+var registry = struct {
+	sync.RWMutex
+	providers map[string]Provider
+}{providers: make(map[string]Provider)}
+
+// Register makes a secrets provider available as secret://<name>/...
+// It panics if the name is empty, already registered or if the provider is nil.
+func Register(name string, p Provider) {
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if name == "" {
+		panic("secret: Register with an empty name")
+	}
+	if p == nil {
+		panic(fmt.Sprintf("secret: Register %q with a nil provider", name))
+	}
+	if _, dup := registry.providers[name]; dup {
+		panic(fmt.Sprintf("secret: Register called twice for provider %q", name))
+	}
+	registry.providers[name] = p
+}
+
+// Registered returns the names of the registered providers.
+func Registered() []string {
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.providers))
+	for name := range registry.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsRef tells if a string is a secret reference.
+func IsRef(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// Resolve returns the value of a secret reference, the value is then redacted by Redact.
+// The reference itself is never part of the error.
+func Resolve(ref string) (string, error) {
+
+	if !IsRef(ref) {
+		return "", fmt.Errorf("a secret reference must start with %s", Prefix)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(ref, Prefix), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("a secret reference must be %s<provider>/<path>", Prefix)
+	}
+
+	registry.RLock()
+	p, found := registry.providers[parts[0]]
+	registry.RUnlock()
+	if !found {
+		return "", fmt.Errorf("secrets provider %q does not exist (available: %v)", parts[0], Registered())
+	}
+
+	value, err := p.Resolve(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("secrets provider %q: %s", parts[0], err)
+	}
+
+	remember(value)
+	return value, nil
+}
+
+// ResolveValue resolves the secret references of a decoded JSON or YAML value (maps, lists).
+func ResolveValue(v interface{}) (interface{}, error) {
+
+	switch val := v.(type) {
+	case string:
+		if !IsRef(val) {
+			return val, nil
+		}
+		return Resolve(val)
+
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(val))
+		for k, sub := range val {
+			r, err := ResolveValue(sub)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", k, err)
+			}
+			resolved[k] = r
+		}
+		return resolved, nil
+
+	case []interface{}:
+		resolved := make([]interface{}, len(val))
+		for i, sub := range val {
+			r, err := ResolveValue(sub)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+
+	default:
+		return v, nil
+	}
+}
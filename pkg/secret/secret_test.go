@@ -0,0 +1,199 @@
+package secret
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+
+	os.Setenv("VIGIE_TEST_SECRET", "s3cr3t")
+	defer os.Unsetenv("VIGIE_TEST_SECRET")
+
+	dir, err := ioutil.TempDir("", "vigie-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secretFile := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(secretFile, []byte("filepass\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{name: "env", ref: "secret://env/VIGIE_TEST_SECRET", want: "s3cr3t"},
+		{name: "env unset", ref: "secret://env/VIGIE_TEST_UNSET", wantErr: true},
+		{name: "file", ref: "secret://file/" + secretFile, want: "filepass"},
+		{name: "file missing", ref: "secret://file/" + filepath.Join(dir, "none"), wantErr: true},
+		{name: "unknown provider", ref: "secret://nope/x", wantErr: true},
+		{name: "no path", ref: "secret://env/", wantErr: true},
+		{name: "not a reference", ref: "plain", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+
+	os.Setenv("VIGIE_TEST_REDACT", `pa"ss`)
+	defer os.Unsetenv("VIGIE_TEST_REDACT")
+
+	if _, err := Resolve("secret://env/VIGIE_TEST_REDACT"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "raw", in: `password: pa"ss`, want: "password: " + Mask},
+		{name: "json escaped", in: `{"password":"pa\"ss"}`, want: `{"password":"` + Mask + `"}`},
+		{name: "nothing to redact", in: "hello", want: "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Errorf("Redact() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactBytes(t *testing.T) {
+
+	// Short, "200" is also an HTTP code
+	os.Setenv("VIGIE_TEST_PIN", "200")
+	defer os.Unsetenv("VIGIE_TEST_PIN")
+
+	if _, err := Resolve("secret://env/VIGIE_TEST_PIN"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "json strings only", in: `{"pin":"200","httpcode":200}` + "\n", want: `{"httpcode":200,"pin":"` + Mask + `"}` + "\n"},
+		{name: "json list", in: `["x200",1.200]`, want: `["x` + Mask + `",1.200]`},
+		{name: "text", in: "pin 200", want: "pin " + Mask},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(RedactBytes([]byte(tt.in))); got != tt.want {
+				t.Errorf("RedactBytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveStruct(t *testing.T) {
+
+	os.Setenv("VIGIE_TEST_TOKEN", "tok")
+	defer os.Unsetenv("VIGIE_TEST_TOKEN")
+
+	type sub struct {
+		Token string
+	}
+	type conf struct {
+		Password string
+		Plain    string
+		Sub      sub
+		Ptr      *sub
+		List     []string
+		Headers  map[string]string
+	}
+
+	c := conf{
+		Password: "secret://env/VIGIE_TEST_TOKEN",
+		Plain:    "plain",
+		Sub:      sub{Token: "secret://env/VIGIE_TEST_TOKEN"},
+		Ptr:      &sub{Token: "secret://env/VIGIE_TEST_TOKEN"},
+		List:     []string{"secret://env/VIGIE_TEST_TOKEN"},
+		Headers:  map[string]string{"Authorization": "secret://env/VIGIE_TEST_TOKEN"},
+	}
+
+	if err := ResolveStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Password != "tok" || c.Plain != "plain" || c.Sub.Token != "tok" || c.Ptr.Token != "tok" ||
+		c.List[0] != "tok" || c.Headers["Authorization"] != "tok" {
+		t.Errorf("ResolveStruct() = %+v", c)
+	}
+
+	bad := conf{Sub: sub{Token: "secret://env/VIGIE_TEST_UNSET"}}
+	if err := ResolveStruct(&bad); err == nil {
+		t.Error("ResolveStruct() expected an error for an unset variable")
+	}
+}
+
+func TestResolveVault(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/vigie":
+			_, _ = w.Write([]byte(`{"data":{"data":{"smtp":"kv2pass"}}}`))
+		case "/v1/kv/vigie":
+			_, _ = w.Write([]byte(`{"data":{"smtp":"kv1pass"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	os.Setenv("VAULT_ADDR", srv.URL)
+	os.Setenv("VAULT_TOKEN", "root")
+	defer os.Unsetenv("VAULT_ADDR")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{name: "kv2", ref: "secret://vault/secret/data/vigie#smtp", want: "kv2pass"},
+		{name: "kv1", ref: "secret://vault/kv/vigie#smtp", want: "kv1pass"},
+		{name: "missing key", ref: "secret://vault/kv/vigie#nope", wantErr: true},
+		{name: "not found", ref: "secret://vault/kv/none#smtp", wantErr: true},
+		{name: "no key", ref: "secret://vault/kv/vigie", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package secret
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ResolveStruct replaces the secret references in the string fields of a configuration,
+// nested structs, pointers, slices and maps included.
+func ResolveStruct(ptr interface{}) error {
+
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("ResolveStruct needs a non nil pointer")
+	}
+	return resolveReflect(v.Elem(), "")
+}
+
+func resolveReflect(v reflect.Value, path string) error {
+
+	switch v.Kind() {
+	case reflect.String:
+		if !IsRef(v.String()) || !v.CanSet() {
+			return nil
+		}
+		value, err := Resolve(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		v.SetString(value)
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Interface {
+			// Values in an interface are not settable: resolve a copy
+			resolved, err := ResolveValue(v.Interface())
+			if err != nil {
+				return fmt.Errorf("%s: %s", path, err)
+			}
+			if v.CanSet() && resolved != nil {
+				v.Set(reflect.ValueOf(resolved))
+			}
+			return nil
+		}
+		return resolveReflect(v.Elem(), path)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				// unexported
+				continue
+			}
+			if err := resolveReflect(v.Field(i), joinPath(path, t.Field(i).Name)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveReflect(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String && v.Type().Elem().Kind() != reflect.Interface {
+			return nil
+		}
+		for _, k := range v.MapKeys() {
+			elem := v.MapIndex(k)
+			resolved, err := ResolveValue(elem.Interface())
+			if err != nil {
+				return fmt.Errorf("%s: %s", joinPath(path, fmt.Sprint(k.Interface())), err)
+			}
+			if resolved == nil {
+				continue
+			}
+			v.SetMapIndex(k, reflect.ValueOf(resolved).Convert(v.Type().Elem()))
+		}
+	}
+
+	return nil
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// LogHook redacts the resolved secrets from the log entries.
+type LogHook struct{}
+
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (LogHook) Fire(entry *logrus.Entry) error {
+
+	entry.Message = Redact(entry.Message)
+	for k, v := range entry.Data {
+		switch val := v.(type) {
+		case string:
+			entry.Data[k] = Redact(val)
+		case error:
+			entry.Data[k] = Redact(val.Error())
+		}
+	}
+	return nil
+}
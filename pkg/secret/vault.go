@@ -0,0 +1,67 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var vaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// resolveVault reads a key of a Vault secret: secret://vault/secret/data/vigie#smtp_password
+// The server and the token are VAULT_ADDR and VAULT_TOKEN, KV v1 and v2 engines are supported.
+func resolveVault(ref string) (string, error) {
+
+	parts := strings.SplitN(ref, "#", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("a vault secret must be vault/<path>#<key>")
+	}
+	path, key := parts[0], parts[1]
+
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+
+	resp, err := vaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s for %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid vault response: %s", err)
+	}
+
+	// KV v2 nests the secret in data.data
+	data := body.Data
+	if nested, ok := body.Data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	value, found := data[key]
+	if !found {
+		return "", fmt.Errorf("key %q does not exist in %s", key, path)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %q of %s is not a string", key, path)
+	}
+	return str, nil
+}
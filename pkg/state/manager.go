@@ -0,0 +1,210 @@
+package state
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/process"
+	"github.com/vincoll/vigie/pkg/teststruct"
+)
+
+const (
+	eventsSize = 1000
+	// events applied at most before publishing a snapshot
+	maxBatch = 100
+)
+
+// Manager is the single writer of the state of the TestSuites: the results of the runs
+// are sent to it as events, then applied one after the other. The readers (API, alerting)
+// get a Snapshot, never the TestSuites being updated.
+type Manager struct {
+	events   chan event
+	updates  chan update
+	snapshot atomic.Value // *Snapshot
+
+	// Owned by the writer
+	suites map[uint64]*teststruct.TestSuite
+}
+
+// event is the outcome of a run, applied to its TestStep.
+type event struct {
+	task    teststruct.Task
+	out     process.Outcome
+	applied chan struct{}
+}
+
+// update is a change of the whole state: a load of new TestSuites and/or a change
+// made by fn (pauses, restored states...).
+type update struct {
+	load    bool
+	suites  map[uint64]*teststruct.TestSuite
+	fn      func()
+	applied chan struct{}
+}
+
+// NewManager starts the writer, with no TestSuite loaded.
+func NewManager() *Manager {
+
+	m := &Manager{
+		events:  make(chan event, eventsSize),
+		updates: make(chan update),
+		suites:  map[uint64]*teststruct.TestSuite{},
+	}
+	m.snapshot.Store(&Snapshot{Taken: time.Now(), Suites: map[uint64]*Suite{}})
+
+	go m.run()
+	return m
+}
+
+// Snapshot returns the last state published, it is never modified.
+func (m *Manager) Snapshot() *Snapshot {
+	return m.snapshot.Load().(*Snapshot)
+}
+
+// Ingest applies the outcome of a run to its TestStep, then returns once it is
+// in the snapshot. Without a manager, the outcome is applied by the caller.
+func (m *Manager) Ingest(task teststruct.Task, out process.Outcome) {
+
+	if m == nil {
+		process.Apply(task, out)
+		return
+	}
+
+	ev := event{task: task, out: out, applied: make(chan struct{})}
+	m.events <- ev
+	<-ev.applied
+}
+
+// Load replaces the TestSuites by the ones imported.
+func (m *Manager) Load(suites map[uint64]*teststruct.TestSuite) {
+	m.send(update{load: true, suites: suites})
+}
+
+// Update runs fn in the writer, the changes made on the TestSuites
+// are in the snapshot once it returns.
+func (m *Manager) Update(fn func()) {
+	m.send(update{fn: fn})
+}
+
+func (m *Manager) send(up update) {
+
+	up.applied = make(chan struct{})
+	m.updates <- up
+	<-up.applied
+}
+
+// run applies the events and the updates, one at a time.
+func (m *Manager) run() {
+
+	for {
+		select {
+		case ev := <-m.events:
+			batch := m.drain(ev)
+			tasks := make([]teststruct.Task, 0, len(batch))
+			for _, ev := range batch {
+				process.Apply(ev.task, ev.out)
+				tasks = append(tasks, ev.task)
+			}
+			m.snapshot.Store(m.refresh(m.Snapshot(), tasks))
+			for _, ev := range batch {
+				close(ev.applied)
+			}
+
+		case up := <-m.updates:
+			if up.load {
+				m.suites = up.suites
+			}
+			if up.fn != nil {
+				up.fn()
+			}
+			m.snapshot.Store(build(m.suites, m.Snapshot().Version+1))
+			close(up.applied)
+		}
+	}
+}
+
+// drain returns the event received with the ones waiting, up to maxBatch.
+func (m *Manager) drain(first event) []event {
+
+	batch := []event{first}
+	for len(batch) < maxBatch {
+		select {
+		case ev := <-m.events:
+			batch = append(batch, ev)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// refresh returns a copy of the snapshot with the views of the tasks updated,
+// the suites and cases untouched are shared with the previous one.
+func (m *Manager) refresh(prev *Snapshot, tasks []teststruct.Task) *Snapshot {
+
+	next := &Snapshot{
+		Version: prev.Version + 1,
+		Taken:   time.Now(),
+		Suites:  make(map[uint64]*Suite, len(prev.Suites)),
+	}
+	for id, s := range prev.Suites {
+		next.Suites[id] = s
+	}
+
+	// Copied in this refresh, they can be modified
+	suites := map[*Suite]bool{}
+	cases := map[*Case]bool{}
+
+	for _, task := range tasks {
+
+		// A TestStep removed by a reload is not in the snapshot anymore
+		ts, found := m.suites[task.TestSuite.ID]
+		if !found {
+			continue
+		}
+		tc, found := ts.TestCases[task.TestCase.ID]
+		if !found || tc.TestSteps[task.TestStep.ID] != task.TestStep {
+			continue
+		}
+
+		s := next.Suites[ts.ID]
+		if !suites[s] {
+			s = s.copy()
+			next.Suites[ts.ID] = s
+			suites[s] = true
+		}
+		s.TSHeader = ts.ToHeader()
+
+		c := s.Cases[tc.ID]
+		if !cases[c] {
+			c = c.copy()
+			s.Cases[tc.ID] = c
+			cases[c] = true
+		}
+		c.TCHeader = tc.ToHeader()
+		c.Steps[task.TestStep.ID] = newStep(task.TestStep)
+	}
+	return next
+}
+
+// build returns the snapshot of all the TestSuites.
+func build(suites map[uint64]*teststruct.TestSuite, version uint64) *Snapshot {
+
+	snap := &Snapshot{
+		Version: version,
+		Taken:   time.Now(),
+		Suites:  make(map[uint64]*Suite, len(suites)),
+	}
+	for id, ts := range suites {
+		s := &Suite{TSHeader: ts.ToHeader(), Cases: make(map[uint64]*Case, len(ts.TestCases))}
+		for tcID, tc := range ts.TestCases {
+			c := &Case{TCHeader: tc.ToHeader(), Steps: make(map[uint64]*Step, len(tc.TestSteps))}
+			for stepID, tStep := range tc.TestSteps {
+				c.Steps[stepID] = newStep(tStep)
+			}
+			s.Cases[tcID] = c
+		}
+		snap.Suites[id] = s
+	}
+	return snap
+}
@@ -0,0 +1,224 @@
+package state
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/process"
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+// newSuites returns the TestSuites and the tasks of their TestSteps.
+func newSuites(nTS, nTC, nStep int) (map[uint64]*teststruct.TestSuite, []teststruct.Task) {
+
+	suites := map[uint64]*teststruct.TestSuite{}
+	var tasks []teststruct.Task
+	for i := 1; i <= nTS; i++ {
+		ts := &teststruct.TestSuite{ID: uint64(i), Name: fmt.Sprintf("ts%d", i), TestCases: map[uint64]*teststruct.TestCase{}}
+		for j := 1; j <= nTC; j++ {
+			tc := &teststruct.TestCase{ID: uint64(j), Name: fmt.Sprintf("tc%d", j), TestSteps: map[uint64]*teststruct.TestStep{}}
+			for k := 1; k <= nStep; k++ {
+				tStep := &teststruct.TestStep{ID: uint64(k), Name: fmt.Sprintf("step%d", k)}
+				tc.TestSteps[tStep.ID] = tStep
+				tasks = append(tasks, teststruct.Task{TestSuite: ts, TestCase: tc, TestStep: tStep})
+			}
+			ts.TestCases[tc.ID] = tc
+		}
+		suites[ts.ID] = ts
+	}
+	return suites, tasks
+}
+
+func outcome(status teststruct.StepStatus) process.Outcome {
+	return process.Outcome{Result: teststruct.VigieResult{LastAttempt: time.Now(), Status: status}}
+}
+
+// checkConsistency checks that the status of each TestCase and TestSuite
+// is the one of its TestSteps in the snapshot.
+func checkConsistency(snap *Snapshot) error {
+
+	for _, s := range snap.Suites {
+		tsFailing, tsRun := false, false
+		for _, c := range s.Cases {
+			tcFailing, tcRun := false, false
+			for _, st := range c.Steps {
+				switch st.Status() {
+				case teststruct.AssertFailure.String():
+					tcFailing = true
+				case teststruct.Success.String():
+					tcRun = true
+				}
+			}
+
+			want := teststruct.NotDefined.String()
+			if tcFailing {
+				want = teststruct.Failure.String()
+			} else if tcRun {
+				want = teststruct.Success.String()
+			}
+			if c.Status != want {
+				return fmt.Errorf("snapshot %d: testcase %s/%s is %q, its steps tell %q", snap.Version, s.Name, c.Name, c.Status, want)
+			}
+			tsFailing = tsFailing || tcFailing
+			tsRun = tsRun || tcRun
+		}
+
+		want := teststruct.NotDefined.String()
+		if tsFailing {
+			want = teststruct.Failure.String()
+		} else if tsRun {
+			want = teststruct.Success.String()
+		}
+		if s.Status != want {
+			return fmt.Errorf("snapshot %d: testsuite %s is %q, its testcases tell %q", snap.Version, s.Name, s.Status, want)
+		}
+	}
+	return nil
+}
+
+func TestManager_Ingest(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	suites, tasks := newSuites(3, 3, 4)
+	m := NewManager()
+	m.Load(suites)
+
+	const (
+		writers = 8
+		runs    = 50
+	)
+
+	// The readers check each snapshot while the results are ingested
+	done := make(chan struct{})
+	errs := make(chan error, 1)
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if err := checkConsistency(m.Snapshot()); err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	// Each writer runs its own TestSteps, as the scheduler does: the last run is a failure
+	// for the odd TestSteps, a success for the even ones
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for run := 0; run < runs; run++ {
+				for i := w; i < len(tasks); i += writers {
+					status := teststruct.Success
+					if (run+i)%2 == 0 {
+						status = teststruct.AssertFailure
+					}
+					m.Ingest(tasks[i], outcome(status))
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	default:
+	}
+
+	snap := m.Snapshot()
+	if err := checkConsistency(snap); err != nil {
+		t.Fatal(err)
+	}
+	for i, task := range tasks {
+		want := teststruct.Success.String()
+		if (runs-1+i)%2 == 0 {
+			want = teststruct.AssertFailure.String()
+		}
+		got := snap.Suites[task.TestSuite.ID].Cases[task.TestCase.ID].Steps[task.TestStep.ID].Status()
+		if got != want {
+			t.Errorf("step %v is %q in the snapshot, want %q", task.UIDs(), got, want)
+		}
+	}
+}
+
+func TestManager_Snapshot(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	suites, tasks := newSuites(1, 2, 2)
+	m := NewManager()
+	m.Load(suites)
+
+	before := m.Snapshot()
+	m.Ingest(tasks[0], outcome(teststruct.AssertFailure))
+	after := m.Snapshot()
+
+	if after.Version <= before.Version {
+		t.Errorf("Version = %d after an ingest, want > %d", after.Version, before.Version)
+	}
+
+	// The snapshot taken before is not modified
+	ts, tc, step := tasks[0].TestSuite.ID, tasks[0].TestCase.ID, tasks[0].TestStep.ID
+	if got := before.Suites[ts].Cases[tc].Steps[step].Status(); got != teststruct.NotDefined.String() {
+		t.Errorf("step is %q in the previous snapshot, want %q", got, teststruct.NotDefined.String())
+	}
+	if got := before.Suites[ts].Status; got != teststruct.NotDefined.String() {
+		t.Errorf("testsuite is %q in the previous snapshot, want %q", got, teststruct.NotDefined.String())
+	}
+	if got := after.Suites[ts].Status; got != teststruct.Failure.String() {
+		t.Errorf("testsuite is %q in the snapshot, want %q", got, teststruct.Failure.String())
+	}
+
+	// The TestCase untouched is shared between the snapshots
+	other := tasks[len(tasks)-1].TestCase.ID
+	if before.Suites[ts].Cases[other] != after.Suites[ts].Cases[other] {
+		t.Error("the testcase untouched is expected to be shared between the snapshots")
+	}
+
+	// An update is in the snapshot once done
+	m.Update(func() { tasks[1].TestStep.SetPaused("1") })
+	if got := m.Snapshot().Suites[ts].Cases[tc].Steps[tasks[1].TestStep.ID].Status(); got != teststruct.Paused.String() {
+		t.Errorf("step is %q after the update, want %q", got, teststruct.Paused.String())
+	}
+
+	// A TestSuite removed by a reload is not in the snapshot anymore
+	m.Load(map[uint64]*teststruct.TestSuite{})
+	m.Ingest(tasks[0], outcome(teststruct.Success))
+	if len(m.Snapshot().Suites) != 0 {
+		t.Errorf("Suites = %v after a reload, want none", m.Snapshot().Suites)
+	}
+}
+
+func TestManager_nil(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	_, tasks := newSuites(1, 1, 1)
+
+	// Without a manager the outcome is applied by the caller
+	var m *Manager
+	m.Ingest(tasks[0], outcome(teststruct.AssertFailure))
+	if got := tasks[0].TestCase.GetStatus(); got != teststruct.Failure {
+		t.Errorf("testcase is %q, want %q", got, teststruct.Failure)
+	}
+}
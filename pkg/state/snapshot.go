@@ -0,0 +1,100 @@
+package state
+
+import (
+	"time"
+
+	"github.com/vincoll/vigie/pkg/teststruct"
+)
+
+// Snapshot is the state of the TestSuites at a time, consistent: the status of a TestSuite
+// is the one of its TestCases and TestSteps. It must not be modified.
+type Snapshot struct {
+	Version uint64
+	Taken   time.Time
+	Suites  map[uint64]*Suite
+}
+
+// Suite is the view of a TestSuite.
+type Suite struct {
+	teststruct.TSHeader
+	Cases map[uint64]*Case
+}
+
+// Case is the view of a TestCase.
+type Case struct {
+	teststruct.TCHeader
+	Steps map[uint64]*Step
+}
+
+// Step is the view of a TestStep.
+type Step struct {
+	desc  teststruct.TStepDescribe
+	tStep *teststruct.TestStep // for its next run, from its schedule
+}
+
+func newStep(tStep *teststruct.TestStep) *Step {
+	return &Step{desc: tStep.ToTestStepDescribe(), tStep: tStep}
+}
+
+// Status returns the status of the TestStep.
+func (st *Step) Status() string {
+	return st.desc.StepResD.StatusStr
+}
+
+// Describe returns the TestStep as exposed by the API.
+func (st *Step) Describe() teststruct.TStepDescribe {
+
+	desc := st.desc
+	desc.StepNext = st.tStep.NextRun(time.Now())
+	return desc
+}
+
+// Describe returns the TestCase and its TestSteps as exposed by the API.
+func (c *Case) Describe() teststruct.TCDescribe {
+
+	desc := teststruct.TCDescribe{
+		ID:        c.ID,
+		Name:      c.Name,
+		Status:    c.Status,
+		TestSteps: make([]teststruct.TStepDescribe, 0, len(c.Steps)),
+	}
+	for _, st := range c.Steps {
+		desc.TestSteps = append(desc.TestSteps, st.Describe())
+	}
+	return desc
+}
+
+// Describe returns the TestSuite, its TestCases and TestSteps as exposed by the API.
+func (s *Suite) Describe() teststruct.TSDescribe {
+
+	desc := teststruct.TSDescribe{
+		ID:        s.ID,
+		Name:      s.Name,
+		Status:    s.Status,
+		TestCases: make([]teststruct.TCDescribe, 0, len(s.Cases)),
+	}
+	for _, c := range s.Cases {
+		desc.TestCases = append(desc.TestCases, c.Describe())
+	}
+	return desc
+}
+
+// copy returns the Suite with its own map of Cases.
+func (s *Suite) copy() *Suite {
+
+	cp := &Suite{TSHeader: s.TSHeader, Cases: make(map[uint64]*Case, len(s.Cases))}
+	for id, c := range s.Cases {
+		cp.Cases[id] = c
+	}
+	return cp
+}
+
+// copy returns the Case with its own map of Steps.
+func (c *Case) copy() *Case {
+
+	cp := &Case{TCHeader: c.TCHeader, Steps: make(map[uint64]*Step, len(c.Steps))}
+	for id, st := range c.Steps {
+		cp.Steps[id] = st
+	}
+	return cp
+}
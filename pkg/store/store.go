@@ -0,0 +1,213 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/secret"
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+	"github.com/vincoll/vigie/pkg/utils/timeutils"
+)
+
+const (
+	defaultRetention = 24 * time.Hour
+	defaultInterval  = time.Minute
+)
+
+// ConfStore is the local file keeping the state of the TestSteps across restarts.
+type ConfStore struct {
+	Enable bool `toml:"enable"`
+	// state.json in the temp folder of Vigie if not set
+	File string `toml:"file"`
+	// History kept for each TestStep, and state of the TestSteps removed
+	Retention string `toml:"retention"`
+	// The state is saved at this interval
+	Interval string `toml:"interval"`
+}
+
+// Store keeps the last state of each TestStep by ID and a short history of its runs.
+type Store struct {
+	mu        sync.Mutex
+	file      string
+	retention time.Duration
+	Interval  time.Duration
+	steps     map[uint64]*Record
+}
+
+// Record is the state of a TestStep and its history.
+type Record struct {
+	ID      uint64               `json:"id"`
+	State   teststruct.StepState `json:"state"`
+	History []Point              `json:"history"`
+}
+
+// Point is a run of a TestStep.
+type Point struct {
+	Time   time.Time             `json:"time"`
+	Status teststruct.StepStatus `json:"status"`
+}
+
+// snapshot is the content of the file.
+type snapshot struct {
+	Saved time.Time `json:"saved"`
+	Steps []Record  `json:"steps"`
+}
+
+// NewStore loads the state saved by a previous run, the records older than the retention are dropped.
+func NewStore(conf ConfStore) (*Store, error) {
+
+	s := &Store{
+		file:      conf.File,
+		retention: defaultRetention,
+		Interval:  defaultInterval,
+		steps:     make(map[uint64]*Record),
+	}
+	if s.file == "" {
+		s.file = filepath.Join(utils.TEMPPATH, "state.json")
+	}
+
+	if conf.Retention != "" {
+		r, err := timeutils.ShortTimeStrToDuration(conf.Retention)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retention: %s", err)
+		}
+		s.retention = r
+	}
+	if conf.Interval != "" {
+		i, err := timeutils.ShortTimeStrToDuration(conf.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %s", err)
+		}
+		s.Interval = i
+	}
+	if s.retention <= 0 || s.Interval <= 0 {
+		return nil, fmt.Errorf("the retention and the interval of the state store must be positive")
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.prune(time.Now())
+	return s, nil
+}
+
+// load reads the state saved in the file, none if the file does not exist yet.
+func (s *Store) load() error {
+
+	raw, err := ioutil.ReadFile(s.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read the state: %s", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return fmt.Errorf("cannot read the state from %s: %s", s.file, err)
+	}
+
+	for i := range snap.Steps {
+		s.steps[snap.Steps[i].ID] = &snap.Steps[i]
+	}
+	return nil
+}
+
+// Get returns the state saved of a TestStep.
+func (s *Store) Get(id uint64) (teststruct.StepState, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, found := s.steps[id]
+	if !found {
+		return teststruct.StepState{}, false
+	}
+	return rec.State, true
+}
+
+// History returns the runs of a TestStep kept, the oldest first.
+func (s *Store) History(id uint64) []Point {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, found := s.steps[id]
+	if !found {
+		return []Point{}
+	}
+	return append([]Point(nil), rec.History...)
+}
+
+// Put records the state of a TestStep, its last run is added to its history.
+func (s *Store) Put(id uint64, st teststruct.StepState) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, found := s.steps[id]
+	if !found {
+		rec = &Record{ID: id, History: make([]Point, 0, 1)}
+		s.steps[id] = rec
+	}
+	rec.State = st
+
+	if n := len(rec.History); n == 0 || st.LastAttempt.After(rec.History[n-1].Time) {
+		rec.History = append(rec.History, Point{Time: st.LastAttempt, Status: st.Status})
+	}
+}
+
+// prune drops the runs older than the retention,
+// and the TestSteps not run since (removed). s.mu must be held.
+func (s *Store) prune(now time.Time) {
+
+	limit := now.Add(-s.retention)
+	for id, rec := range s.steps {
+		if rec.State.LastAttempt.Before(limit) {
+			delete(s.steps, id)
+			continue
+		}
+		i := sort.Search(len(rec.History), func(i int) bool { return !rec.History[i].Time.Before(limit) })
+		rec.History = rec.History[i:]
+	}
+}
+
+// Save writes the state into the file through a temp file renamed,
+// in order not to leave a truncated file. The results hold the probe answers:
+// the secrets are redacted and the file is readable by its owner only.
+func (s *Store) Save() error {
+
+	s.mu.Lock()
+	s.prune(time.Now())
+
+	snap := snapshot{Saved: time.Now(), Steps: make([]Record, 0, len(s.steps))}
+	for _, rec := range s.steps {
+		snap.Steps = append(snap.Steps, *rec)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(snap.Steps, func(i, j int) bool { return snap.Steps[i].ID < snap.Steps[j].ID })
+
+	raw, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("cannot save the state: %s", err)
+	}
+
+	// A temp file left by a previous run keeps its mode
+	tmp := s.file + ".tmp"
+	_ = os.Remove(tmp)
+	if err := ioutil.WriteFile(tmp, secret.RedactBytes(raw), 0600); err != nil {
+		return fmt.Errorf("cannot save the state: %s", err)
+	}
+	if err := os.Rename(tmp, s.file); err != nil {
+		return fmt.Errorf("cannot save the state: %s", err)
+	}
+	return nil
+}
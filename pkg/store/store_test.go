@@ -0,0 +1,144 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/assertion"
+	"github.com/vincoll/vigie/pkg/probe"
+	"github.com/vincoll/vigie/pkg/secret"
+	"github.com/vincoll/vigie/pkg/teststruct"
+)
+
+func TestStore_restart(t *testing.T) {
+
+	conf := ConfStore{Enable: true, File: filepath.Join(t.TempDir(), "state.json"), Retention: "1h"}
+	now := time.Now().Truncate(time.Second)
+
+	s, err := NewStore(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := []teststruct.StoredResult{{
+		Answer:          map[string]interface{}{"httpcode": 503.0},
+		ProbeInfo:       probe.ProbeInfo{Status: probe.Success, ResponseTime: 120 * time.Millisecond, IPresolved: "10.0.0.1"},
+		AssertionResult: []assertion.AssertResult{{Assertion: "httpcode == 200", Severity: assertion.Warning}},
+		Status:          teststruct.AssertFailure,
+	}}
+
+	s.Put(1, teststruct.StepState{Status: teststruct.Success, LastAttempt: now.Add(-2 * time.Minute)})
+	s.Put(1, teststruct.StepState{Status: teststruct.AssertFailure, LastAttempt: now.Add(-time.Minute), VigieResults: results})
+	// Same run saved twice: a single point
+	s.Put(1, teststruct.StepState{Status: teststruct.AssertFailure, LastAttempt: now.Add(-time.Minute), VigieResults: results})
+	// Removed before the retention
+	s.Put(2, teststruct.StepState{Status: teststruct.Success, LastAttempt: now.Add(-2 * time.Hour)})
+
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewStore(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, found := restarted.Get(1)
+	if !found || st.Status != teststruct.AssertFailure || !st.LastAttempt.Equal(now.Add(-time.Minute)) {
+		t.Fatalf("Get(1) = %+v, %v, want the last state", st, found)
+	}
+	if len(st.VigieResults) != 1 {
+		t.Fatalf("Get(1) VigieResults = %+v, want 1 result", st.VigieResults)
+	}
+	vr := st.VigieResults[0]
+	if vr.ProbeInfo.ResponseTime != 120*time.Millisecond || vr.ProbeInfo.IPresolved != "10.0.0.1" ||
+		vr.Answer["httpcode"] != 503.0 || vr.AssertionResult[0].Severity != assertion.Warning {
+		t.Errorf("Get(1) VigieResults = %+v, not restored", vr)
+	}
+
+	if h := restarted.History(1); len(h) != 2 || h[0].Status != teststruct.Success || h[1].Status != teststruct.AssertFailure {
+		t.Errorf("History(1) = %+v, want success then assert_failure", h)
+	}
+	if _, found := restarted.Get(2); found {
+		t.Error("Get(2) expected the TestStep not run within the retention to be dropped")
+	}
+}
+
+func TestStore_prune(t *testing.T) {
+
+	now := time.Now()
+	s := &Store{retention: time.Hour, steps: make(map[uint64]*Record)}
+
+	s.Put(1, teststruct.StepState{Status: teststruct.Success, LastAttempt: now.Add(-90 * time.Minute)})
+	s.Put(1, teststruct.StepState{Status: teststruct.Failure, LastAttempt: now.Add(-30 * time.Minute)})
+	s.Put(1, teststruct.StepState{Status: teststruct.Success, LastAttempt: now})
+
+	s.prune(now)
+
+	h := s.History(1)
+	if len(h) != 2 || h[0].Status != teststruct.Failure {
+		t.Errorf("History() = %+v, want the 2 runs within the retention", h)
+	}
+}
+
+func TestNewStore_conf(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		conf    ConfStore
+		wantErr bool
+	}{
+		{name: "default", conf: ConfStore{}},
+		{name: "short", conf: ConfStore{Retention: "6h", Interval: "30s"}},
+		{name: "bad retention", conf: ConfStore{Retention: "a week"}, wantErr: true},
+		{name: "bad interval", conf: ConfStore{Interval: "-1m"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.conf.File = filepath.Join(t.TempDir(), "state.json")
+			if _, err := NewStore(tt.conf); (err != nil) != tt.wantErr {
+				t.Errorf("NewStore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStore_Save_secret(t *testing.T) {
+
+	os.Setenv("VIGIE_TEST_STORE", "t0k3n")
+	token, err := secret.Resolve("secret://env/VIGIE_TEST_STORE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := ConfStore{Enable: true, File: filepath.Join(t.TempDir(), "state.json")}
+	s, err := NewStore(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []teststruct.StoredResult{{Answer: map[string]interface{}{"body": "token=" + token}, Status: teststruct.Success}}
+	s.Put(1, teststruct.StepState{Status: teststruct.Success, LastAttempt: time.Now(), VigieResults: results})
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := ioutil.ReadFile(conf.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), token) {
+		t.Errorf("Save() wrote the secret: %s", raw)
+	}
+	fi, err := os.Stat(conf.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("Save() file mode = %v, want 0600", fi.Mode().Perm())
+	}
+}
@@ -35,23 +35,29 @@ type TotalAlertMessage struct {
 type StepStatus int
 
 const (
-	Success       StepStatus = 1
-	NotDefined    StepStatus = 0
-	AssertFailure StepStatus = -1
-	Failure       StepStatus = -2
-	Timeout       StepStatus = -3
-	Error         StepStatus = -4
+	Paused           StepStatus = 4 // Not run: paused through the API
+	DependencyFailed StepStatus = 3 // Not run: a dependency is down
+	Warning          StepStatus = 2 // Only warning assertions have failed
+	Success          StepStatus = 1
+	NotDefined       StepStatus = 0
+	AssertFailure    StepStatus = -1
+	Failure          StepStatus = -2
+	Timeout          StepStatus = -3
+	Error            StepStatus = -4
 )
 
 func (ss StepStatus) String() string {
 
 	m := map[StepStatus]string{
-		NotDefined:    "not_defined",
-		Success:       "success",
-		AssertFailure: "assert_failure",
-		Failure:       "failure",
-		Timeout:       "timeout",
-		Error:         "error",
+		NotDefined:       "not_defined",
+		Success:          "success",
+		Warning:          "warning",
+		AssertFailure:    "assert_failure",
+		Failure:          "failure",
+		Timeout:          "timeout",
+		Error:            "error",
+		DependencyFailed: "dependency_failed",
+		Paused:           "paused",
 	}
 
 	return m[ss]
@@ -65,8 +71,34 @@ func (ss StepStatus) IsSucess() bool {
 	return ss == Success
 }
 
+// IsFailure returns true if the status puts the TestStep down,
+// a Warning is not a failure, neither is a DependencyFailed (the root cause is) or a Paused.
+func (ss StepStatus) IsFailure() bool {
+	return ss != Success && ss != Warning && ss != NotDefined && ss != DependencyFailed && ss != Paused
+}
+
+// WorseThan returns true if ss is a worse status than other:
+// Success < Warning < NotDefined, DependencyFailed, Paused < AssertFailure < Failure < Timeout < Error
+func (ss StepStatus) WorseThan(other StepStatus) bool {
+	return ss.gravity() > other.gravity()
+}
+
+func (ss StepStatus) gravity() int {
+	switch ss {
+	case Success:
+		return 0
+	case Warning:
+		return 1
+	case DependencyFailed, Paused:
+		return 2
+	default:
+		// NotDefined and failures (0, -1 ... -4)
+		return 2 - int(ss)
+	}
+}
+
 func (ss StepStatus) IsTimeMesureable() bool {
-	if ss == Success || ss == AssertFailure {
+	if ss == Success || ss == Warning || ss == AssertFailure {
 		return true
 	} else {
 		return false
@@ -0,0 +1,232 @@
+package teststruct
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DependsOn are the references of the TestSteps or TestCases a step depends on:
+// testsuite/testcase[/teststep], ./testcase[/teststep] in the same TestSuite, or an UID (1-2[-3]).
+// A single reference or a list of references.
+type DependsOn []string
+
+func (do *DependsOn) UnmarshalJSON(data []byte) error {
+
+	var ref string
+	if err := json.Unmarshal(data, &ref); err == nil {
+		*do = DependsOn{ref}
+		return nil
+	}
+
+	var refs []string
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return fmt.Errorf("depends_on must be a reference or a list of references")
+	}
+	*do = refs
+	return nil
+}
+
+var uidRE = regexp.MustCompile(`^\d+-\d+(-\d+)?$`)
+
+// depIndex resolves the references of the dependencies into TestSteps,
+// it is rebuilt after each import.
+type depIndex struct {
+	sync.RWMutex
+	refs  map[string][]*TestStep // path and UID of the TestSteps and the TestCases
+	paths map[*TestStep]string   // testsuite/testcase/teststep
+	suite map[*TestStep]string   // TestSuite name, for the relative references
+}
+
+var dependencies = depIndex{
+	refs:  map[string][]*TestStep{},
+	paths: map[*TestStep]string{},
+	suite: map[*TestStep]string{},
+}
+
+// IndexDependencies indexes the TestSteps of the TestSuites to resolve the dependencies,
+// then returns the references which cannot be resolved and the dependency cycles.
+func IndexDependencies(tss map[uint64]*TestSuite) []error {
+
+	refs := map[string][]*TestStep{}
+	paths := map[*TestStep]string{}
+	suite := map[*TestStep]string{}
+
+	for _, ts := range tss {
+		ts.Mutex.RLock()
+		for _, tc := range ts.TestCases {
+			tc.Mutex.RLock()
+			tcPath := ts.Name + "/" + tc.Name
+			tcUID := fmt.Sprintf("%d-%d", ts.ID, tc.ID)
+			for _, tStep := range tc.TestSteps {
+				tStep.Mutex.RLock()
+				stepPath := tcPath + "/" + tStep.Name
+				refs[stepPath] = append(refs[stepPath], tStep)
+				refs[fmt.Sprintf("%s-%d", tcUID, tStep.ID)] = append(refs[fmt.Sprintf("%s-%d", tcUID, tStep.ID)], tStep)
+				refs[tcPath] = append(refs[tcPath], tStep)
+				refs[tcUID] = append(refs[tcUID], tStep)
+				paths[tStep] = stepPath
+				suite[tStep] = ts.Name
+				tStep.Mutex.RUnlock()
+			}
+			tc.Mutex.RUnlock()
+		}
+		ts.Mutex.RUnlock()
+	}
+
+	dependencies.Lock()
+	dependencies.refs, dependencies.paths, dependencies.suite = refs, paths, suite
+	dependencies.Unlock()
+
+	return dependencies.validate()
+}
+
+// resolve returns the TestSteps of a reference, the TestStep itself excluded.
+// Must be read locked.
+func (di *depIndex) resolve(ref string, from *TestStep) []*TestStep {
+
+	key := ref
+	if strings.HasPrefix(ref, "./") {
+		key = di.suite[from] + "/" + strings.TrimPrefix(ref, "./")
+	}
+
+	steps := make([]*TestStep, 0, len(di.refs[key]))
+	for _, s := range di.refs[key] {
+		if s != from {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// validate returns the unknown references and the cycles.
+func (di *depIndex) validate() []error {
+
+	di.RLock()
+	defer di.RUnlock()
+
+	var errs []error
+	graph := make(map[*TestStep][]*TestStep, len(di.paths))
+
+	for tStep, path := range di.paths {
+		tStep.Mutex.RLock()
+		for _, ref := range tStep.DependsOn {
+			if !uidRE.MatchString(ref) && !strings.Contains(ref, "/") {
+				errs = append(errs, fmt.Errorf("%s: invalid dependency %q, must be testsuite/testcase[/teststep], ./testcase[/teststep] or an UID", path, ref))
+				continue
+			}
+			deps := di.resolve(ref, tStep)
+			if len(deps) == 0 {
+				errs = append(errs, fmt.Errorf("%s: dependency %q does not exist", path, ref))
+				continue
+			}
+			graph[tStep] = append(graph[tStep], deps...)
+		}
+		tStep.Mutex.RUnlock()
+	}
+
+	// Cycles: depth-first search
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*TestStep]int, len(graph))
+	var visit func(s *TestStep, chain []string)
+	visit = func(s *TestStep, chain []string) {
+		state[s] = visiting
+		chain = append(chain, di.paths[s])
+		for _, d := range graph[s] {
+			switch state[d] {
+			case visiting:
+				errs = append(errs, fmt.Errorf("dependency cycle: %s > %s", strings.Join(chain, " > "), di.paths[d]))
+			case unvisited:
+				visit(d, chain)
+			}
+		}
+		state[s] = visited
+	}
+
+	// Same errors order at each import
+	steps := make([]*TestStep, 0, len(graph))
+	for s := range graph {
+		steps = append(steps, s)
+	}
+	sort.Slice(steps, func(i, j int) bool { return di.paths[steps[i]] < di.paths[steps[j]] })
+	for _, s := range steps {
+		if state[s] == unvisited {
+			visit(s, nil)
+		}
+	}
+
+	return errs
+}
+
+// FailingDependency returns the root cause (testsuite/testcase/teststep) if a dependency
+// of the TestStep is down: failing, or itself skipped because of its dependencies.
+func (tStep *TestStep) FailingDependency() (rootCause string, failing bool) {
+
+	tStep.Mutex.RLock()
+	refs := tStep.DependsOn
+	tStep.Mutex.RUnlock()
+
+	if len(refs) == 0 {
+		return "", false
+	}
+
+	dependencies.RLock()
+	defer dependencies.RUnlock()
+
+	self := dependencies.paths[tStep]
+	for _, ref := range refs {
+		for _, dep := range dependencies.resolve(ref, tStep) {
+
+			dep.Mutex.RLock()
+			status, root := dep.Status, dep.RootCause
+			dep.Mutex.RUnlock()
+
+			switch {
+			case status == DependencyFailed && root != self:
+				return root, true
+			case status.IsFailure():
+				return dependencies.paths[dep], true
+			}
+		}
+	}
+	return "", false
+}
+
+// dependents returns the TestSteps skipped because of this TestStep.
+func (tStep *TestStep) dependents() []string {
+
+	// The paths are copied: no TestStep is locked under the index lock
+	dependencies.RLock()
+	self, found := dependencies.paths[tStep]
+	paths := make(map[*TestStep]string, len(dependencies.paths))
+	for s, path := range dependencies.paths {
+		paths[s] = path
+	}
+	dependencies.RUnlock()
+
+	if !found {
+		return nil
+	}
+
+	var names []string
+	for s, path := range paths {
+		if s == tStep {
+			continue
+		}
+		s.Mutex.RLock()
+		status, root := s.Status, s.RootCause
+		s.Mutex.RUnlock()
+		if status == DependencyFailed && root == self {
+			names = append(names, path)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,112 @@
+package teststruct
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDependsOn_UnmarshalJSON(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		data    string
+		want    DependsOn
+		wantErr bool
+	}{
+		{name: "single", data: `"core/dns"`, want: DependsOn{"core/dns"}},
+		{name: "list", data: `["core/dns", "./router"]`, want: DependsOn{"core/dns", "./router"}},
+		{name: "invalid", data: `{"a": 1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got DependsOn
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// depSuites returns two TestSuites:
+// core/network: router, dns (depends on ./network/router)
+// web/site: home (depends on core/network/dns), api (depends on 1-10)
+func depSuites() (map[uint64]*TestSuite, map[string]*TestStep) {
+
+	steps := map[string]*TestStep{
+		"router": {Name: "router", ID: 100},
+		"dns":    {Name: "dns", ID: 101, DependsOn: DependsOn{"./network/router"}},
+		"home":   {Name: "home", ID: 200, DependsOn: DependsOn{"core/network/dns"}},
+		"api":    {Name: "api", ID: 201, DependsOn: DependsOn{"1-10"}},
+	}
+
+	tss := map[uint64]*TestSuite{
+		1: {Name: "core", ID: 1, TestCases: map[uint64]*TestCase{
+			10: {Name: "network", ID: 10, TestSteps: map[uint64]*TestStep{100: steps["router"], 101: steps["dns"]}},
+		}},
+		2: {Name: "web", ID: 2, TestCases: map[uint64]*TestCase{
+			20: {Name: "site", ID: 20, TestSteps: map[uint64]*TestStep{200: steps["home"], 201: steps["api"]}},
+		}},
+	}
+	return tss, steps
+}
+
+func TestTestStep_FailingDependency(t *testing.T) {
+
+	tss, steps := depSuites()
+	if errs := IndexDependencies(tss); len(errs) != 0 {
+		t.Fatalf("IndexDependencies() = %v", errs)
+	}
+
+	steps["router"].Status = Timeout
+	steps["dns"].Status = DependencyFailed
+	steps["dns"].RootCause = "core/network/router"
+
+	tests := []struct {
+		name        string
+		step        string
+		wantRoot    string
+		wantFailing bool
+	}{
+		{name: "no dependency", step: "router"},
+		{name: "relative", step: "dns", wantRoot: "core/network/router", wantFailing: true},
+		{name: "transitive root cause", step: "home", wantRoot: "core/network/router", wantFailing: true},
+		{name: "testcase UID", step: "api", wantRoot: "core/network/router", wantFailing: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, failing := steps[tt.step].FailingDependency()
+			if root != tt.wantRoot || failing != tt.wantFailing {
+				t.Errorf("FailingDependency() = %q, %v, want %q, %v", root, failing, tt.wantRoot, tt.wantFailing)
+			}
+		})
+	}
+
+	if got := steps["router"].dependents(); !reflect.DeepEqual(got, []string{"core/network/dns"}) {
+		t.Errorf("dependents() = %v", got)
+	}
+
+	// The root cause recovers
+	steps["router"].Status = Success
+	if _, failing := steps["dns"].FailingDependency(); failing {
+		t.Error("FailingDependency() expected no failing dependency after the recovery")
+	}
+}
+
+func TestIndexDependencies_errors(t *testing.T) {
+
+	tss, steps := depSuites()
+	steps["router"].DependsOn = DependsOn{"web/site/home"}
+	steps["api"].DependsOn = DependsOn{"nothing/here", "router"}
+
+	errs := IndexDependencies(tss)
+	if len(errs) != 3 {
+		t.Fatalf("IndexDependencies() = %v, want an unknown, an invalid reference and a cycle", errs)
+	}
+}
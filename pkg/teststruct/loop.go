@@ -0,0 +1,231 @@
+package teststruct
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vincoll/vigie/pkg/render"
+)
+
+// loopItem is the placeholder replaced by the values of a loop,
+// $item.<name> for a named dimension.
+const loopItem = "$item"
+
+// StepLoop multiplies a step by the values of its dimensions.
+// A list is a single dimension ($item), a map of lists has named
+// dimensions ($item.url, $item.resolver) expanded as a Cartesian product.
+type StepLoop []LoopDimension
+
+// LoopDimension is a named list of values, a value can be a variable ($var).
+type LoopDimension struct {
+	Name   string
+	Values []string
+}
+
+func (sl *StepLoop) UnmarshalJSON(data []byte) error {
+
+	var list []interface{}
+	if err := json.Unmarshal(data, &list); err == nil {
+		*sl = StepLoop{{Values: render.List(list)}}
+		return nil
+	}
+
+	var dims map[string]interface{}
+	if err := json.Unmarshal(data, &dims); err != nil {
+		return fmt.Errorf("loop must be a list or a map of lists")
+	}
+
+	loop := make(StepLoop, 0, len(dims))
+	for name, values := range dims {
+		if name == "" {
+			return fmt.Errorf("loop dimension name is empty")
+		}
+		loop = append(loop, LoopDimension{Name: name, Values: render.List(values)})
+	}
+	// Same expansion order at each import
+	sort.Slice(loop, func(i, j int) bool { return loop[i].Name < loop[j].Name })
+
+	*sl = loop
+	return nil
+}
+
+// loopCoord is the value of a dimension for an expanded step.
+type loopCoord struct {
+	Name  string
+	Value string
+}
+
+// placeholder returns the value replaced by this coordinate: $item or $item.<name>
+func (lc loopCoord) placeholder() string {
+	if lc.Name == "" {
+		return loopItem
+	}
+	return loopItem + "." + lc.Name
+}
+
+// tag returns the generated tag of this coordinate: loop_item or loop_<name>
+func (lc loopCoord) tag() string {
+	if lc.Name == "" {
+		return "loop_item"
+	}
+	return "loop_" + lc.Name
+}
+
+// loopedProbe is a probe expanded by a loop, with its coordinates.
+type loopedProbe struct {
+	ProbeWrap ProbeWrap
+	Coords    []loopCoord
+}
+
+// coordsName returns the coordinates for a step name: url=a.com, resolver=1.1.1.1
+func (lp loopedProbe) coordsName() string {
+	parts := make([]string, 0, len(lp.Coords))
+	for _, c := range lp.Coords {
+		parts = append(parts, fmt.Sprintf("%s=%s", c.Name, c.Value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// isMatrix tells if the step has been expanded on named dimensions.
+func (lp loopedProbe) isMatrix() bool {
+	return len(lp.Coords) > 0 && lp.Coords[0].Name != ""
+}
+
+// expand returns the values of the dimension, variables ($var) are replaced by their values.
+func (ld LoopDimension) expand(vars render.Vars) ([]string, error) {
+
+	values := make([]string, 0, len(ld.Values))
+	for _, v := range ld.Values {
+		if strings.HasPrefix(v, "$") && len(v) > 1 {
+			varValue, present := vars[v[1:]]
+			if !present {
+				return nil, fmt.Errorf("vars does not exists %q", v[1:])
+			}
+			values = append(values, render.List(varValue)...)
+			continue
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// loop expands the probe of a step on each combination of the loop dimensions.
+func loop(stepProbe map[string]interface{}, sl StepLoop, vars render.Vars, wrapProbe func(map[string]interface{}) (ProbeWrap, error)) ([]loopedProbe, error) {
+
+	// No loop or no placeholder: a single probe
+	if len(sl) == 0 || !hasPlaceholder(stepProbe) {
+		pw, err := wrapProbe(stepProbe)
+		if err != nil {
+			return nil, err
+		}
+		return []loopedProbe{{ProbeWrap: pw}}, nil
+	}
+
+	// Cartesian product of the dimensions
+	combos := [][]loopCoord{{}}
+	for _, dim := range sl {
+		values, err := dim.expand(vars)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("loop dimension %q has no value", dim.Name)
+		}
+
+		next := make([][]loopCoord, 0, len(combos)*len(values))
+		for _, combo := range combos {
+			for _, val := range values {
+				c := append(combo[:len(combo):len(combo)], loopCoord{Name: dim.Name, Value: val})
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+
+	lps := make([]loopedProbe, 0, len(combos))
+	for _, combo := range combos {
+
+		replace := make(map[string]string, len(combo))
+		for _, c := range combo {
+			replace[c.placeholder()] = c.Value
+		}
+
+		expanded, err := replacePlaceholders(stepProbe, replace)
+		if err != nil {
+			return nil, err
+		}
+
+		pw, err := wrapProbe(expanded.(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		lps = append(lps, loopedProbe{ProbeWrap: pw, Coords: combo})
+	}
+
+	return lps, nil
+}
+
+// hasPlaceholder tells if a value contains a loop placeholder.
+func hasPlaceholder(v interface{}) bool {
+
+	switch val := v.(type) {
+	case string:
+		return val == loopItem || strings.HasPrefix(val, loopItem+".")
+	case map[string]interface{}:
+		for _, sub := range val {
+			if hasPlaceholder(sub) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, sub := range val {
+			if hasPlaceholder(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replacePlaceholders returns a copy of the value with the loop placeholders replaced.
+func replacePlaceholders(v interface{}, replace map[string]string) (interface{}, error) {
+
+	switch val := v.(type) {
+	case string:
+		if !(val == loopItem || strings.HasPrefix(val, loopItem+".")) {
+			return val, nil
+		}
+		r, found := replace[val]
+		if !found {
+			return nil, fmt.Errorf("%s is not a dimension of the loop", val)
+		}
+		return r, nil
+
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(val))
+		for k, sub := range val {
+			r, err := replacePlaceholders(sub, replace)
+			if err != nil {
+				return nil, err
+			}
+			copied[k] = r
+		}
+		return copied, nil
+
+	case []interface{}:
+		copied := make([]interface{}, len(val))
+		for i, sub := range val {
+			r, err := replacePlaceholders(sub, replace)
+			if err != nil {
+				return nil, err
+			}
+			copied[i] = r
+		}
+		return copied, nil
+
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,124 @@
+package teststruct
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/vincoll/vigie/pkg/render"
+)
+
+func TestStepLoop_UnmarshalJSON(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		data    string
+		want    StepLoop
+		wantErr bool
+	}{
+		{name: "list", data: `["$hosts", "a.com"]`, want: StepLoop{{Values: []string{"$hosts", "a.com"}}}},
+		{name: "numbers", data: `[80, 443]`, want: StepLoop{{Values: []string{"80", "443"}}}},
+		{
+			name: "dimensions",
+			data: `{"url": ["$urls"], "ipversion": [4, 6]}`,
+			want: StepLoop{{Name: "ipversion", Values: []string{"4", "6"}}, {Name: "url", Values: []string{"$urls"}}},
+		},
+		{name: "invalid", data: `"a.com"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StepLoop
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoop(t *testing.T) {
+
+	vars := render.Vars{"urls": []interface{}{"https://a.com", "https://b.com"}}
+
+	// The probe map is kept as is to check the expansion
+	keep := func(stepProbe map[string]interface{}) (ProbeWrap, error) {
+		return ProbeWrap{Template: &ProbeTemplate{Probe: stepProbe}}, nil
+	}
+
+	tests := []struct {
+		name       string
+		probe      map[string]interface{}
+		loop       StepLoop
+		wantProbes []map[string]interface{}
+		wantCoords [][]loopCoord
+		wantErr    bool
+	}{
+		{
+			name:       "no_loop",
+			probe:      map[string]interface{}{"url": "https://a.com"},
+			wantProbes: []map[string]interface{}{{"url": "https://a.com"}},
+			wantCoords: [][]loopCoord{nil},
+		},
+		{
+			name:       "item",
+			probe:      map[string]interface{}{"url": "$item"},
+			loop:       StepLoop{{Values: []string{"$urls", "https://c.com"}}},
+			wantProbes: []map[string]interface{}{{"url": "https://a.com"}, {"url": "https://b.com"}, {"url": "https://c.com"}},
+			wantCoords: [][]loopCoord{{{Value: "https://a.com"}}, {{Value: "https://b.com"}}, {{Value: "https://c.com"}}},
+		},
+		{
+			name:  "matrix",
+			probe: map[string]interface{}{"url": "$item.url", "ip": map[string]interface{}{"version": "$item.ipversion"}},
+			loop:  StepLoop{{Name: "ipversion", Values: []string{"4", "6"}}, {Name: "url", Values: []string{"$urls"}}},
+			wantProbes: []map[string]interface{}{
+				{"url": "https://a.com", "ip": map[string]interface{}{"version": "4"}},
+				{"url": "https://b.com", "ip": map[string]interface{}{"version": "4"}},
+				{"url": "https://a.com", "ip": map[string]interface{}{"version": "6"}},
+				{"url": "https://b.com", "ip": map[string]interface{}{"version": "6"}},
+			},
+			wantCoords: [][]loopCoord{
+				{{Name: "ipversion", Value: "4"}, {Name: "url", Value: "https://a.com"}},
+				{{Name: "ipversion", Value: "4"}, {Name: "url", Value: "https://b.com"}},
+				{{Name: "ipversion", Value: "6"}, {Name: "url", Value: "https://a.com"}},
+				{{Name: "ipversion", Value: "6"}, {Name: "url", Value: "https://b.com"}},
+			},
+		},
+		{
+			name:    "unknown_dimension",
+			probe:   map[string]interface{}{"url": "$item.host"},
+			loop:    StepLoop{{Name: "url", Values: []string{"$urls"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown_var",
+			probe:   map[string]interface{}{"url": "$item"},
+			loop:    StepLoop{{Values: []string{"$nope"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loop(tt.probe, tt.loop, vars, keep)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loop() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.wantProbes) {
+				t.Fatalf("loop() returned %d probes, want %d", len(got), len(tt.wantProbes))
+			}
+			for i, lp := range got {
+				if !reflect.DeepEqual(lp.ProbeWrap.Template.Probe, tt.wantProbes[i]) {
+					t.Errorf("loop()[%d] probe = %v, want %v", i, lp.ProbeWrap.Template.Probe, tt.wantProbes[i])
+				}
+				if !reflect.DeepEqual(lp.Coords, tt.wantCoords[i]) {
+					t.Errorf("loop()[%d] coords = %v, want %v", i, lp.Coords, tt.wantCoords[i])
+				}
+			}
+		})
+	}
+}
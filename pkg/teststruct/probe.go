@@ -1,60 +1,153 @@
 package teststruct
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/vincoll/vigie/pkg/maintenance"
 	"github.com/vincoll/vigie/pkg/probe"
+	"github.com/vincoll/vigie/pkg/render"
+	"github.com/vincoll/vigie/pkg/secret"
 )
 
 // Property represents a key/value pair used to define properties.
 type configTestStructJson struct {
-	Frequency   ProbeConfigJsonRaw `json:"frequency"` // time interval between two tests
-	Concurrency map[string]int     `json:"concurrency"`
-	Timeout     ProbeConfigJsonRaw `json:"timeout"`    // timeout on executor
-	Retry       map[string]int     `json:"retry"`      // nb retry a test case if it is in failure.
-	Retrydelay  ProbeConfigJsonRaw `json:"retrydelay"` // delay between two retries
+	Frequency        ProbeConfigJsonRaw       `json:"frequency"` // time interval between two tests
+	Concurrency      map[string]int           `json:"concurrency"`
+	Timeout          ProbeConfigJsonRaw       `json:"timeout"`           // timeout on executor
+	Retry            map[string]int           `json:"retry"`             // nb retry a test case if it is in failure.
+	Retrydelay       ProbeConfigJsonRaw       `json:"retrydelay"`        // delay between two retries
+	Retrybackoff     map[string]float64       `json:"retrybackoff"`      // multiplier of the delay after each retry
+	FailureFrequency ProbeConfigJsonRaw       `json:"failure_frequency"` // time interval between two tests while failing
+	FailureBackoff   map[string]float64       `json:"failure_backoff"`   // multiplier of the failure interval after each run
+	Maintenance      []maintenance.WindowJSON `json:"maintenance"`       // maintenance windows, inherited by the children
+	Schedule         *Schedule                `json:"schedule"`          // cron and active hours, inherited by the children
 }
 
 // Property represents a key/value pair used to define properties.
 type configTestStruct struct {
-	Frequency   map[string]time.Duration `json:"frequency"` // time interval between two tests
-	Concurrency map[string]int           `json:"concurrency"`
-	Timeout     map[string]time.Duration `json:"timeout"`    // timeout on executor
-	Retry       map[string]int           `json:"retry"`      // nb retry a test case if it is in failure.
-	Retrydelay  map[string]time.Duration `json:"retrydelay"` // delay between two retries
+	Frequency        map[string]time.Duration `json:"frequency"` // time interval between two tests
+	Concurrency      map[string]int           `json:"concurrency"`
+	Timeout          map[string]time.Duration `json:"timeout"`    // timeout on executor
+	Retry            map[string]int           `json:"retry"`      // nb retry a test case if it is in failure.
+	Retrydelay       map[string]time.Duration `json:"retrydelay"` // delay between two retries
+	Retrybackoff     map[string]float64       `json:"retrybackoff"`
+	FailureFrequency map[string]time.Duration `json:"failure_frequency"`
+	FailureBackoff   map[string]float64       `json:"failure_backoff"`
+	Maintenance      []maintenance.Window     `json:"maintenance"`
+	DependsOn        DependsOn                `json:"-"` // Dependencies of a TestCase
+	Schedule         *Schedule                `json:"schedule"`
 }
 
 type ProbeConfigJsonRaw map[string]string
 
 type ProbeWrap struct {
-	Probe      probe.Probe   `json:"probe"`
-	Frequency  time.Duration `json:"frequency"`  // time interval between two tests
-	Retry      int           `json:"retry"`      // nb retry a test case if it is in failure.
-	Retrydelay time.Duration `json:"retrydelay"` // delay between two retries
-	Timeout    time.Duration `json:"timeout"`    // timeout on executor
+	Probe            probe.Probe    `json:"probe"`
+	Frequency        time.Duration  `json:"frequency"`         // time interval between two tests
+	Retry            int            `json:"retry"`             // nb retry a test case if it is in failure.
+	Retrydelay       time.Duration  `json:"retrydelay"`        // delay between two retries
+	Retrybackoff     float64        `json:"retrybackoff"`      // multiplier of the delay after each retry: 1 constant, 2 exponential
+	Timeout          time.Duration  `json:"timeout"`           // timeout on executor
+	Concurrency      int            `json:"concurrency"`       // max steps of this probe type running at once, 0 is unlimited
+	FailureFrequency time.Duration  `json:"failure_frequency"` // time interval between two tests while failing, 0 keeps the frequency
+	FailureBackoff   float64        `json:"failure_backoff"`   // multiplier of the failure interval after each run, up to the frequency
+	Template         *ProbeTemplate `json:"-"`                 // Probe rendered at each run (render: run)
+	Secrets          [][]string     `json:"-" hash:"ignore"`   // Paths of the probe fields holding a secret, masked by Export
+}
+
+// ProbeTemplate is the probe of a step rendered at each run.
+type ProbeTemplate struct {
+	Probe map[string]interface{}
+	Vars  render.Vars
+}
+
+// wrap renders the probe and initializes it.
+func (pt *ProbeTemplate) wrap() (ProbeWrap, error) {
+
+	rendered, secrets, err := render.MapSecrets(pt.Probe, pt.Vars)
+	if err != nil {
+		return ProbeWrap{}, fmt.Errorf("probe: %s", err)
+	}
+	pw, err := wrapProbe(rendered)
+	pw.Secrets = secrets
+	return pw, err
+}
+
+// HashInclude excludes the probe rendered at import of a step rendered at each run
+// from its ID: the step is identified by its template.
+func (pw ProbeWrap) HashInclude(field string, v interface{}) (bool, error) {
+	if field == "Probe" && pw.Template != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Rendered returns the ProbeWrap with its probe rendered for this run.
+func (pw ProbeWrap) Rendered() (ProbeWrap, error) {
+
+	if pw.Template == nil {
+		return pw, nil
+	}
+
+	rpw, err := pw.Template.wrap()
+	if err != nil {
+		return pw, err
+	}
+	pw.Probe = rpw.Probe
+	pw.Secrets = rpw.Secrets
+	return pw, nil
 }
 
 type ProbeWrapAPI struct {
-	Probe      probe.Probe `json:"probe"`
-	Frequency  string      `json:"frequency"`  // time interval between two tests
-	Retry      int         `json:"retry"`      // nb retry a test case if it is in failure.
-	Retrydelay string      `json:"retrydelay"` // delay between two retries
-	Timeout    string      `json:"timeout"`    // timeout on executor
+	Probe            interface{} `json:"probe"`      // probe with its secrets redacted
+	Frequency        string      `json:"frequency"`  // time interval between two tests
+	Retry            int         `json:"retry"`      // nb retry a test case if it is in failure.
+	Retrydelay       string      `json:"retrydelay"` // delay between two retries
+	Retrybackoff     float64     `json:"retrybackoff"`
+	FailureFrequency string      `json:"failure_frequency,omitempty"`
+	FailureBackoff   float64     `json:"failure_backoff,omitempty"`
+	Timeout          string      `json:"timeout"` // timeout on executor
 }
 
 func (pw ProbeWrap) Export() ProbeWrapAPI {
 	return ProbeWrapAPI{
-		Probe:      pw.Probe,
-		Frequency:  fmt.Sprintf("%v", pw.Frequency),
-		Timeout:    fmt.Sprintf("%v", pw.Timeout),
-		Retry:      pw.Retry,
-		Retrydelay: fmt.Sprintf("%v", pw.Retrydelay),
+		Probe:            redactedProbe(pw.Probe, pw.Secrets),
+		Frequency:        fmt.Sprintf("%v", pw.Frequency),
+		Timeout:          fmt.Sprintf("%v", pw.Timeout),
+		Retry:            pw.Retry,
+		Retrydelay:       fmt.Sprintf("%v", pw.Retrydelay),
+		Retrybackoff:     pw.Retrybackoff,
+		FailureFrequency: exportFailureFrequency(pw.FailureFrequency),
+		FailureBackoff:   pw.FailureBackoff,
+	}
+}
+
+// exportFailureFrequency formats the failure frequency, empty if the step keeps its frequency.
+func exportFailureFrequency(ff time.Duration) string {
+	if ff == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%v", ff)
+}
+
+// redactedProbe returns the probe as a map with its fields holding a secret
+// masked and the values of the other secrets redacted.
+func redactedProbe(p probe.Probe, secrets [][]string) interface{} {
+
+	if p == nil {
+		return nil
+	}
+	m, err := probe.ToMap(p)
+	if err != nil {
+		return secret.Mask
 	}
+	return secret.MaskFields(secret.RedactValue(m), secrets)
 }
 
-func (pw ProbeWrap) Run() []probe.ProbeReturnInterface {
-	return pw.Probe.Run(pw.Timeout)
+// Run runs the probe, ctx carries its timeout.
+func (pw ProbeWrap) Run(ctx context.Context) []probe.ProbeReturnInterface {
+	return pw.Probe.Run(ctx)
 }
 
 // StepAssertions contains step assertions
@@ -0,0 +1,144 @@
+package teststruct
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/utils/timeutils"
+)
+
+// Schedule runs a TestStep at the times of a cron instead of its frequency,
+// and/or only during its active hours.
+type Schedule struct {
+	Cron     string `json:"cron,omitempty"`     // Runs of the step: "30 2 * * *"
+	Active   string `json:"active,omitempty"`   // Cron of the minutes during which the step runs: "* 8-18 * * 1-5"
+	Timezone string `json:"timezone,omitempty"` // Timezone of the crons, Local by default
+
+	cron, active timeutils.Cron
+	loc          *time.Location
+}
+
+// Validate checks the schedule and prepares it for Next and IsActive.
+func (s *Schedule) Validate() error {
+
+	if s.Cron == "" && s.Active == "" {
+		return fmt.Errorf("schedule must have a cron or active hours")
+	}
+
+	s.loc = time.Local
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return fmt.Errorf("schedule: %s", err)
+		}
+		s.loc = loc
+	}
+
+	var err error
+	if s.Cron != "" {
+		if s.cron, err = timeutils.ParseCron(s.Cron); err != nil {
+			return fmt.Errorf("schedule: %s", err)
+		}
+	}
+	if s.Active != "" {
+		if s.active, err = timeutils.ParseCron(s.Active); err != nil {
+			return fmt.Errorf("schedule active hours: %s", err)
+		}
+	}
+	return nil
+}
+
+// IsCron tells if the step runs at the times of a cron instead of its frequency.
+func (s *Schedule) IsCron() bool {
+	return s != nil && s.Cron != ""
+}
+
+// IsActive tells if the step can run at t.
+func (s *Schedule) IsActive(t time.Time) bool {
+
+	if s == nil || s.Active == "" {
+		return true
+	}
+	return s.active.Match(t.In(s.loc))
+}
+
+// nextActive returns the first time from t (included) within the active hours.
+func (s *Schedule) nextActive(t time.Time) time.Time {
+
+	if s.IsActive(t) {
+		return t
+	}
+	return s.active.Next(t.In(s.loc))
+}
+
+// Next returns the next run of a cron schedule strictly after t,
+// a zero time if there is none.
+func (s *Schedule) Next(t time.Time) time.Time {
+
+	if !s.IsCron() {
+		return time.Time{}
+	}
+
+	// Runs outside the active hours are skipped
+	next := t
+	for i := 0; i < 1000; i++ {
+		next = s.cron.Next(next.In(s.loc))
+		if next.IsZero() || s.IsActive(next) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// ScheduleOffset spreads the steps of a frequency over its period:
+// derived from the ID of the TestStep, it stays the same across the reloads.
+func ScheduleOffset(id uint64, freq time.Duration) time.Duration {
+	return time.Duration(id % uint64(freq))
+}
+
+// NextRun returns the next time the TestStep is sent to the scheduler after now,
+// a zero time if it will not run.
+func (tStep *TestStep) NextRun(now time.Time) time.Time {
+
+	tStep.Mutex.RLock()
+	sched, id, freq := tStep.Schedule, tStep.ID, tStep.ProbeWrap.Frequency
+	tStep.Mutex.RUnlock()
+
+	if sched.IsCron() {
+		return sched.Next(now)
+	}
+	if freq <= 0 {
+		return time.Time{}
+	}
+
+	// Slot of the step in the period, the periods are aligned on the clock
+	offset := ScheduleOffset(id, freq)
+	slot := now.Truncate(freq).Add(offset)
+	if !slot.After(now) {
+		slot = slot.Add(freq)
+	}
+
+	// First slot within the active hours
+	for i := 0; i < 1000; i++ {
+		active := sched.nextActive(slot)
+		if active.IsZero() {
+			return time.Time{}
+		}
+		if active.Equal(slot) {
+			return slot
+		}
+		slot = active.Truncate(freq).Add(offset)
+		if slot.Before(active) {
+			slot = slot.Add(freq)
+		}
+	}
+	return time.Time{}
+}
+
+// IsActive tells if the TestStep can run at t (active hours).
+func (tStep *TestStep) IsActive(t time.Time) bool {
+
+	tStep.Mutex.RLock()
+	defer tStep.Mutex.RUnlock()
+	return tStep.Schedule.IsActive(t)
+}
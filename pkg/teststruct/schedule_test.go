@@ -0,0 +1,127 @@
+package teststruct
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Validate(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		sched   Schedule
+		wantErr bool
+	}{
+		{name: "cron", sched: Schedule{Cron: "30 2 * * *"}},
+		{name: "active", sched: Schedule{Active: "* 8-18 * * 1-5", Timezone: "Europe/Paris"}},
+		{name: "empty", sched: Schedule{}, wantErr: true},
+		{name: "bad cron", sched: Schedule{Cron: "61 * * * *"}, wantErr: true},
+		{name: "bad active", sched: Schedule{Active: "* 25 * * *"}, wantErr: true},
+		{name: "bad timezone", sched: Schedule{Cron: "* * * * *", Timezone: "Mars/Olympus"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sched.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleOffset(t *testing.T) {
+
+	tests := []struct {
+		name string
+		id   uint64
+		freq time.Duration
+		want time.Duration
+	}{
+		{name: "within", id: 1500, freq: time.Second, want: 1500},
+		{name: "modulo", id: uint64(90 * time.Second), freq: time.Minute, want: 30 * time.Second},
+		{name: "large id", id: 18446744073709551615, freq: time.Minute, want: time.Duration(18446744073709551615 % uint64(time.Minute))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScheduleOffset(tt.id, tt.freq)
+			if got != tt.want || got < 0 || got >= tt.freq {
+				t.Errorf("ScheduleOffset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTestStep_NextRun(t *testing.T) {
+
+	// Monday
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		id    uint64
+		freq  time.Duration
+		sched *Schedule
+		want  time.Time
+	}{
+		{name: "frequency", id: uint64(20 * time.Minute), freq: time.Hour,
+			want: time.Date(2021, 3, 1, 10, 20, 0, 0, time.UTC)},
+		{name: "frequency slot passed", id: 0, freq: time.Hour,
+			want: time.Date(2021, 3, 1, 11, 0, 0, 0, time.UTC)},
+		{name: "cron", freq: time.Hour, sched: &Schedule{Cron: "30 2 * * *", Timezone: "UTC"},
+			want: time.Date(2021, 3, 2, 2, 30, 0, 0, time.UTC)},
+		{name: "cron timezone", freq: time.Hour, sched: &Schedule{Cron: "30 2 * * *", Timezone: "Europe/Paris"},
+			want: time.Date(2021, 3, 2, 1, 30, 0, 0, time.UTC)},
+		{name: "cron inactive runs skipped", freq: time.Hour, sched: &Schedule{Cron: "0 */6 * * *", Active: "* * * * 6,0", Timezone: "UTC"},
+			want: time.Date(2021, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{name: "frequency active", id: uint64(5 * time.Minute), freq: time.Hour, sched: &Schedule{Active: "* 8-18 * * 1-5", Timezone: "UTC"},
+			want: time.Date(2021, 3, 1, 10, 5, 0, 0, time.UTC)},
+		{name: "frequency inactive", id: uint64(5 * time.Minute), freq: time.Hour, sched: &Schedule{Active: "* 12-13 * * *", Timezone: "UTC"},
+			want: time.Date(2021, 3, 1, 12, 5, 0, 0, time.UTC)},
+		{name: "no frequency", freq: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.sched != nil {
+				if err := tt.sched.Validate(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tStep := TestStep{ID: tt.id, Schedule: tt.sched}
+			tStep.ProbeWrap.Frequency = tt.freq
+
+			if got := tStep.NextRun(now); !got.Equal(tt.want) {
+				t.Errorf("NextRun() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_IsActive(t *testing.T) {
+
+	sched := &Schedule{Active: "* 8-18 * * 1-5", Timezone: "UTC"}
+	if err := sched.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		sched *Schedule
+		at    time.Time
+		want  bool
+	}{
+		{name: "no schedule", sched: nil, at: time.Date(2021, 3, 6, 3, 0, 0, 0, time.UTC), want: true},
+		{name: "working hours", sched: sched, at: time.Date(2021, 3, 1, 18, 59, 0, 0, time.UTC), want: true},
+		{name: "evening", sched: sched, at: time.Date(2021, 3, 1, 19, 0, 0, 0, time.UTC), want: false},
+		{name: "weekend", sched: sched, at: time.Date(2021, 3, 6, 10, 0, 0, 0, time.UTC), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sched.IsActive(tt.at); got != tt.want {
+				t.Errorf("IsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
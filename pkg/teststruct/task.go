@@ -1,6 +1,9 @@
 package teststruct
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type Task struct {
 	TestSuite *TestSuite
@@ -8,36 +11,57 @@ type Task struct {
 	TestStep  *TestStep
 }
 
-func (t *Task) LockAll() {
-	t.TestSuite.Mutex.Lock()
-	t.TestCase.Mutex.Lock()
-	t.TestStep.Mutex.Lock()
-}
+// WriteMetadataChanges sets the time of the last change on the Task,
+// each TestStruct is locked in turn: no lock is held while taking another.
+func (t *Task) WriteMetadataChanges(lastchg time.Time) {
 
-func (t *Task) UnlockAll() {
+	t.TestStep.Mutex.Lock()
+	t.TestStep.LastChange = lastchg
 	t.TestStep.Mutex.Unlock()
+
+	t.TestCase.Mutex.Lock()
+	t.TestCase.LastChange = lastchg
 	t.TestCase.Mutex.Unlock()
+
+	t.TestSuite.Mutex.Lock()
+	t.TestSuite.LastChange = lastchg
 	t.TestSuite.Mutex.Unlock()
 }
 
-func (t *Task) RLockAll() {
+// Labels returns the tags and the names of the Task (testsuite, testcase, teststep),
+// matched by the silences.
+func (t *Task) Labels() map[string]string {
+
+	labels := make(map[string]string)
 	t.TestSuite.Mutex.RLock()
+	copyTags(labels, t.TestSuite.Tags)
+	t.TestSuite.Mutex.RUnlock()
+
 	t.TestCase.Mutex.RLock()
-	t.TestStep.Mutex.RLock()
-}
+	copyTags(labels, t.TestCase.Tags)
+	t.TestCase.Mutex.RUnlock()
 
-func (t *Task) RUnlockAll() {
+	t.TestStep.Mutex.RLock()
+	copyTags(labels, t.TestStep.Tags)
 	t.TestStep.Mutex.RUnlock()
-	t.TestCase.Mutex.RUnlock()
-	t.TestSuite.Mutex.RUnlock()
+
+	// The names do not change once imported
+	labels["testsuite"] = t.TestSuite.Name
+	labels["testcase"] = t.TestCase.Name
+	labels["teststep"] = t.TestStep.Name
+	return labels
 }
 
-func (t *Task) WriteMetadataChanges(lastchg time.Time) {
+func copyTags(labels, tags map[string]string) {
+	for k, v := range tags {
+		labels[k] = v
+	}
+}
 
-	t.LockAll()
-	t.TestStep.LastChange = lastchg
-	t.TestSuite.LastChange = lastchg
-	t.TestCase.LastChange = lastchg
-	t.UnlockAll()
+// UIDs returns the UIDs of the TestSuite, the TestCase and the TestStep of the Task (6, 6-87, 6-87-230).
+func (t *Task) UIDs() []string {
 
+	ts := fmt.Sprintf("%d", t.TestSuite.ID)
+	tc := fmt.Sprintf("%s-%d", ts, t.TestCase.ID)
+	return []string{ts, tc, fmt.Sprintf("%s-%d", tc, t.TestStep.ID)}
 }
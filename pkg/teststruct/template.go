@@ -0,0 +1,212 @@
+package teststruct
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Templates are reusable steps, referenced by name in the extends of a step.
+type Templates map[string]JSONStep
+
+// sharedTemplates are the templates imported from the templates files,
+// available in every TestSuite.
+var sharedTemplates Templates
+
+// SetSharedTemplates sets the templates available in every TestSuite.
+func SetSharedTemplates(tpls Templates) {
+	sharedTemplates = tpls
+}
+
+// StepExtends is the list of templates extended by a step:
+// a single name or a list of names, applied in order.
+type StepExtends []string
+
+func (se *StepExtends) UnmarshalJSON(data []byte) error {
+
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*se = StepExtends{name}
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("extends must be a template name or a list of template names")
+	}
+	*se = names
+	return nil
+}
+
+// withLocal returns the shared templates with the templates of a TestSuite,
+// a TestSuite template overrides a shared template with the same name.
+func (tpls Templates) withLocal(local Templates) Templates {
+
+	if len(local) == 0 {
+		return tpls
+	}
+
+	merged := make(Templates, len(tpls)+len(local))
+	for name, tpl := range tpls {
+		merged[name] = tpl
+	}
+	for name, tpl := range local {
+		merged[name] = tpl
+	}
+	return merged
+}
+
+// resolve returns the step merged with the templates it extends,
+// the values of the step override the ones of the templates.
+func (tpls Templates) resolve(jstp JSONStep) (JSONStep, error) {
+	return tpls.resolveChain(jstp, nil)
+}
+
+func (tpls Templates) resolveChain(jstp JSONStep, chain []string) (JSONStep, error) {
+
+	if len(jstp.Extends) == 0 {
+		return jstp, nil
+	}
+
+	var base JSONStep
+	for _, name := range jstp.Extends {
+
+		for _, parent := range chain {
+			if parent == name {
+				return JSONStep{}, fmt.Errorf("template %q extends itself: %s > %s", name, strings.Join(chain, " > "), name)
+			}
+		}
+
+		tpl, found := tpls[name]
+		if !found {
+			return JSONStep{}, fmt.Errorf("template %q does not exist", name)
+		}
+
+		resolved, err := tpls.resolveChain(tpl, append(chain[:len(chain):len(chain)], name))
+		if err != nil {
+			return JSONStep{}, err
+		}
+		base = mergeStep(base, resolved)
+	}
+
+	return mergeStep(base, jstp), nil
+}
+
+// mergeStep overrides the base step with a step:
+// probe, config and tags are merged, assertions and dependencies are appended.
+// The name is never inherited.
+func mergeStep(base, over JSONStep) JSONStep {
+
+	merged := JSONStep{
+		Name:       over.Name,
+		Probe:      mergeProbe(base.Probe, over.Probe),
+		Assertions: append(append(base.Assertions[:0:0], base.Assertions...), over.Assertions...),
+		Loop:       over.Loop,
+		Render:     over.Render,
+		DependsOn:  append(append(base.DependsOn[:0:0], base.DependsOn...), over.DependsOn...),
+		Tags:       mergeProbe(base.Tags, over.Tags),
+		Config: configTestStructJson{
+			Frequency:        mergeStrMap(base.Config.Frequency, over.Config.Frequency),
+			Concurrency:      mergeIntMap(base.Config.Concurrency, over.Config.Concurrency),
+			Timeout:          mergeStrMap(base.Config.Timeout, over.Config.Timeout),
+			Retry:            mergeIntMap(base.Config.Retry, over.Config.Retry),
+			Retrydelay:       mergeStrMap(base.Config.Retrydelay, over.Config.Retrydelay),
+			Retrybackoff:     mergeFloatMap(base.Config.Retrybackoff, over.Config.Retrybackoff),
+			FailureFrequency: mergeStrMap(base.Config.FailureFrequency, over.Config.FailureFrequency),
+			FailureBackoff:   mergeFloatMap(base.Config.FailureBackoff, over.Config.FailureBackoff),
+			Maintenance:      append(append(base.Config.Maintenance[:0:0], base.Config.Maintenance...), over.Config.Maintenance...),
+			Schedule:         over.Config.Schedule,
+		},
+	}
+
+	if merged.Config.Schedule == nil {
+		merged.Config.Schedule = base.Config.Schedule
+	}
+
+	if len(merged.Loop) == 0 {
+		merged.Loop = base.Loop
+	}
+	if merged.Render == "" {
+		merged.Render = base.Render
+	}
+
+	return merged
+}
+
+// mergeProbe deeply merges two maps into a new one,
+// nested maps are merged and other values are replaced.
+func mergeProbe(base, over map[string]interface{}) map[string]interface{} {
+
+	if base == nil && over == nil {
+		return nil
+	}
+
+	merged := make(map[string]interface{}, len(base)+len(over))
+	for k, v := range base {
+		if sub, isMap := v.(map[string]interface{}); isMap {
+			v = mergeProbe(sub, nil)
+		}
+		merged[k] = v
+	}
+	for k, v := range over {
+		overSub, overIsMap := v.(map[string]interface{})
+		baseSub, baseIsMap := merged[k].(map[string]interface{})
+		if overIsMap && baseIsMap {
+			merged[k] = mergeProbe(baseSub, overSub)
+			continue
+		}
+		if overIsMap {
+			v = mergeProbe(overSub, nil)
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+func mergeStrMap(base, over ProbeConfigJsonRaw) ProbeConfigJsonRaw {
+
+	if base == nil && over == nil {
+		return nil
+	}
+
+	merged := make(ProbeConfigJsonRaw, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		merged[k] = v
+	}
+	return merged
+}
+
+func mergeIntMap(base, over map[string]int) map[string]int {
+
+	if base == nil && over == nil {
+		return nil
+	}
+
+	merged := make(map[string]int, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		merged[k] = v
+	}
+	return merged
+}
+
+func mergeFloatMap(base, over map[string]float64) map[string]float64 {
+
+	if base == nil && over == nil {
+		return nil
+	}
+
+	merged := make(map[string]float64, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,117 @@
+package teststruct
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/vincoll/vigie/pkg/assertion"
+)
+
+func TestTemplates_resolve(t *testing.T) {
+
+	tpls := Templates{
+		"https": {
+			Config: configTestStructJson{Timeout: ProbeConfigJsonRaw{"http": "5s"}},
+			Probe: map[string]interface{}{
+				"type":    "http",
+				"method":  "GET",
+				"headers": map[string]interface{}{"Accept": "application/json", "User-Agent": "vigie"},
+			},
+			Assertions: []assertion.RawAssert{{Assertion: "httpcode == 200"}},
+			Tags:       map[string]interface{}{"team": "web"},
+		},
+		"api": {
+			Extends:    StepExtends{"https"},
+			Probe:      map[string]interface{}{"headers": map[string]interface{}{"Authorization": "Bearer x"}},
+			Assertions: []assertion.RawAssert{{Assertion: "probeinfo.responsetime < 1s"}},
+		},
+		"loop_a": {Extends: StepExtends{"loop_b"}},
+		"loop_b": {Extends: StepExtends{"loop_a"}},
+	}
+
+	tests := []struct {
+		name    string
+		step    JSONStep
+		want    JSONStep
+		wantErr bool
+	}{
+		{
+			name: "no_extends",
+			step: JSONStep{Name: "raw", Probe: map[string]interface{}{"type": "http"}},
+			want: JSONStep{Name: "raw", Probe: map[string]interface{}{"type": "http"}},
+		},
+		{
+			name: "override",
+			step: JSONStep{
+				Name:       "status",
+				Extends:    StepExtends{"api"},
+				Config:     configTestStructJson{Frequency: ProbeConfigJsonRaw{"http": "1m"}},
+				Probe:      map[string]interface{}{"url": "https://a.com/status", "headers": map[string]interface{}{"User-Agent": "curl"}},
+				Assertions: []assertion.RawAssert{{Assertion: "body == \"ok\""}},
+				Tags:       map[string]interface{}{"team": "api"},
+			},
+			want: JSONStep{
+				Name:   "status",
+				Config: configTestStructJson{Frequency: ProbeConfigJsonRaw{"http": "1m"}, Timeout: ProbeConfigJsonRaw{"http": "5s"}},
+				Probe: map[string]interface{}{
+					"type":    "http",
+					"method":  "GET",
+					"url":     "https://a.com/status",
+					"headers": map[string]interface{}{"Accept": "application/json", "User-Agent": "curl", "Authorization": "Bearer x"},
+				},
+				Assertions: []assertion.RawAssert{
+					{Assertion: "httpcode == 200"},
+					{Assertion: "probeinfo.responsetime < 1s"},
+					{Assertion: "body == \"ok\""},
+				},
+				Tags: map[string]interface{}{"team": "api"},
+			},
+		},
+		{name: "missing", step: JSONStep{Extends: StepExtends{"nope"}}, wantErr: true},
+		{name: "cycle", step: JSONStep{Extends: StepExtends{"loop_a"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tpls.resolve(tt.step)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolve() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// The templates must not be modified by a step
+	headers := tpls["https"].Probe["headers"].(map[string]interface{})
+	if len(headers) != 2 || headers["User-Agent"] != "vigie" {
+		t.Errorf("resolve() has modified the template: %v", headers)
+	}
+}
+
+func TestStepExtends_UnmarshalJSON(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		data    string
+		want    StepExtends
+		wantErr bool
+	}{
+		{name: "string", data: `"https"`, want: StepExtends{"https"}},
+		{name: "list", data: `["https", "api"]`, want: StepExtends{"https", "api"}},
+		{name: "invalid", data: `{"https": true}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StepExtends
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalJSON() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
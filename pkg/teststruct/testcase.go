@@ -3,6 +3,7 @@ package teststruct
 import (
 	"fmt"
 	"github.com/mitchellh/hashstructure"
+	"github.com/vincoll/vigie/pkg/render"
 	"sync"
 	"time"
 )
@@ -12,6 +13,7 @@ type JSONTestCase struct {
 	Config    configTestStructJson   `json:"config"`
 	Loop      []string               `json:"loop"`
 	JsonSteps []JSONStep             `json:"steps"`
+	DependsOn DependsOn              `json:"depends_on"` // Dependencies of all the steps
 	Tags      map[string]interface{} `json:"tags"`
 }
 
@@ -55,13 +57,50 @@ func importConfig(cfgTS *configTestStruct, cfgTC configTestStruct) configTestStr
 			cfgTC.Retry[k] = v
 		}
 	}
+	if cfgTC.Concurrency == nil {
+		cfgTC.Concurrency = map[string]int{}
+	}
+	for k, v := range cfgTS.Concurrency {
+		if _, present := cfgTC.Concurrency[k]; !present {
+			cfgTC.Concurrency[k] = v
+		}
+	}
+	if cfgTC.Retrybackoff == nil {
+		cfgTC.Retrybackoff = map[string]float64{}
+	}
+	for k, v := range cfgTS.Retrybackoff {
+		if _, present := cfgTC.Retrybackoff[k]; !present {
+			cfgTC.Retrybackoff[k] = v
+		}
+	}
+	if cfgTC.FailureFrequency == nil {
+		cfgTC.FailureFrequency = map[string]time.Duration{}
+	}
+	for k, v := range cfgTS.FailureFrequency {
+		if _, present := cfgTC.FailureFrequency[k]; !present {
+			cfgTC.FailureFrequency[k] = v
+		}
+	}
+	if cfgTC.FailureBackoff == nil {
+		cfgTC.FailureBackoff = map[string]float64{}
+	}
+	for k, v := range cfgTS.FailureBackoff {
+		if _, present := cfgTC.FailureBackoff[k]; !present {
+			cfgTC.FailureBackoff[k] = v
+		}
+	}
+	if cfgTC.Schedule == nil {
+		cfgTC.Schedule = cfgTS.Schedule
+	}
+	// Maintenance windows add up
+	cfgTC.Maintenance = append(cfgTC.Maintenance[:len(cfgTC.Maintenance):len(cfgTC.Maintenance)], cfgTS.Maintenance...)
 
 	return cfgTC
 
 }
 
 // https://blog.gopheracademy.com/advent-2016/advanced-encoding-decoding/
-func (jtc JSONTestCase) toTestCase(ctsTS *configTestStruct, tsVars map[string][]string) (TestCase, error) {
+func (jtc JSONTestCase) toTestCase(ctsTS *configTestStruct, tsVars render.Vars, templates Templates) (TestCase, error) {
 
 	var tc TestCase
 
@@ -83,15 +122,23 @@ func (jtc JSONTestCase) toTestCase(ctsTS *configTestStruct, tsVars map[string][]
 	}
 
 	ctcTC = importConfig(ctsTS, ctcTC)
+	ctcTC.DependsOn = jtc.DependsOn
 
 	// Add TestSteps
 	tc.TestSteps = make(map[uint64]*TestStep, len(jtc.JsonSteps))
 
-	for _, jStp := range jtc.JsonSteps {
+	for i, jStp := range jtc.JsonSteps {
+
+		// Resolve the templates before the hash of the TestStep
+		rStp, err := templates.resolve(jStp)
+		if err != nil {
+			return TestCase{}, fmt.Errorf("%s : Step is invalid: %w", tc.Name, atPath(err, "steps", i))
+		}
 
-		teststeps, err := jStp.toTestStep(&ctcTC, tsVars)
+		teststeps, err := rStp.toTestStep(&ctcTC, tsVars)
 		if err != nil {
-			return TestCase{}, fmt.Errorf("%s : Step is invalid: %s", tc.Name, err)
+			err = assertionPath(err, len(rStp.Assertions)-len(jStp.Assertions))
+			return TestCase{}, fmt.Errorf("%s : Step is invalid: %w", tc.Name, atPath(err, "steps", i))
 		}
 
 		tc.addAllTestSteps(teststeps)
@@ -163,17 +210,6 @@ func (tc *TestCase) SetStatus(newStatus StepStatus) {
 	tc.Mutex.Unlock()
 }
 
-// Returns this Testcase without Teststeps
-func (tc *TestCase) WithoutTStep() *TestCase {
-
-	tcBis := *tc
-	// Reset TestStep
-	tcBis.Mutex = sync.RWMutex{}
-	tcBis.TestSteps = make(map[uint64]*TestStep, 1)
-	return &tcBis
-
-}
-
 // ImportTestSteps will add new TSteps to an empty or already populated TestCase,
 // Import Rules : remove oldTSteps that are absent from the new TSteps, keep common TSteps, add new ones
 func (tc *TestCase) _ImportTestSteps(newTSteps map[uint64]*TestStep) {
@@ -209,7 +245,8 @@ func (tc *TestCase) _ImportTestSteps(newTSteps map[uint64]*TestStep) {
 }
 
 // ImportTestSteps will add new TSteps to an empty or already populated TestCase,
-// Import Rules : remove oldTSteps that are absent from the new TSteps, keep common TSteps, add new ones
+// Import Rules : remove oldTSteps that are absent from the new TSteps, keep common TSteps, add new ones.
+// tc is the old TestCase: newTSteps gets its unchanged TestSteps.
 func (tc *TestCase) ImportTestSteps(newTSteps map[uint64]*TestStep) {
 
 	// newTSteps is considered as the new base state,
@@ -223,5 +260,7 @@ func (tc *TestCase) ImportTestSteps(newTSteps map[uint64]*TestStep) {
 
 		}
 	}
+	tc.Mutex.Lock()
 	tc.TestSteps = newTSteps
+	tc.Mutex.Unlock()
 }
@@ -3,6 +3,8 @@ package teststruct
 import (
 	"bytes"
 	"encoding/json"
+
+	"github.com/vincoll/vigie/pkg/secret"
 )
 
 type TCHeader struct {
@@ -44,7 +46,7 @@ func (tc *TestCase) ToConsul() []byte {
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(cTS)
 
-	return reqBodyBytes.Bytes()
+	return secret.RedactBytes(reqBodyBytes.Bytes())
 
 }
 
@@ -68,6 +70,7 @@ func (tc *TestCase) ToJSON() TCDescribe {
 	var TCDesc TCDescribe
 	TCDesc.TestSteps = make([]TStepDescribe, 0)
 	tc.Mutex.RLock()
+	TCDesc.ID = tc.ID
 	TCDesc.Name = tc.Name
 	TCDesc.Status = tc.Status.String()
 
@@ -82,11 +85,7 @@ func (tc *TestCase) ToJSON() TCDescribe {
 
 func (tc *TestCase) ToAlertShortTC() TCAlertShort {
 
-	htc := TCHeader{
-		ID:     tc.ID,
-		Name:   tc.Name,
-		Status: tc.Status.String(),
-	}
+	htc := tc.ToHeader()
 
 	AsTC := TCAlertShort{
 		TCHeader:  htc,
@@ -11,17 +11,21 @@ func (tc *TestCase) countTestStep() (totalTStep int) {
 // UpdateStatus change and return the TC ResultStatus
 // Loop on each TSteps
 // If one of the TSteps is KO => Set TC status to False
+// If one of the TSteps is in Warning => Set TC status to Warning (still true)
 func (tc *TestCase) UpdateStatus() bool {
 	tc.Mutex.Lock()
 
+	status := Success
 	for _, tStep := range tc.TestSteps {
 
 		switch tStep.GetStatus() {
 
 		case Success:
 			// Pass
-		case NotDefined:
-			// Neutral => Treated as Success for now
+		case NotDefined, Paused, DependencyFailed:
+			// Neutral => Treated as Success for now, the root cause of a DependencyFailed fails its own TestCase
+		case Warning:
+			status = Warning
 		default:
 			tc.Status = Failure
 			tc.Mutex.Unlock()
@@ -29,8 +33,8 @@ func (tc *TestCase) UpdateStatus() bool {
 
 		}
 	}
-	// If all TSteps == Success
-	tc.Status = Success
+	// If all TSteps == Success (or Warning)
+	tc.Status = status
 	tc.Mutex.Unlock()
 	return true
 }
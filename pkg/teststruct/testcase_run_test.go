@@ -0,0 +1,40 @@
+package teststruct
+
+import (
+	"testing"
+)
+
+func TestTestCase_UpdateStatus(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		steps     []StepStatus
+		want      bool
+		wantTC    StepStatus
+		wantSuite StepStatus
+	}{
+		{name: "success", steps: []StepStatus{Success, Success}, want: true, wantTC: Success, wantSuite: Success},
+		{name: "warning", steps: []StepStatus{Success, Warning}, want: true, wantTC: Warning, wantSuite: Warning},
+		{name: "neutral", steps: []StepStatus{NotDefined, Paused}, want: true, wantTC: Success, wantSuite: Success},
+		{name: "dependency failed only", steps: []StepStatus{DependencyFailed, Success}, want: true, wantTC: Success, wantSuite: Success},
+		{name: "root cause", steps: []StepStatus{DependencyFailed, AssertFailure}, want: false, wantTC: Failure, wantSuite: Failure},
+		{name: "timeout", steps: []StepStatus{Timeout}, want: false, wantTC: Failure, wantSuite: Failure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &TestCase{TestSteps: map[uint64]*TestStep{}}
+			for i, status := range tt.steps {
+				tc.TestSteps[uint64(i)] = &TestStep{Status: status}
+			}
+			ts := &TestSuite{TestCases: map[uint64]*TestCase{1: tc}}
+
+			if got := tc.UpdateStatus(); got != tt.want {
+				t.Errorf("UpdateStatus() = %v, want %v", got, tt.want)
+			}
+			ts.UpdateStatus()
+			if tc.Status != tt.wantTC || ts.Status != tt.wantSuite {
+				t.Errorf("TestCase status = %s, TestSuite status = %s, want %s and %s", tc.Status, ts.Status, tt.wantTC, tt.wantSuite)
+			}
+		})
+	}
+}
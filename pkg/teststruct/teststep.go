@@ -1,17 +1,19 @@
 package teststruct
 
 import (
+	"errors"
 	"fmt"
 	"github.com/mitchellh/hashstructure"
-	"reflect"
-	"regexp"
 	"sync"
 	"time"
 
-	"github.com/vincoll/vigie/pkg/probe/probetable"
+	// Built-in probes
+	_ "github.com/vincoll/vigie/pkg/probe/probetable"
 
 	"github.com/vincoll/vigie/pkg/assertion"
+	"github.com/vincoll/vigie/pkg/maintenance"
 	"github.com/vincoll/vigie/pkg/probe"
+	"github.com/vincoll/vigie/pkg/render"
 )
 
 // Step represents a Step
@@ -19,11 +21,15 @@ type Step map[string]interface{}
 
 type JSONStep struct {
 	Name       string                 `json:"name"`
+	Extends    StepExtends            `json:"extends"` // Templates, resolved before toTestStep
 	Config     configTestStructJson   `json:"config"`
 	Probe      map[string]interface{} `json:"probe"`
-	Assertions []string               `json:"assertions"`
-	Loop       []string               `json:"loop"`
+	Assertions []assertion.RawAssert  `json:"assertions"`
+	Loop       StepLoop               `json:"loop"`
 	Tags       map[string]interface{} `json:"tags"`
+	Render     string                 `json:"render"` // When the probe templates are rendered: import (default) or run
+	DependsOn  DependsOn              `json:"depends_on"`
+	secrets    [][]string             // Paths of the probe fields holding a secret, set by render
 }
 
 // TestStep est constitué d'une Step ainsi que d'autres objets permettant l'exec et la traçabilité
@@ -41,6 +47,14 @@ type TestStep struct {
 	LastPositiveVigieResults *[]TestResult `hash:"ignore"`
 	Status                   StepStatus    `hash:"ignore"`
 	Tags                     map[string]string
+	Maintenance              []maintenance.Window // Maintenance windows of the step and its parents
+	InMaintenance            string               `hash:"ignore"` // Window or silence muting the step, empty if none
+	DependsOn                DependsOn            // Dependencies of the step and its TestCase
+	RootCause                string               `hash:"ignore"` // Failing dependency if DependencyFailed
+	Paused                   string               `hash:"ignore"` // UID of the pause stopping the step, empty if none
+	Attempts                 []Attempt            `hash:"ignore"` // Attempts of the last run
+	Recovered                bool                 `hash:"ignore"` // Last run succeeded after a retry
+	Schedule                 *Schedule            // Cron and active hours, nil to run at the frequency
 }
 
 // TestStepComparaison is use to compare a teststep, it only contains
@@ -51,10 +65,16 @@ type TestStepComparaison struct {
 	ProbeWrap  ProbeWrap
 }
 
-func (jstp JSONStep) toTestStep(ctsTC *configTestStruct, tsVars map[string][]string) ([]TestStep, error) {
+func (jstp JSONStep) toTestStep(ctsTC *configTestStruct, tsVars render.Vars) ([]TestStep, error) {
 
 	testSteps := make([]TestStep, 0)
 
+	// Templates ({{ .var }}) are rendered now, except the probe of a step rendered at each run
+	jstp, err := jstp.render(tsVars)
+	if err != nil {
+		return []TestStep{}, err
+	}
+
 	// Config
 	// UnMarshall spécifique de la config avec conversion de strings type (1d,7m) en time.duration
 	ctsStep, err := unmarshallConfigTestStruct(jstp.Config)
@@ -62,26 +82,53 @@ func (jstp JSONStep) toTestStep(ctsTC *configTestStruct, tsVars map[string][]str
 		return []TestStep{}, fmt.Errorf("config declaration: %s", err)
 	}
 
-	// Assertions
-	assertions, errAsrt := assertion.GetCleanAsserts(jstp.Assertions)
-	if errAsrt != nil {
-		return []TestStep{}, fmt.Errorf("Invalid step assertion %s :", errAsrt)
+	wrap := func(stepProbe map[string]interface{}) (ProbeWrap, error) {
+		pw, err := wrapProbe(stepProbe)
+		pw.Secrets = jstp.secrets
+		return pw, err
+	}
+	if jstp.Render == renderAtRun {
+		wrap = func(stepProbe map[string]interface{}) (ProbeWrap, error) {
+			return wrapTemplateProbe(stepProbe, tsVars)
+		}
 	}
 
 	// Replace Var if present in the probe section
-	probeWraps, errVars := loop(jstp.Probe, jstp.Loop, tsVars)
+	loopedProbes, errVars := loop(jstp.Probe, jstp.Loop, tsVars, wrap)
 
 	if errVars != nil {
 		return []TestStep{}, fmt.Errorf("Invalid Loop in step: %s :", errVars)
 	}
 
-	for _, pw := range probeWraps {
+	// A loop over an empty list of values gives no probe
+	if len(loopedProbes) == 0 {
+		return []TestStep{}, fmt.Errorf("Invalid Loop in step: no probe to run")
+	}
+
+	// Assertions
+	// Validated against the answer of the probe (keys, types)
+	assertions, errAsrt := assertion.GetCleanAsserts(jstp.Assertions, loopedProbes[0].ProbeWrap.Probe.AnswerSchema())
+	if errAsrt != nil {
+		// Named by its step: the same assertion is often shared by several steps
+		name := jstp.Name
+		if name == "" {
+			name = loopedProbes[0].ProbeWrap.Probe.GenerateTStepName()
+		}
+		return []TestStep{}, fmt.Errorf("step %q: invalid assertion: %w", name, errAsrt)
+	}
+
+	for _, lp := range loopedProbes {
 
 		var tstep TestStep
 
 		tstep.Tags, _ = mapStrInterfaceToStrStr(jstp.Tags)
+		// Coordinates of the step in the loop
+		for _, c := range lp.Coords {
+			tstep.Tags[c.tag()] = c.Value
+		}
 
-		tstep.ProbeWrap = pw
+		tstep.ProbeWrap = lp.ProbeWrap
+		tstep.DependsOn = append(tstep.DependsOn, jstp.DependsOn...)
 		// A Slice is composed with pointers.
 		// Can't share the same assertion object.
 		// Copy is mandatory, assertion struct will be modified later.
@@ -90,12 +137,18 @@ func (jstp JSONStep) toTestStep(ctsTC *configTestStruct, tsVars map[string][]str
 		tstep.Assertions = copyAssert
 
 		// Name
-		if jstp.Name == "" {
+		switch {
+		case lp.isMatrix() && jstp.Name == "":
+			// Generate a unique name for each combination of the loop
+			tstep.Name = fmt.Sprintf("%s (%s)", tstep.ProbeWrap.Probe.GenerateTStepName(), lp.coordsName())
 
-			tstep.Name = tstep.ProbeWrap.Probe.GenerateTStepName()
+		case lp.isMatrix():
+			tstep.Name = fmt.Sprintf("%s (%s)", jstp.Name, lp.coordsName())
 
-		} else {
+		case jstp.Name == "":
+			tstep.Name = tstep.ProbeWrap.Probe.GenerateTStepName()
 
+		default:
 			if jstp.Loop != nil {
 				// Generate a unique name for each looped variables
 				genTstpName := tstep.ProbeWrap.Probe.GenerateTStepName()
@@ -139,74 +192,6 @@ func (jstp JSONStep) toTestStep(ctsTC *configTestStruct, tsVars map[string][]str
 
 }
 
-func loop(stepProbe map[string]interface{}, loop []string, vars map[string][]string) (pws []ProbeWrap, err error) {
-
-	if len(loop) == 0 {
-		pw, err := wrapProbe(stepProbe)
-		if err != nil {
-			return []ProbeWrap{}, fmt.Errorf("%s", err)
-		}
-		pws = append(pws, pw)
-		return pws, nil
-	}
-	var findItem bool
-	var r = regexp.MustCompile(`(\B\$)(.+)`)
-	for k, v := range stepProbe {
-		// Detect if TestSuites value (interface) is a string or not
-		//	if strv, ok := v.(string); ok {
-		// If TestSuites value is matching {{_}} regex (ex: {{varX}} )
-		strv := fmt.Sprintf("%v", v)
-		if strv == "$item" {
-			findItem = true
-			for _, itm := range loop {
-				// if variable pattern $*
-				if r.MatchString(itm) {
-					x := r.FindStringSubmatch(itm)
-					y := x[len(x)-1] // y = foo from $foo
-					// Looking for if foo exists in vigie's vars
-					if valvigie, present := vars[y]; present {
-						// For each oh them create/add a new ProbeWrap
-						for _, val := range valvigie {
-							stepProbe[k] = val
-							pw, err := wrapProbe(stepProbe)
-							if err != nil {
-								return []ProbeWrap{}, fmt.Errorf("%s", err)
-							}
-							pws = append(pws, pw)
-
-						}
-					} else {
-						// Vars pattern find, but not the vars does not exists.
-						return []ProbeWrap{}, fmt.Errorf("vars does not exists %q", y)
-					}
-				} else {
-					stepProbe[k] = itm
-					pw, err := wrapProbe(stepProbe)
-					if err != nil {
-						return []ProbeWrap{}, fmt.Errorf("%s", err)
-					}
-					pws = append(pws, pw)
-				}
-			} // for val in Loop variable
-
-		} // if $item
-
-	} // Loop looking for $item
-	if findItem == false {
-		// No $item found despite the len(loop) > 0
-		// return nil, fmt.Errorf("No var $item found, despite len(loop) >= 1 TODO") //TODO
-		// Simply add without edit
-		pw, err := wrapProbe(stepProbe)
-		if err != nil {
-			return []ProbeWrap{}, fmt.Errorf("%s", err)
-		}
-		pws = append(pws, pw)
-	}
-
-	return pws, nil
-
-}
-
 // probeType returns the name of the executor which is set to run this TestStep
 // Is simply a shortcut for tStep.ProbeWrap.Probe.GetName()
 func (tStep *TestStep) probeType() string {
@@ -225,6 +210,17 @@ func (tStep *TestStep) ResponseTimeInflux() float64 {
 
 }
 
+// assertionPath locates an invalid assertion among the ones written in the step:
+// the inherited ones of its templates come first, they are located by the step.
+func assertionPath(err error, inherited int) error {
+
+	var asrtErr *assertion.AssertionError
+	if errors.As(err, &asrtErr) && asrtErr.Index >= inherited {
+		return atPath(err, "assertions", asrtErr.Index-inherited)
+	}
+	return err
+}
+
 // wrapProbe initializes a test by name
 func wrapProbe(stepProbe map[string]interface{}) (pw ProbeWrap, err error) {
 
@@ -241,35 +237,23 @@ func wrapProbe(stepProbe map[string]interface{}) (pw ProbeWrap, err error) {
 	// xxx := utils.MapInterfacetoString(stepProbe)
 	// ---------------
 
-	// Check if Exec Exists & Create Probe Object
-	if e, ok := probetable.AvailableProbes[probeType]; ok {
-
-		// TODO : Clean this mess : Avoid reflection
-		//https://groups.google.com/forum/#!topic/golang-nuts/1hWgUhXBBTU
-		// http://play.golang.org/p/xInaAl3rkE
-		var xProbe probe.Probe
-		indirect := reflect.Indirect(reflect.ValueOf(e))
-		newIndirect := reflect.New(indirect.Type())
-		newIndirect.Elem().Set(reflect.ValueOf(indirect.Interface()))
-		newNamed := newIndirect.Interface()
-		casted := newNamed.(probe.Probe)
-		xProbe = casted
-
-		// Initialize TStep values against the own Probe needs
-		// Initialize is different for each probe
-		// Apply Step values to the Probe
-		err = xProbe.Initialize(stepProbe)
-		if err != nil {
-			return pw, fmt.Errorf("probe %q can't import this step: %s", xProbe.GetName(), err)
-		}
+	// Create a new Probe from the registered probes
+	xProbe, err := probe.New(probeType)
+	if err != nil {
+		return pw, err
+	}
 
-		pw = ProbeWrap{Probe: xProbe}
+	// Initialize TStep values against the own Probe needs
+	// Initialize is different for each probe
+	// Apply Step values to the Probe
+	err = xProbe.Initialize(stepProbe)
+	if err != nil {
+		return pw, fmt.Errorf("probe %q can't import this step: %s", xProbe.GetName(), err)
+	}
 
-		return pw, nil
+	pw = ProbeWrap{Probe: xProbe}
 
-	} else {
-		return pw, fmt.Errorf("probe type %q is not implemented in Vigie", probeType)
-	}
+	return pw, nil
 }
 
 // importConfig replace config from a cfg only if non-present in the ProbeWrap
@@ -289,6 +273,29 @@ func (tStep *TestStep) importConfig(cfg *configTestStruct) error {
 	if tStep.ProbeWrap.Timeout == 0 {
 		tStep.ProbeWrap.Timeout = cfg.Timeout[tStep.probeType()]
 	}
+
+	if tStep.ProbeWrap.Retrybackoff == 0 {
+		tStep.ProbeWrap.Retrybackoff = cfg.Retrybackoff[tStep.probeType()]
+	}
+
+	if tStep.ProbeWrap.Concurrency == 0 {
+		tStep.ProbeWrap.Concurrency = cfg.Concurrency[tStep.probeType()]
+	}
+
+	if tStep.ProbeWrap.FailureFrequency == 0 {
+		tStep.ProbeWrap.FailureFrequency = cfg.FailureFrequency[tStep.probeType()]
+	}
+
+	if tStep.ProbeWrap.FailureBackoff == 0 {
+		tStep.ProbeWrap.FailureBackoff = cfg.FailureBackoff[tStep.probeType()]
+	}
+
+	if tStep.Schedule == nil {
+		tStep.Schedule = cfg.Schedule
+	}
+
+	tStep.Maintenance = append(tStep.Maintenance, cfg.Maintenance...)
+	tStep.DependsOn = append(tStep.DependsOn, cfg.DependsOn...)
 	return nil
 
 }
@@ -310,5 +317,80 @@ func (tStep *TestStep) validateWrapProbe() error {
 		tStep.ProbeWrap.Timeout = tStep.ProbeWrap.Probe.GetDefaultTimeout()
 	}
 
+	if tStep.ProbeWrap.Retry < 0 {
+		return fmt.Errorf("retry MUST be >= 0")
+	}
+
+	// Same delay between each retry by default
+	if tStep.ProbeWrap.Retrybackoff == 0 {
+		tStep.ProbeWrap.Retrybackoff = 1
+	}
+
+	// A failure frequency only applies if it is faster than the frequency
+	if tStep.ProbeWrap.FailureFrequency >= tStep.ProbeWrap.Frequency {
+		tStep.ProbeWrap.FailureFrequency = 0
+	}
+	if tStep.ProbeWrap.FailureBackoff == 0 {
+		tStep.ProbeWrap.FailureBackoff = 1
+	}
+
 	return nil
 }
+
+// When the probe templates of a step are rendered
+const (
+	renderAtImport = "import"
+	renderAtRun    = "run"
+)
+
+// render renders the templates ({{ .var }}) of the step with the variables.
+// The probe of a step rendered at each run is kept as is.
+func (jstp JSONStep) render(vars render.Vars) (JSONStep, error) {
+
+	switch jstp.Render {
+	case "", renderAtImport, renderAtRun:
+	default:
+		return JSONStep{}, fmt.Errorf("render must be %q or %q, not %q", renderAtImport, renderAtRun, jstp.Render)
+	}
+
+	var err error
+	if jstp.Name, err = render.String(jstp.Name, vars); err != nil {
+		return JSONStep{}, fmt.Errorf("name: %s", err)
+	}
+
+	if jstp.Render != renderAtRun {
+		if jstp.Probe, jstp.secrets, err = render.MapSecrets(jstp.Probe, vars); err != nil {
+			return JSONStep{}, fmt.Errorf("probe: %s", err)
+		}
+	}
+
+	assertions := make([]assertion.RawAssert, 0, len(jstp.Assertions))
+	for _, ra := range jstp.Assertions {
+		if ra.Assertion, err = render.String(ra.Assertion, vars); err != nil {
+			return JSONStep{}, fmt.Errorf("assertion: %s", err)
+		}
+		assertions = append(assertions, ra)
+	}
+	jstp.Assertions = assertions
+
+	if jstp.Tags, err = render.Map(jstp.Tags, vars); err != nil {
+		return JSONStep{}, fmt.Errorf("tags: %s", err)
+	}
+
+	return jstp, nil
+}
+
+// wrapTemplateProbe initializes a probe rendered at each run,
+// it is rendered once now to validate it.
+func wrapTemplateProbe(stepProbe map[string]interface{}, vars render.Vars) (ProbeWrap, error) {
+
+	// The loop modifies stepProbe: the template keeps a copy
+	tpl := &ProbeTemplate{Probe: mergeProbe(stepProbe, nil), Vars: vars}
+
+	pw, err := tpl.wrap()
+	if err != nil {
+		return ProbeWrap{}, err
+	}
+	pw.Template = tpl
+	return pw, nil
+}
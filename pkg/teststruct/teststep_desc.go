@@ -3,8 +3,10 @@ package teststruct
 import (
 	"bytes"
 	"encoding/json"
-	"github.com/vincoll/vigie/pkg/probe"
+	"fmt"
+	"github.com/vincoll/vigie/pkg/secret"
 	"github.com/vincoll/vigie/pkg/utils/timeutils"
+	"strings"
 	"time"
 )
 
@@ -16,14 +18,20 @@ type StepResultDescribe struct {
 	VigieResults             []TestResult  `json:"vigieresults"`
 	Details                  []string      `json:"details"`
 	LastChange               time.Time     `json:"lastchange"`
+	Attempts                 []Attempt     `json:"attempts"`
+	Recovered                bool          `json:"recovered"` // Success after a retry
 }
 
 type TStepDescribe struct {
 	Name      string             `json:"name"`
-	StepProbe probe.Probe        `json:"probe"`
+	StepProbe interface{}        `json:"probe"`
 	StepD     StepParam          `json:"parameters"`
 	StepResD  StepResultDescribe `json:"result"`
 	StepAss   []string           `json:"assertions"`
+	StepMaint string             `json:"maintenance,omitempty"` // Window or silence muting the step
+	StepRoot  string             `json:"rootcause,omitempty"`   // Failing dependency of a skipped step
+	StepPause string             `json:"paused,omitempty"`      // UID of the pause stopping the step
+	StepNext  time.Time          `json:"nextrun"`               // Next run, zero if none
 }
 
 type TStepConsul struct {
@@ -38,8 +46,9 @@ type StepAssertionDescribe struct {
 type TStepAlertShort struct {
 	Name    string   `json:"name"`
 	ID      uint64   `json:"id"`
-	Status  string   `json:"status"`  // Status de la teststep
-	Details []string `json:"details"` // Liste des messages result Assertions
+	Status  string   `json:"status"`            // Status de la teststep
+	Details []string `json:"details"`           // Liste des messages result Assertions
+	Skipped []string `json:"skipped,omitempty"` // TestSteps skipped because of this one (root cause)
 }
 
 func (tStep *TestStep) ToConsul() []byte {
@@ -56,7 +65,7 @@ func (tStep *TestStep) ToConsul() []byte {
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(cTS)
 
-	return reqBodyBytes.Bytes()
+	return secret.RedactBytes(reqBodyBytes.Bytes())
 
 }
 
@@ -66,10 +75,17 @@ func (tStep *TestStep) ToTestStepDescribe() TStepDescribe {
 	tStep.Mutex.RLock()
 
 	TDesc := StepParam{
-		Frequency:  timeutils.FormatDuration(tStep.ProbeWrap.Frequency),
-		Retry:      tStep.ProbeWrap.Retry,
-		Retrydelay: timeutils.FormatDuration(tStep.ProbeWrap.Retrydelay),
-		Timeout:    timeutils.FormatDuration(tStep.ProbeWrap.Timeout),
+		Frequency:        timeutils.FormatDuration(tStep.ProbeWrap.Frequency),
+		Retry:            tStep.ProbeWrap.Retry,
+		Retrydelay:       timeutils.FormatDuration(tStep.ProbeWrap.Retrydelay),
+		Retrybackoff:     tStep.ProbeWrap.Retrybackoff,
+		Concurrency:      tStep.ProbeWrap.Concurrency,
+		FailureFrequency: exportFailureFrequency(tStep.ProbeWrap.FailureFrequency),
+		FailureBackoff:   tStep.ProbeWrap.FailureBackoff,
+		Timeout:          timeutils.FormatDuration(tStep.ProbeWrap.Timeout),
+		Maintenance:      tStep.Maintenance,
+		DependsOn:        tStep.DependsOn,
+		Schedule:         tStep.Schedule,
 	}
 
 	// Add Assertion full text
@@ -82,22 +98,28 @@ func (tStep *TestStep) ToTestStepDescribe() TStepDescribe {
 		StatusStr:                tStep.Status.String(),
 		LastAttempt:              tStep.LastAttempt,
 		LastPositiveTimeResult:   tStep.LastPositiveTimeResult,
-		LastPositiveVigieResults: tStep.GetLastPositiveResult(),
+		LastPositiveVigieResults: tStep.LastPositiveVigieResults,
 		VigieResults:             tStep.VigieResults,
 		Details:                  tStep.Failures,
 		LastChange:               tStep.LastChange,
+		Attempts:                 tStep.Attempts,
+		Recovered:                tStep.Recovered,
 	}
 
 	desc := TStepDescribe{
 		Name:      tStep.Name,
-		StepProbe: tStep.ProbeWrap.Probe,
+		StepProbe: redactedProbe(tStep.ProbeWrap.Probe, tStep.ProbeWrap.Secrets),
 		StepD:     TDesc,
 		StepResD:  TResDesc,
 		StepAss:   assrts,
+		StepMaint: tStep.InMaintenance,
+		StepRoot:  tStep.RootCause,
+		StepPause: tStep.Paused,
 	}
 
 	tStep.Mutex.RUnlock()
 
+	desc.StepNext = tStep.NextRun(time.Now())
 	return desc
 
 }
@@ -114,11 +136,11 @@ func (tStep *TestStep) ToStepAlertShort() TStepAlertShort {
 
 	switch tStep.Status {
 
-	case AssertFailure:
+	case AssertFailure, Warning:
 		d := make([]string, 0, len(tStep.Assertions))
 
 		for _, vr := range tStep.VigieResults {
-			if vr.Status == AssertFailure {
+			if vr.Status == AssertFailure || vr.Status == Warning {
 				for _, assertRes := range vr.AssertionResult {
 					if assertRes.ResultStatus != 1 {
 						d = append(d, assertRes.ResultAssert)
@@ -137,5 +159,11 @@ func (tStep *TestStep) ToStepAlertShort() TStepAlertShort {
 	}
 
 	tStep.Mutex.RUnlock()
+
+	// Root cause: the dependent steps are not alerted, but summarized here
+	if stepRecap.Skipped = tStep.dependents(); len(stepRecap.Skipped) > 0 {
+		d := stepRecap.Details
+		stepRecap.Details = append(d[:len(d):len(d)], fmt.Sprintf("root cause of %d skipped steps: %s", len(stepRecap.Skipped), strings.Join(stepRecap.Skipped, ", ")))
+	}
 	return stepRecap
 }
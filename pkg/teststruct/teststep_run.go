@@ -1,9 +1,11 @@
 package teststruct
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"github.com/vincoll/vigie/pkg/assertion"
+	"github.com/vincoll/vigie/pkg/maintenance"
 	"github.com/vincoll/vigie/pkg/utils"
 	"time"
 
@@ -11,10 +13,17 @@ import (
 )
 
 type StepParam struct {
-	Frequency  string `json:"frequency"`  // time interval between two test
-	Retry      int    `json:"retry"`      // nb retry a test case if it is in failure.
-	Retrydelay string `json:"retrydelay"` // delay between two retries
-	Timeout    string `json:"timeout"`    // timeout on executor
+	Frequency        string               `json:"frequency"`                   // time interval between two test
+	Retry            int                  `json:"retry"`                       // nb retry a test case if it is in failure.
+	Retrydelay       string               `json:"retrydelay"`                  // delay between two retries
+	Retrybackoff     float64              `json:"retrybackoff"`                // multiplier of the delay after each retry
+	Concurrency      int                  `json:"concurrency,omitempty"`       // max steps of this probe type running at once
+	FailureFrequency string               `json:"failure_frequency,omitempty"` // time interval between two tests while failing
+	FailureBackoff   float64              `json:"failure_backoff,omitempty"`   // multiplier of the failure interval after each run
+	Timeout          string               `json:"timeout"`                     // timeout on executor
+	Maintenance      []maintenance.Window `json:"maintenance,omitempty"`       // maintenance windows
+	DependsOn        DependsOn            `json:"depends_on,omitempty"`        // dependencies
+	Schedule         *Schedule            `json:"schedule,omitempty"`          // cron and active hours
 }
 
 type VigieResult struct {
@@ -22,21 +31,52 @@ type VigieResult struct {
 	Status      StepStatus // A evaluer
 	TestResults []TestResult
 	Issue       string // A dégager 10/2020
+	Maintenance string // Window or silence active during the run, its failures are not alerted
+	RootCause   string // Failing dependency of a DependencyFailed result
+	Attempts    []Attempt
+	Recovered   bool // Success after at least one retry
 }
 
-func (tStep *TestStep) WriteResult_OLD(pData VigieResult) {
+// Attempt is one run of the probe, retried until it succeeds or the retries are exhausted.
+type Attempt struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Status   StepStatus    `json:"-"`
+	Issue    string        `json:"issue,omitempty"`
+}
+
+// MarshalJSON exposes the status as a string.
+func (a Attempt) MarshalJSON() ([]byte, error) {
+	type attempt Attempt
+	return json.Marshal(struct {
+		attempt
+		StatusStr string `json:"status"`
+	}{attempt(a), a.Status.String()})
+}
+
+// WriteResult writes the result of a run into the TestStep
+// then returns if the TestStep status has changed and if this change must be alerted.
+func (tStep *TestStep) WriteResult(pData VigieResult) (stateChanged, alertEvent bool) {
 
 	start := time.Now()
 	tStep.Mutex.Lock()
 	tStep.LastAttempt = pData.LastAttempt
-	tStep.Status = pData.Status
+	stateChanged, alertEvent = tStep.setNewStatus(pData.Status)
 	tStep.VigieResults = pData.TestResults
 	tStep.Failures = make([]string, 0) // Clear past failures
+	tStep.RootCause = pData.RootCause
+	tStep.Attempts = pData.Attempts
+	tStep.Recovered = pData.Recovered
+	tStep.Paused = ""
+
+	// The last positive results are not overwritten by the next runs:
+	// the snapshots of the state keep pointing at them.
+	lastResults := pData.TestResults
 
 	switch pData.Status {
 	case Success:
 		tStep.LastPositiveTimeResult = pData.LastAttempt
-		tStep.LastPositiveVigieResults = &tStep.VigieResults
+		tStep.LastPositiveVigieResults = &lastResults
 
 		utils.Log.WithFields(logrus.Fields{
 			"package":  "process",
@@ -59,6 +99,23 @@ func (tStep *TestStep) WriteResult_OLD(pData VigieResult) {
 			"teststep": tStep.Name,
 		}).Debugf("TestStep KO - timeout %s", pData.Issue)
 
+	case Warning:
+		tStep.LastPositiveTimeResult = pData.LastAttempt
+		tStep.LastPositiveVigieResults = &lastResults
+
+		utils.Log.WithFields(logrus.Fields{
+			"package":  "process",
+			"teststep": tStep.Name,
+		}).Debugf("TestStep OK - Warning Assertion FAILED")
+
+	case DependencyFailed:
+		tStep.Failures = append(tStep.Failures, pData.Issue)
+
+		utils.Log.WithFields(logrus.Fields{
+			"package":  "process",
+			"teststep": tStep.Name,
+		}).Debugf("TestStep skipped - %s", pData.Issue)
+
 	case AssertFailure:
 		utils.Log.WithFields(logrus.Fields{
 			"package":  "process",
@@ -84,6 +141,54 @@ func (tStep *TestStep) WriteResult_OLD(pData VigieResult) {
 
 	tStep.Mutex.Unlock()
 
+	return stateChanged, alertEvent
+}
+
+// setNewStatus sets the new status of the TestStep (must be locked),
+// the first Success after the import or a pause is not an alert event.
+func (tStep *TestStep) setNewStatus(newStatus StepStatus) (hasChanged, alertEvent bool) {
+
+	oldStatus := tStep.Status
+	tStep.Status = newStatus
+
+	if oldStatus == newStatus {
+		return false, false
+	}
+	if (oldStatus == NotDefined || oldStatus == Paused) && newStatus == Success {
+		return true, false
+	}
+	return true, true
+}
+
+// SetMaintenance sets the window or the silence muting the TestStep (empty if none)
+// then returns the previous one.
+func (tStep *TestStep) SetMaintenance(reason string) (previous string) {
+
+	tStep.Mutex.Lock()
+	previous = tStep.InMaintenance
+	tStep.InMaintenance = reason
+	tStep.Mutex.Unlock()
+	return previous
+}
+
+// SetPaused sets the pause stopping the TestStep, its status becomes Paused.
+// An empty uID resumes it: not defined until its next run. Returns true if it has changed.
+func (tStep *TestStep) SetPaused(uID string) bool {
+
+	tStep.Mutex.Lock()
+	defer tStep.Mutex.Unlock()
+
+	if tStep.Paused == uID {
+		return false
+	}
+	tStep.Paused = uID
+	tStep.LastChange = time.Now()
+	if uID == "" {
+		tStep.Status = NotDefined
+	} else {
+		tStep.Status = Paused
+	}
+	return true
 }
 
 func (tStep *TestStep) GetStatus() (ss StepStatus) {
@@ -108,6 +213,14 @@ func (tStep *TestStep) GetLastPositiveResult() (vrs *[]TestResult) {
 
 }
 
+// IsFailing tells if the TestStep is down outside of a maintenance.
+func (tStep *TestStep) IsFailing() bool {
+
+	tStep.Mutex.RLock()
+	defer tStep.Mutex.RUnlock()
+	return tStep.Status.IsFailure() && tStep.InMaintenance == ""
+}
+
 func (tStep *TestStep) GetReSyncro() (syncroDelay time.Duration) {
 
 	tStep.Mutex.Lock()
@@ -125,24 +238,35 @@ func (tStep *TestStep) GetReSyncro() (syncroDelay time.Duration) {
 
 }
 
-// applyChecks apply checks on result, return true if all assertions are Success, false otherwise
-func (tStep *TestStep) AssertProbeResult(probeResult probe.ProbeReturnInterface) (assertResults []assertion.AssertResult, success bool) {
+// AssertProbeResult applies the assertions on a probe result and returns the status:
+// AssertFailure if a critical assertion fails, Warning if only warning assertions fail,
+// Success otherwise (info assertions are only reported).
+func (tStep *TestStep) AssertProbeResult(probeResult probe.ProbeReturnInterface) (assertResults []assertion.AssertResult, status StepStatus) {
 	tStep.Mutex.RLock()
 
-	assertStatus := true
+	status = Success
 	assertResults = make([]assertion.AssertResult, 0, len(tStep.Assertions))
 
 	// Check de TestResults against each Assertions
 	for i, a := range tStep.Assertions {
 
-		ar := assertion.AssertResult{Assertion: a.AssertConditionsLong()}
+		ar := assertion.AssertResult{Assertion: a.AssertConditionsLong(), Severity: a.Severity}
 
 		assertion2 := &tStep.Assertions[i]
-		_, fails := assertion.ApplyAssert(probeResult, assertion2)
+		_, fails, detail := assertion.ApplyAssert(probeResult, assertion2)
 		if fails != "" {
-			assertStatus = false
 			ar.ResultStatus = 2
 			ar.ResultAssert = fails
+			ar.Detail = detail
+
+			switch a.Severity {
+			case assertion.Critical:
+				status = AssertFailure
+			case assertion.Warning:
+				if status == Success {
+					status = Warning
+				}
+			}
 		} else {
 			ar.ResultStatus = 1
 			ar.ResultAssert = "ok"
@@ -153,7 +277,7 @@ func (tStep *TestStep) AssertProbeResult(probeResult probe.ProbeReturnInterface)
 	}
 	tStep.Mutex.RUnlock()
 
-	return assertResults, assertStatus
+	return assertResults, status
 }
 
 func (tStep *TestStep) _SetUndefinedAssertRes() {
@@ -16,6 +16,8 @@ func TestTestStep_setNewStatus(t *testing.T) {
 	asrtFail := Tstep{Mutex: sync.RWMutex{}, Status: AssertFailure}
 	timeout := Tstep{Mutex: sync.RWMutex{}, Status: Timeout}
 	err := Tstep{Mutex: sync.RWMutex{}, Status: Error}
+	warn := Tstep{Mutex: sync.RWMutex{}, Status: Warning}
+	paused := Tstep{Mutex: sync.RWMutex{}, Status: Paused}
 	type args struct {
 		newStatus StepStatus
 	}
@@ -68,6 +70,15 @@ func TestTestStep_setNewStatus(t *testing.T) {
 		{"T_Error-AssertFail", err, args{newStatus: AssertFailure}, true, true},
 		{"T_Error-timeout", err, args{newStatus: Timeout}, true, true},
 		{"T_Error-Error", err, args{newStatus: Error}, false, true},
+		// Warning
+		{"T_NotDefined-Warning", notDef, args{newStatus: Warning}, true, true},
+		{"T_Success-Warning", success, args{newStatus: Warning}, true, true},
+		{"T_Warning-Warning", warn, args{newStatus: Warning}, false, false},
+		{"T_Warning-Success", warn, args{newStatus: Success}, true, true},
+		{"T_Warning-AssertFail", warn, args{newStatus: AssertFailure}, true, true},
+		// Paused
+		{"T_Paused-Success", paused, args{newStatus: Success}, true, false},
+		{"T_Paused-Failure", paused, args{newStatus: Failure}, true, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
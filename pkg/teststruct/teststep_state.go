@@ -0,0 +1,122 @@
+package teststruct
+
+import (
+	"time"
+
+	"github.com/vincoll/vigie/pkg/assertion"
+	"github.com/vincoll/vigie/pkg/probe"
+)
+
+// StepState is the state of a TestStep kept by the state store across restarts.
+type StepState struct {
+	Status                   StepStatus     `json:"status"`
+	LastAttempt              time.Time      `json:"lastattempt"`
+	LastChange               time.Time      `json:"lastchange"`
+	LastPositiveTimeResult   time.Time      `json:"lastpositivetimeresult"`
+	Failures                 []string       `json:"failures,omitempty"`
+	RootCause                string         `json:"rootcause,omitempty"`
+	VigieResults             []StoredResult `json:"vigieresults,omitempty"`
+	LastPositiveVigieResults []StoredResult `json:"lastpositivevigieresults,omitempty"`
+}
+
+// StoredResult is a TestResult as saved by the state store,
+// the probe result is restored as a probe.StoredReturn.
+type StoredResult struct {
+	Answer          map[string]interface{}   `json:"answer"`
+	ProbeInfo       probe.ProbeInfo          `json:"probeinfo"`
+	Labels          map[string]string        `json:"labels,omitempty"`
+	Values          map[string]interface{}   `json:"values,omitempty"`
+	AssertionResult []assertion.AssertResult `json:"assertion_result,omitempty"`
+	Status          StepStatus               `json:"status"`
+}
+
+// State returns the state of the TestStep to save,
+// false if there is nothing to save: never run or paused.
+func (tStep *TestStep) State() (StepState, bool) {
+
+	tStep.Mutex.RLock()
+	defer tStep.Mutex.RUnlock()
+
+	if tStep.LastAttempt.IsZero() || tStep.Status == NotDefined || tStep.Status == Paused {
+		return StepState{}, false
+	}
+
+	st := StepState{
+		Status:                 tStep.Status,
+		LastAttempt:            tStep.LastAttempt,
+		LastChange:             tStep.LastChange,
+		LastPositiveTimeResult: tStep.LastPositiveTimeResult,
+		Failures:               append([]string(nil), tStep.Failures...),
+		RootCause:              tStep.RootCause,
+		VigieResults:           toStoredResults(tStep.VigieResults),
+	}
+	if tStep.LastPositiveVigieResults != nil {
+		st.LastPositiveVigieResults = toStoredResults(*tStep.LastPositiveVigieResults)
+	}
+	return st, true
+}
+
+// RestoreState sets the saved state on a TestStep not run yet,
+// returns false if the TestStep has already a state of its own.
+func (tStep *TestStep) RestoreState(st StepState) bool {
+
+	tStep.Mutex.Lock()
+	defer tStep.Mutex.Unlock()
+
+	if !tStep.LastAttempt.IsZero() || tStep.Status != NotDefined {
+		return false
+	}
+
+	tStep.Status = st.Status
+	tStep.LastAttempt = st.LastAttempt
+	tStep.LastChange = st.LastChange
+	tStep.LastPositiveTimeResult = st.LastPositiveTimeResult
+	tStep.Failures = st.Failures
+	tStep.RootCause = st.RootCause
+	tStep.VigieResults = fromStoredResults(st.VigieResults)
+	if st.LastPositiveVigieResults != nil {
+		lpvr := fromStoredResults(st.LastPositiveVigieResults)
+		tStep.LastPositiveVigieResults = &lpvr
+	}
+	return true
+}
+
+func toStoredResults(trs []TestResult) []StoredResult {
+
+	if trs == nil {
+		return nil
+	}
+	srs := make([]StoredResult, 0, len(trs))
+	for _, tr := range trs {
+		sr := StoredResult{AssertionResult: tr.AssertionResult, Status: tr.Status}
+		if tr.ProbeReturn != nil {
+			sr.Answer = tr.ProbeReturn.DumpAnswer()
+			sr.ProbeInfo = tr.ProbeReturn.GetProbeInfo()
+			sr.Labels = tr.ProbeReturn.Labels()
+			sr.Values = tr.ProbeReturn.Values()
+		}
+		srs = append(srs, sr)
+	}
+	return srs
+}
+
+func fromStoredResults(srs []StoredResult) []TestResult {
+
+	if srs == nil {
+		return nil
+	}
+	trs := make([]TestResult, 0, len(srs))
+	for _, sr := range srs {
+		trs = append(trs, TestResult{
+			ProbeReturn: probe.StoredReturn{
+				Answer:    sr.Answer,
+				ProbeInfo: sr.ProbeInfo,
+				Lbls:      sr.Labels,
+				Vals:      sr.Values,
+			},
+			AssertionResult: sr.AssertionResult,
+			Status:          sr.Status,
+		})
+	}
+	return trs
+}
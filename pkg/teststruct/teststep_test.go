@@ -0,0 +1,107 @@
+package teststruct
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/vincoll/vigie/pkg/assertion"
+	"github.com/vincoll/vigie/pkg/render"
+	"github.com/vincoll/vigie/pkg/secret"
+)
+
+func TestJSONStep_render(t *testing.T) {
+
+	vars := render.Vars{"host": "vigie.dev", "port": float64(443), "team": "web"}
+
+	tests := []struct {
+		name    string
+		step    JSONStep
+		want    JSONStep
+		wantErr bool
+	}{
+		{
+			name: "import",
+			step: JSONStep{
+				Name:       "GET {{ .host }}",
+				Probe:      map[string]interface{}{"type": "http", "url": "https://{{ .host }}", "port": "{{ .port }}"},
+				Assertions: []assertion.RawAssert{{Assertion: `headers.Host == "{{ .host }}"`}},
+				Tags:       map[string]interface{}{"team": "{{ .team }}"},
+			},
+			want: JSONStep{
+				Name:       "GET vigie.dev",
+				Probe:      map[string]interface{}{"type": "http", "url": "https://vigie.dev", "port": float64(443)},
+				Assertions: []assertion.RawAssert{{Assertion: `headers.Host == "vigie.dev"`}},
+				Tags:       map[string]interface{}{"team": "web"},
+			},
+		},
+		{
+			name: "run",
+			step: JSONStep{
+				Name:   "POST {{ .host }}",
+				Probe:  map[string]interface{}{"type": "http", "body": `{"at": "{{ now | unix }}"}`},
+				Render: renderAtRun,
+			},
+			want: JSONStep{
+				Name:       "POST vigie.dev",
+				Probe:      map[string]interface{}{"type": "http", "body": `{"at": "{{ now | unix }}"}`},
+				Assertions: []assertion.RawAssert{},
+				Render:     renderAtRun,
+			},
+		},
+		{name: "missing_var", step: JSONStep{Probe: map[string]interface{}{"url": "{{ .nope }}"}}, wantErr: true},
+		{name: "invalid_render", step: JSONStep{Render: "sometimes"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.step.render(vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("render() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeWrap_Export_secrets(t *testing.T) {
+
+	// Short, and hidden by b64enc: only masked by field
+	os.Setenv("VIGIE_TEST_PIN", "42")
+	defer os.Unsetenv("VIGIE_TEST_PIN")
+
+	probe := map[string]interface{}{
+		"type":              "http",
+		"url":               "https://vigie.dev",
+		"basicauthpassword": "secret://env/VIGIE_TEST_PIN",
+		"headers":           map[string]interface{}{"Authorization": `Basic {{ printf "admin:%s" (secret "env/VIGIE_TEST_PIN") | b64enc }}`, "X-Team": "web"},
+	}
+
+	for _, renderAt := range []string{renderAtImport, renderAtRun} {
+		t.Run(renderAt, func(t *testing.T) {
+			jstp := JSONStep{
+				Probe:  probe,
+				Render: renderAt,
+				Config: configTestStructJson{Frequency: ProbeConfigJsonRaw{"http": "1m"}},
+			}
+			tSteps, err := jstp.toTestStep(&configTestStruct{}, render.Vars{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			pw, err := tSteps[0].ProbeWrap.Rendered()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := pw.Export().Probe.(map[string]interface{})
+			headers := got["headers"].(map[string]interface{})
+			if got["basic_auth_password"] != secret.Mask || headers["Authorization"] != secret.Mask {
+				t.Errorf("Export() secrets not masked: password %v, Authorization %v", got["basic_auth_password"], headers["Authorization"])
+			}
+			if got["url"] != "https://vigie.dev" || headers["X-Team"] != "web" {
+				t.Errorf("Export() fields without secret are masked: %v", got)
+			}
+		})
+	}
+}
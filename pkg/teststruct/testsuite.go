@@ -7,8 +7,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/sirupsen/logrus"
-
+	"github.com/vincoll/vigie/pkg/render"
 	"github.com/vincoll/vigie/pkg/utils"
 )
 
@@ -16,7 +15,8 @@ type JSONTestSuite struct {
 	Config        configTestStructJson   `json:"config"`
 	Name          string                 `json:"name"`
 	JsonTestCases []JSONTestCase         `json:"testcases"`
-	Vars          map[string][]string    `json:"vars"`
+	Vars          map[string]interface{} `json:"vars"`
+	Templates     Templates              `json:"templates"`
 	Tags          map[string]interface{} `json:"tags"`
 }
 
@@ -47,11 +47,11 @@ func (ts *TestSuite) UnmarshalJSON(data []byte) error {
 	var err error
 	*ts, err = jsonTS.toTestSuite()
 	if err != nil {
-		utils.Log.WithFields(logrus.Fields{
-			"testsuite": jsonTS.Name,
-		}).Fatalf("A TestSuite is invalid: %s", err)
-
-		return err
+		// The error is logged and exposed by the loader, with the file
+		if jsonTS.Name != "" {
+			return fmt.Errorf("testsuite %q is invalid: %w", jsonTS.Name, err)
+		}
+		return fmt.Errorf("testsuite is invalid: %w", err)
 	}
 	return nil
 }
@@ -76,28 +76,20 @@ func (jts JSONTestSuite) toTestSuite() (TestSuite, error) {
 	// UnMarshall spécifique de la config avec conversion de strings type (1d,7m) en time.duration
 	ctsTS, err := unmarshallConfigTestStruct(jts.Config)
 	if err != nil {
-
-		utils.Log.WithFields(logrus.Fields{
-			"testsuite": ts.Name,
-		}).Fatalf("config declaration : %s", err)
-
 		return TestSuite{}, fmt.Errorf("config declaration: %s", err)
 	}
 
 	ts.TestCases = make(map[uint64]*TestCase, len(jts.JsonTestCases))
 
-	mergeMapsTS := utils.MergeMaps(utils.ALLVARS, jts.Vars)
+	mergeMapsTS := render.Vars(utils.ALLVARS).Merge(jts.Vars)
+	templates := sharedTemplates.withLocal(jts.Templates)
 
 	// Apply config inheritance TestSuite => TC
-	for _, jtc := range jts.JsonTestCases {
+	for i, jtc := range jts.JsonTestCases {
 
-		testcase, jtcErr := jtc.toTestCase(&ctsTS, mergeMapsTS)
+		testcase, jtcErr := jtc.toTestCase(&ctsTS, mergeMapsTS, templates)
 		if jtcErr != nil {
-
-			utils.Log.WithFields(logrus.Fields{
-				"testsuite": ts.Name,
-			}).Fatalf("Cannot import : %s", jtcErr)
-
+			return TestSuite{}, fmt.Errorf("cannot import testcase: %w", atPath(jtcErr, "testcases", i))
 		}
 
 		ts.addTestCase(&testcase)
@@ -125,16 +117,6 @@ func (ts *TestSuite) GetTestcaseByID(TCid uint64) (tc *TestCase) {
 
 }
 
-func (ts *TestSuite) WithoutTC() TestSuite {
-
-	tsBis := *ts
-	// Reset TestCase
-	tsBis.TestCases = make(map[uint64]*TestCase, 1)
-	tsBis.Mutex = sync.RWMutex{}
-	return tsBis
-
-}
-
 // removeTestCase simply add a TestCase to this TestSuite, concurrency safe
 func (ts *TestSuite) addTestCase(newTC *TestCase) {
 
@@ -153,7 +135,8 @@ func (ts *TestSuite) removeTestCase(ID uint64) {
 
 }
 
-// ImportAllTestCases will add new TCs, remove oldTC that are absent from the new TCs, keep common TCs
+// ImportAllTestCases will add new TCs, remove oldTC that are absent from the new TCs, keep common TCs.
+// ts is the old TestSuite: newTCs gets its unchanged TestCases and TestSteps.
 func (ts *TestSuite) ImportAllTestCases(newTCs map[uint64]*TestCase) {
 
 	// Compilation to avoid multiples loops
@@ -171,11 +154,17 @@ func (ts *TestSuite) ImportAllTestCases(newTCs map[uint64]*TestCase) {
 				// Name is identical to an existing TC, but ID is different.
 				// That means that the old import have changed. And so TSteps.
 				// The full old TestCase state is keep, but we need to go deeper to update this TestCase.
-				newTCs[nTCid].ImportTestSteps(oTC.TestSteps)
+				oTC.ImportTestSteps(newTCs[nTCid].TestSteps)
+				// The status is kept until the next run
+				oTC.Mutex.RLock()
+				newTCs[nTCid].Status, newTCs[nTCid].LastChange = oTC.Status, oTC.LastChange
+				oTC.Mutex.RUnlock()
 			} else {
 				newTCs[nTCid] = oTC
 			}
 		}
 	}
+	ts.Mutex.Lock()
 	ts.TestCases = newTCs
+	ts.Mutex.Unlock()
 }
@@ -3,6 +3,8 @@ package teststruct
 import (
 	"bytes"
 	"encoding/json"
+
+	"github.com/vincoll/vigie/pkg/secret"
 )
 
 type TSDescribe struct {
@@ -31,7 +33,7 @@ func (ts *TestSuite) ToConsul() []byte {
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(cTS)
 
-	return reqBodyBytes.Bytes()
+	return secret.RedactBytes(reqBodyBytes.Bytes())
 
 }
 
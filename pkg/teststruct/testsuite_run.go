@@ -3,15 +3,19 @@ package teststruct
 func (ts *TestSuite) UpdateStatus() {
 
 	ts.Mutex.Lock()
+	status := Success
 	for _, tc := range ts.TestCases {
 
-		if tc.GetStatus() == Failure {
+		switch tc.GetStatus() {
+		case Failure:
 			ts.Status = Failure
 			ts.Mutex.Unlock()
 			return
+		case Warning:
+			status = Warning
 		}
 	}
-	ts.Status = Success
+	ts.Status = status
 	ts.Mutex.Unlock()
 	return
 }
@@ -1,20 +1,54 @@
 package teststruct
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 )
 
+// PathError locates an error in a TestSuite file by the keys and indexes
+// of its element ([testcases 0 steps 2 assertions 1]), the loader turns it into a line.
+type PathError struct {
+	Path []string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// atPath prefixes the path of the error with the parent element (testcases 0),
+// an error without path gets one.
+func atPath(err error, key string, index int) error {
+
+	parent := []string{key, strconv.Itoa(index)}
+	var pErr *PathError
+	if errors.As(err, &pErr) {
+		pErr.Path = append(parent, pErr.Path...)
+		return err
+	}
+	return &PathError{Path: parent, Err: err}
+}
+
 // unmarshallConfigTestStruct convert raw json data into more easily manipulated types
 func unmarshallConfigTestStruct(ctjson configTestStructJson) (configTestStruct, error) {
 
 	// Convert string duration format (1d, 127ms...) to time.duration
 	cts := configTestStruct{
-		Concurrency: ctjson.Concurrency,
-		Retry:       ctjson.Retry,
-		Frequency:   map[string]time.Duration{},
-		Retrydelay:  map[string]time.Duration{},
-		Timeout:     map[string]time.Duration{},
+		Concurrency:      ctjson.Concurrency,
+		Retry:            ctjson.Retry,
+		Retrybackoff:     ctjson.Retrybackoff,
+		FailureBackoff:   ctjson.FailureBackoff,
+		FailureFrequency: map[string]time.Duration{},
+		Schedule:         ctjson.Schedule,
+		Frequency:        map[string]time.Duration{},
+		Retrydelay:       map[string]time.Duration{},
+		Timeout:          map[string]time.Duration{},
 	}
 
 	for k, v := range ctjson.Frequency {
@@ -41,6 +75,49 @@ func unmarshallConfigTestStruct(ctjson configTestStructJson) (configTestStruct,
 		cts.Retrydelay[k] = dur
 	}
 
+	for k, v := range ctjson.FailureFrequency {
+		dur, err := time.ParseDuration(v)
+		if err != nil {
+			return cts, fmt.Errorf("%s: valid time units are [ns, us (or µs), ms, s, m, h", err)
+		}
+		if dur < 0 {
+			return cts, fmt.Errorf("failure_frequency of %s must be >= 0", k)
+		}
+		cts.FailureFrequency[k] = dur
+	}
+
+	for k, v := range ctjson.FailureBackoff {
+		if v < 1 {
+			return cts, fmt.Errorf("failure_backoff of %s must be >= 1 (1 keeps the same interval, 2 doubles it)", k)
+		}
+	}
+
+	for k, v := range ctjson.Concurrency {
+		if v < 0 {
+			return cts, fmt.Errorf("concurrency of %s must be >= 0 (0 is unlimited)", k)
+		}
+	}
+
+	for k, v := range ctjson.Retrybackoff {
+		if v < 1 {
+			return cts, fmt.Errorf("retrybackoff of %s must be >= 1 (1 keeps the same delay, 2 doubles it)", k)
+		}
+	}
+
+	if cts.Schedule != nil {
+		if err := cts.Schedule.Validate(); err != nil {
+			return cts, err
+		}
+	}
+
+	for _, wj := range ctjson.Maintenance {
+		w, err := wj.ToWindow()
+		if err != nil {
+			return cts, err
+		}
+		cts.Maintenance = append(cts.Maintenance, w)
+	}
+
 	return cts, nil
 }
 
@@ -0,0 +1,157 @@
+package ticker
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+// CronPool sends the tasks scheduled by a cron to the scheduler at their next run.
+type CronPool struct {
+	mu              sync.Mutex
+	Tasks           map[uint64]*cronTask
+	wake            chan struct{} // tasks added or removed while running
+	close           chan struct{}
+	chanToScheduler chan teststruct.Task
+}
+
+// cronTask is a task and its next run.
+type cronTask struct {
+	task teststruct.Task
+	next time.Time
+}
+
+func newCronPool(toSched chan teststruct.Task) *CronPool {
+
+	return &CronPool{
+		Tasks:           make(map[uint64]*cronTask, 0),
+		wake:            make(chan struct{}, 1),
+		close:           make(chan struct{}),
+		chanToScheduler: toSched,
+	}
+}
+
+// AddTask adds a task to the CronPool, a task already present is only updated.
+func (cp *CronPool) AddTask(task teststruct.Task) {
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if ct, present := cp.Tasks[task.TestStep.ID]; present {
+		ct.task = task
+		return
+	}
+	cp.Tasks[task.TestStep.ID] = &cronTask{task: task, next: task.TestStep.NextRun(time.Now())}
+	cp.notify()
+}
+
+func (cp *CronPool) RemoveTask(id uint64) {
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	delete(cp.Tasks, id)
+	cp.notify()
+}
+
+// notify wakes the run loop up to take the changes into account, cp.mu must be held.
+func (cp *CronPool) notify() {
+
+	select {
+	case cp.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start the CronPool as seperate goroutine
+func (cp *CronPool) Start() {
+
+	cp.mu.Lock()
+	count := len(cp.Tasks)
+	cp.mu.Unlock()
+
+	if count > 0 {
+		utils.Log.WithFields(log.Fields{
+			"package": "ticker",
+		}).Debugf("CronPool START at %s with %d Tasks ", time.Now().Format(time.RFC3339), count)
+	}
+
+	go cp.run()
+}
+
+// next returns the earliest next run, a zero time if no task will run anymore.
+func (cp *CronPool) next() time.Time {
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	var next time.Time
+	for _, ct := range cp.Tasks {
+		if !ct.next.IsZero() && (next.IsZero() || ct.next.Before(next)) {
+			next = ct.next
+		}
+	}
+	return next
+}
+
+// due returns the tasks due at now and sets their next run.
+func (cp *CronPool) due(now time.Time) []teststruct.Task {
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	tasks := make([]teststruct.Task, 0)
+	for _, ct := range cp.Tasks {
+		if ct.next.IsZero() || ct.next.After(now) {
+			continue
+		}
+		tasks = append(tasks, ct.task)
+		ct.next = ct.task.TestStep.NextRun(now)
+	}
+	return tasks
+}
+
+// run waits for the earliest next run, then sends the tasks due at that time.
+func (cp *CronPool) run() {
+
+	for {
+		// No task will run: waits for tasks
+		wait := time.Duration(1<<63 - 1)
+		if next := cp.next(); !next.IsZero() {
+			wait = time.Until(next)
+		}
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-cp.close:
+			timer.Stop()
+			return
+		case <-cp.wake:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		for _, t := range cp.due(time.Now()) {
+			// A paused task skips its run
+			if isPaused(t) {
+				continue
+			}
+			// Blocks while the scheduler queue is full
+			select {
+			case cp.chanToScheduler <- t:
+			case <-cp.close:
+				return
+			}
+		}
+	}
+}
+
+// Stop the CronPool
+func (cp *CronPool) Stop() {
+	close(cp.close)
+}
@@ -0,0 +1,202 @@
+package ticker
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+// FailurePool runs the failing steps at their failure frequency: a step is moved out of
+// its TickerPool when a run fails, and goes back to it once a run succeeds.
+type FailurePool struct {
+	mu                sync.Mutex
+	Tasks             map[uint64]teststruct.Task // steps with a failure frequency
+	failing           map[uint64]*failingTask    // steps moved out of their TickerPool
+	close             chan struct{}
+	chanToScheduler   chan teststruct.Task
+	chanFromScheduler chan teststruct.Task
+}
+
+// failingTask is a step run at its failure frequency, the interval grows with the backoff.
+type failingTask struct {
+	task     teststruct.Task
+	interval time.Duration
+	next     time.Time
+}
+
+func newFailurePool(toSched, fromSched chan teststruct.Task) *FailurePool {
+
+	return &FailurePool{
+		Tasks:             make(map[uint64]teststruct.Task, 0),
+		failing:           make(map[uint64]*failingTask, 0),
+		close:             make(chan struct{}),
+		chanToScheduler:   toSched,
+		chanFromScheduler: fromSched,
+	}
+}
+
+func (fp *FailurePool) AddTask(task teststruct.Task) {
+
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	fp.Tasks[task.TestStep.ID] = task
+	if ft, present := fp.failing[task.TestStep.ID]; present {
+		ft.task = task
+	}
+}
+
+func (fp *FailurePool) RemoveTask(id uint64) {
+
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	delete(fp.Tasks, id)
+	delete(fp.failing, id)
+}
+
+// IsFailing tells if the step is run by the FailurePool instead of its TickerPool.
+func (fp *FailurePool) IsFailing(id uint64) bool {
+
+	if fp == nil {
+		return false
+	}
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	_, present := fp.failing[id]
+	return present
+}
+
+// Start the FailurePool as seperate goroutine,
+// the steps already failing are moved at once.
+func (fp *FailurePool) Start() {
+
+	now := time.Now()
+	fp.mu.Lock()
+	for _, t := range fp.Tasks {
+		if t.TestStep.IsFailing() {
+			fp.move(t, now)
+		}
+	}
+	fp.mu.Unlock()
+
+	go fp.run()
+}
+
+// update moves the step according to the result of its last run.
+func (fp *FailurePool) update(task teststruct.Task, now time.Time) {
+
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	// Step removed by a reload or without failure frequency
+	if _, present := fp.Tasks[task.TestStep.ID]; !present {
+		return
+	}
+
+	_, moved := fp.failing[task.TestStep.ID]
+	failing := task.TestStep.IsFailing()
+
+	switch {
+	case failing && !moved:
+		fp.move(task, now)
+		utils.Log.WithFields(log.Fields{
+			"package": "ticker", "teststep": task.TestStep.ID,
+		}).Debugf("TestStep is failing, run every %s", task.TestStep.ProbeWrap.FailureFrequency)
+
+	case !failing && moved:
+		delete(fp.failing, task.TestStep.ID)
+		utils.Log.WithFields(log.Fields{
+			"package": "ticker", "teststep": task.TestStep.ID,
+		}).Debugf("TestStep has recovered, back to its TickerPool")
+	}
+}
+
+// move takes the step out of its TickerPool, fp.mu must be held.
+func (fp *FailurePool) move(task teststruct.Task, now time.Time) {
+
+	interval := task.TestStep.ProbeWrap.FailureFrequency
+	fp.failing[task.TestStep.ID] = &failingTask{task: task, interval: interval, next: now.Add(interval)}
+}
+
+// due returns the failing tasks to send at now, and the time of the next one.
+func (fp *FailurePool) due(now time.Time) (tasks []teststruct.Task, next time.Time) {
+
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	for _, ft := range fp.failing {
+		if !ft.next.After(now) {
+			tasks = append(tasks, ft.task)
+			ft.next = now.Add(ft.interval)
+			ft.interval = nextInterval(ft.interval, ft.task.TestStep.ProbeWrap)
+		}
+		if next.IsZero() || ft.next.Before(next) {
+			next = ft.next
+		}
+	}
+	return tasks, next
+}
+
+// nextInterval multiplies the interval by the failure backoff, up to the frequency.
+func nextInterval(interval time.Duration, pw teststruct.ProbeWrap) time.Duration {
+
+	next := time.Duration(float64(interval) * pw.FailureBackoff)
+	if next > pw.Frequency || next <= 0 {
+		return pw.Frequency
+	}
+	return next
+}
+
+// run sends the failing tasks at their interval and moves the steps
+// from the results of the scheduler.
+func (fp *FailurePool) run() {
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-fp.close:
+			return
+
+		case task := <-fp.chanFromScheduler:
+			fp.update(task, time.Now())
+
+		case <-timer.C:
+		}
+
+		tasks, next := fp.due(time.Now())
+		for _, t := range tasks {
+			// Outside its active hours, or paused, the task skips its run
+			if !t.TestStep.IsActive(time.Now()) || isPaused(t) {
+				continue
+			}
+			select {
+			case fp.chanToScheduler <- t:
+			case <-fp.close:
+				return
+			}
+		}
+
+		// Nothing failing: wait for the next result
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = time.Until(next)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// Stop the FailurePool
+func (fp *FailurePool) Stop() {
+	close(fp.close)
+}
@@ -1,6 +1,8 @@
 package ticker
 
 import (
+	log "github.com/sirupsen/logrus"
+
 	"github.com/vincoll/vigie/pkg/teststruct"
 	"github.com/vincoll/vigie/pkg/utils"
 )
@@ -11,40 +13,29 @@ import (
 // That means v.tp[n].task.ts[1] = v.testsuite[x]
 // The Goal is to limitate redondant concurent tickers centralizing them in the vigie instance.
 // Each testStep with the same duration is register to a tickerpool
-func (tpm *TickerPoolManager) ImportTS(nTS map[uint64]*teststruct.TestSuite) *TickerPoolManager {
-
-	// On each TestSuites Collected
-	// ImportTS TestCaseCount and Tickers ()
+//
+// The import is incremental: the TestSteps already scheduled (same ID) keep their slot,
+// only the added and removed TestSteps are (un)scheduled.
+func (tpm *TickerPoolManager) ImportTS(nTS map[uint64]*teststruct.TestSuite) (added, removed int) {
 
+	tasks := make(map[uint64]teststruct.Task, 0)
 	for _, ts := range nTS {
-		// Create Tickers based on TestSuites frequency
-		ts2 := ts
-		for _, tc := range ts2.TestCases {
-			tc2 := tc
-			for _, tstp := range tc2.TestSteps {
-				tstp2 := tstp
-				// Create/Add a new TickerPool (TP)
-				freq := tstp2.ProbeWrap.Frequency
-
-				// Create TP if needed
-				if !tpm.IsTickerPool(freq) {
-					// if does not exists => create new tickerpool
-					err := tpm.AddTickerPool(freq)
-					if err != nil {
-						utils.Log.Errorf("can not create a Tickerpool: %s", err.Error())
-					}
-				}
-				// Add Task in tickerpool
-
-				ntask := teststruct.Task{
-					TestSuite: ts2,
-					TestCase:  tc2,
-					TestStep:  tstp2,
+		for _, tc := range ts.TestCases {
+			for _, tstp := range tc.TestSteps {
+				tasks[tstp.ID] = teststruct.Task{
+					TestSuite: ts,
+					TestCase:  tc,
+					TestStep:  tstp,
 				}
-
-				tpm.AddTask(ntask)
 			}
 		}
 	}
-	return tpm
+
+	added, removed = tpm.sync(tasks)
+
+	utils.Log.WithFields(log.Fields{
+		"package": "ticker",
+	}).Debugf("TickerPools updated: %d TestSteps added, %d removed", added, removed)
+
+	return added, removed
 }
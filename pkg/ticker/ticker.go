@@ -2,13 +2,13 @@ package ticker
 
 import (
 	"fmt"
-	"github.com/vincoll/vigie/pkg/process"
-	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/vincoll/vigie/pkg/pause"
 	"github.com/vincoll/vigie/pkg/teststruct"
 	"github.com/vincoll/vigie/pkg/utils"
 )
@@ -16,64 +16,177 @@ import (
 //https://guzalexander.com/2017/05/31/gracefully-exit-server-in-go.html
 
 type TickerPoolManager struct {
-	tickerPools map[time.Duration]TickerPool
-	ChanToSched chan teststruct.Task
+	mu            sync.Mutex
+	running       bool
+	tickerPools   map[time.Duration]*TickerPool
+	cronPool      *CronPool
+	failurePool   *FailurePool
+	tasks         map[uint64]teststruct.Task // scheduled tasks, by TestStep ID
+	ChanToSched   chan teststruct.Task
+	ChanFromSched chan teststruct.Task // results of the runs, to move the failing steps
 }
 
-func NewTickerPoolManager(toSched chan teststruct.Task) *TickerPoolManager {
+func NewTickerPoolManager(toSched, fromSched chan teststruct.Task) *TickerPoolManager {
 
 	tpm := TickerPoolManager{
-		ChanToSched: toSched,
-		tickerPools: make(map[time.Duration]TickerPool, 0),
+		ChanToSched:   toSched,
+		ChanFromSched: fromSched,
+		tickerPools:   make(map[time.Duration]*TickerPool, 0),
+		cronPool:      newCronPool(toSched),
+		failurePool:   newFailurePool(toSched, fromSched),
+		tasks:         make(map[uint64]teststruct.Task, 0),
 	}
 	return &tpm
 }
 
 func (tpm *TickerPoolManager) AddTickerPool(freq time.Duration) error {
 
+	tpm.mu.Lock()
+	defer tpm.mu.Unlock()
+	return tpm.addTickerPool(freq)
+}
+
+// addTickerPool creates the TickerPool of freq, started if the manager is running.
+func (tpm *TickerPoolManager) addTickerPool(freq time.Duration) error {
+
 	if freq <= time.Millisecond {
 		return fmt.Errorf("TickerPool cannot be created: frequency cannot be < 1ms")
 	}
 
-	tp := TickerPool{
-		ticker:          *time.NewTicker(freq),
-		frequency:       freq,
-		Tasks:           make(map[uint64]*tPoolTasker, 0),
-		close:           make(chan struct{}),
-		chanToScheduler: tpm.ChanToSched,
-	}
-
+	tp := newTickerPool(freq, tpm.ChanToSched, tpm.failurePool)
 	tpm.tickerPools[freq] = tp
+	if tpm.running {
+		tp.Start()
+	}
 	return nil
 
 }
 
 func (tpm *TickerPoolManager) IsTickerPool(freq time.Duration) bool {
+
+	tpm.mu.Lock()
+	defer tpm.mu.Unlock()
 	_, present := tpm.tickerPools[freq]
 	return present
 }
 
+// IsScheduled tells if a TestStep is scheduled, by its ID.
+func (tpm *TickerPoolManager) IsScheduled(ID uint64) bool {
+
+	tpm.mu.Lock()
+	defer tpm.mu.Unlock()
+	_, present := tpm.tasks[ID]
+	return present
+}
+
 // startEachTickerPool déclenche tout les Tickers afin de débuter les tests.
 func (tpm *TickerPoolManager) StartEachTickerPool() {
+
+	tpm.mu.Lock()
+	defer tpm.mu.Unlock()
+
+	if tpm.running {
+		return
+	}
+	tpm.running = true
+
 	for _, tp := range tpm.tickerPools {
-		go tp.Start()
+		tp.Start()
 	}
+	tpm.cronPool.Start()
+	tpm.failurePool.Start()
 }
 
 // stopEachTickerPool stops all the tickers
 func (tpm *TickerPoolManager) StopEachTickerPool() {
+
+	tpm.mu.Lock()
+	defer tpm.mu.Unlock()
+
 	// Stop all the tickers
 	for _, tp := range tpm.tickerPools {
 		tp.Stop()
 	}
+	tpm.cronPool.Stop()
+	tpm.failurePool.Stop()
 }
 
-// stopEachTickerPool stops all the tickers
+// AddTask adds the task to the TickerPool of its frequency,
+// or to the CronPool if it runs at the times of a cron.
+// A task with a failure frequency is moved to the FailurePool while failing.
 func (tpm *TickerPoolManager) AddTask(t teststruct.Task) {
 
-	tpool := tpm.tickerPools[t.TestStep.ProbeWrap.Frequency]
-	tpool.AddTask(t)
+	tpm.mu.Lock()
+	defer tpm.mu.Unlock()
+	tpm.addTask(t)
+}
+
+// addTask adds a new task, or updates the task of a step already scheduled
+// (new TestSuite or TestCase) which keeps its slot.
+func (tpm *TickerPoolManager) addTask(t teststruct.Task) {
+
+	tpm.tasks[t.TestStep.ID] = t
+
+	if t.TestStep.Schedule.IsCron() {
+		tpm.cronPool.AddTask(t)
+		return
+	}
+
+	freq := t.TestStep.ProbeWrap.Frequency
+	if _, present := tpm.tickerPools[freq]; !present {
+		if err := tpm.addTickerPool(freq); err != nil {
+			utils.Log.Errorf("can not create a Tickerpool: %s", err.Error())
+			return
+		}
+	}
+	tpm.tickerPools[freq].AddTask(t)
+
+	if t.TestStep.ProbeWrap.FailureFrequency > 0 {
+		tpm.failurePool.AddTask(t)
+	}
+
+}
+
+// removeTask unschedules a task, the TickerPool left empty is stopped.
+func (tpm *TickerPoolManager) removeTask(t teststruct.Task) {
+
+	delete(tpm.tasks, t.TestStep.ID)
+	tpm.failurePool.RemoveTask(t.TestStep.ID)
+
+	if t.TestStep.Schedule.IsCron() {
+		tpm.cronPool.RemoveTask(t.TestStep.ID)
+		return
+	}
 
+	freq := t.TestStep.ProbeWrap.Frequency
+	if tp, present := tpm.tickerPools[freq]; present {
+		if tp.RemoveTask(t.TestStep.ID) == 0 {
+			tp.Stop()
+			delete(tpm.tickerPools, freq)
+		}
+	}
+}
+
+// sync schedules the new set of tasks: the steps kept (same ID) keep their slot,
+// the steps absent are unscheduled and the new ones are added.
+func (tpm *TickerPoolManager) sync(tasks map[uint64]teststruct.Task) (added, removed int) {
+
+	tpm.mu.Lock()
+	defer tpm.mu.Unlock()
+
+	for id, t := range tpm.tasks {
+		if _, present := tasks[id]; !present {
+			tpm.removeTask(t)
+			removed++
+		}
+	}
+	for id, t := range tasks {
+		if _, present := tpm.tasks[id]; !present {
+			added++
+		}
+		tpm.addTask(t)
+	}
+	return added, removed
 }
 
 func (tpm *TickerPoolManager) GracefulShutdown() {
@@ -81,158 +194,286 @@ func (tpm *TickerPoolManager) GracefulShutdown() {
 }
 
 type TickerPool struct {
-	ticker          time.Ticker
+	mu              sync.Mutex
 	frequency       time.Duration
 	Tasks           map[uint64]*tPoolTasker
+	order           []*tPoolTasker // Tasks sorted by offset
+	running         bool
+	wake            chan struct{} // tasks added or removed while running
 	close           chan struct{}
 	chanToScheduler chan teststruct.Task
+	failures        *FailurePool // failing tasks, run by the FailurePool
 }
 
-type tPoolTasker struct {
-	sync.Mutex
-	task            teststruct.Task
-	schedulingDelay time.Duration
-	// reSync is used when a newState is loaded. => Reset Tickerpools
-	// It add a scheduling delay based on the last attempt to respect
-	// the test interval of this task even if a tickerpool have been reset.
-	reSync time.Duration
-}
+func newTickerPool(freq time.Duration, toSched chan teststruct.Task, failures *FailurePool) *TickerPool {
 
-func (tpt *tPoolTasker) applyReSync() {
-	tpt.Lock()
-	if tpt.reSync != 0 {
-		time.Sleep(tpt.reSync)
-		tpt.resetReSync()
+	return &TickerPool{
+		frequency:       freq,
+		Tasks:           make(map[uint64]*tPoolTasker, 0),
+		wake:            make(chan struct{}, 1),
+		close:           make(chan struct{}),
+		chanToScheduler: toSched,
+		failures:        failures,
 	}
-	tpt.Unlock()
 }
 
-func (tpt *tPoolTasker) resetReSync() {
-	tpt.reSync = 0
+// startupSpread is the window over which the tasks due at the start are spread.
+const startupSpread = 15 * time.Second
+
+// tPoolTasker is a task sent to the scheduler at its offset in each period.
+type tPoolTasker struct {
+	task   teststruct.Task
+	offset time.Duration
+	// reSync is the delay before the next run of a task already run (new state loaded),
+	// 0 or less if it has never run or is late.
+	reSync time.Duration
 }
 
+// AddTask adds a task to the TickerPool, a task already present is only updated:
+// it keeps its slot. A task added while running whose slot is far is sent within startupSpread.
 func (tp *TickerPool) AddTask(task teststruct.Task) {
 
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	if t, present := tp.Tasks[task.TestStep.ID]; present {
+		t.task = task
+		return
+	}
+
 	task3 := tPoolTasker{
-		task:            task,
-		schedulingDelay: 0,
-		reSync:          task.TestStep.GetReSyncro(),
+		task:   task,
+		offset: teststruct.ScheduleOffset(task.TestStep.ID, tp.frequency),
+		reSync: task.TestStep.GetReSyncro(),
 	}
 
 	tp.Tasks[task.TestStep.ID] = &task3
+	i := sort.Search(len(tp.order), func(i int) bool { return tp.order[i].offset > task3.offset })
+	tp.order = append(tp.order, nil)
+	copy(tp.order[i+1:], tp.order[i:])
+	tp.order[i] = &task3
+
+	if tp.running {
+		if early := startupTasks([]*tPoolTasker{&task3}, tp.frequency, time.Now()); len(early) > 0 {
+			go tp.send(early)
+		}
+		tp.notify()
+	}
+}
+
+// RemoveTask removes a task from the TickerPool and returns the number of tasks left.
+func (tp *TickerPool) RemoveTask(id uint64) int {
 
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	if _, present := tp.Tasks[id]; present {
+		delete(tp.Tasks, id)
+		for i, t := range tp.order {
+			if t.task.TestStep.ID == id {
+				tp.order = append(tp.order[:i], tp.order[i+1:]...)
+				break
+			}
+		}
+		tp.notify()
+	}
+	return len(tp.Tasks)
 }
 
-// Start the tickerpool as seperate goroutine
-func (tp *TickerPool) Start() {
+// notify wakes the run loop up to take the changes into account, tp.mu must be held.
+func (tp *TickerPool) notify() {
 
-	// Some types of probes will be more likely to have long intervals
-	// Avoid waiting the first tick in case of a long duration.
+	select {
+	case tp.wake <- struct{}{}:
+	default:
+	}
+}
 
-	if tp.frequency > time.Duration(59)*time.Second {
+// Start the tickerpool as seperate goroutine
+func (tp *TickerPool) Start() {
 
-		// Delay the launch of the different tickerpools
-		// to avoid a startup spike.
+	tp.mu.Lock()
+	tp.running = true
+	start := time.Now()
+	// The tasks never run or late, whose slot is far, are spread
+	// over the first seconds to avoid waiting a long period.
+	early := startupTasks(tp.order, tp.frequency, start)
+	count := len(tp.order)
+	tp.mu.Unlock()
 
-		rand.Seed(time.Now().UnixNano())
-		n := rand.Intn(15) // n will be between 0 and val
-		wait := time.Duration(n) * time.Second
-		time.Sleep(wait)
+	if len(early) > 0 {
 
 		utils.Log.WithFields(log.Fields{
 			"package": "ticker",
-		}).Debugf("Ticker %s PRE-START (%s) at %s", tp.frequency.String(), wait, time.Now().Format(time.RFC3339))
+		}).Debugf("Ticker %s PRE-START %d Tasks at %s", tp.frequency.String(), len(early), start.Format(time.RFC3339))
 
-		tp.processAllTasks2()
+		go tp.send(early)
 	}
 
-	go tp.run()
+	utils.Log.WithFields(log.Fields{
+		"package": "ticker",
+	}).Debugf("Ticker %s START at %s with %d Tasks ", tp.frequency.String(), start.Format(time.RFC3339), count)
+
+	go tp.run(start)
 
 	return
 }
 
-// run will run all the tasks on each ticking
-func (tp *TickerPool) run() {
-	// Loop, Start at each Ticker Tick
+// startupTasks returns the tasks to run at the start, at their offset within startupSpread.
+func startupTasks(tasks []*tPoolTasker, freq time.Duration, start time.Time) []scheduled {
 
-	for {
-		select {
-		case <-tp.ticker.C:
-			tp.processAllTasks2()
-		case <-tp.close:
-			tp.ticker.Stop()
-			return
+	period := start.Truncate(freq)
+	early := make([]scheduled, 0)
+	for _, t := range tasks {
+		slot := period.Add(t.offset)
+		if slot.Before(start) {
+			slot = slot.Add(freq)
+		}
+		if t.reSync <= 0 && slot.Sub(start) > startupSpread {
+			early = append(early, scheduled{task: t, due: start.Add(t.offset % startupSpread)})
 		}
 	}
+	sort.Slice(early, func(i, j int) bool { return early[i].due.Before(early[j].due) })
+	return early
+}
 
+// scheduled is a task and the time to send it to the scheduler.
+type scheduled struct {
+	task *tPoolTasker
+	due  time.Time
 }
 
-// run will run all the tasks on each ticking
-func (tp *TickerPool) Stop() {
-	// Stop the tick
-	tp.close <- struct{}{}
-	close(tp.close)
+// nextSlot returns the tasks of the first slot strictly after last, and its time.
+// The periods are aligned on the clock: a task keeps its slot across the reloads.
+func (tp *TickerPool) nextSlot(last time.Time) ([]teststruct.Task, time.Time) {
 
-}
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
 
-// processAllTasks launched at each Tick :
-// Run all TickerPool tests in independent goroutines.
-// Fine timeout management is managed as close as possible to the probe.
-func (tp *TickerPool) processAllTasks2() {
+	if len(tp.order) == 0 {
+		return nil, time.Time{}
+	}
 
-	utils.Log.WithFields(log.Fields{
-		"package": "ticker",
-	}).Debugf("Ticker %s START at %s with %d Tasks ", tp.frequency.String(), time.Now().Format(time.RFC3339), len(tp.Tasks))
+	period := last.Truncate(tp.frequency)
+	elapsed := last.Sub(period)
+	i := sort.Search(len(tp.order), func(i int) bool { return tp.order[i].offset > elapsed })
+	if i == len(tp.order) {
+		period = period.Add(tp.frequency)
+		i = 0
+	}
 
-	// Task scheduling is an important part, for now it's very simple and limited.
-	// More refined way to avoid spikes will be engaged.
+	// Tasks sharing the same offset
+	offset := tp.order[i].offset
+	tasks := make([]teststruct.Task, 0, 1)
+	for ; i < len(tp.order) && tp.order[i].offset == offset; i++ {
+		tasks = append(tasks, tp.order[i].task)
+	}
+	return tasks, period.Add(offset)
+}
 
-	for i, _ := range tp.Tasks {
+// run sends each task to the scheduler at its offset in each period,
+// the tasks added or removed are taken into account at once.
+func (tp *TickerPool) run(start time.Time) {
+
+	last := start
+	for {
+		tasks, due := tp.nextSlot(last)
 
-		tp.chanToScheduler <- tp.Tasks[i].task
+		// Empty TickerPool: waits for tasks
+		wait := time.Duration(1<<63 - 1)
+		if len(tasks) > 0 {
+			wait = time.Until(due)
+		}
+		timer := time.NewTimer(wait)
 
+		select {
+		case <-tp.close:
+			timer.Stop()
+			return
+		case <-tp.wake:
+			timer.Stop()
+			// The slots until now are passed, except the one due
+			now := time.Now()
+			if len(tasks) > 0 && !now.Before(due) {
+				now = due.Add(-1)
+			}
+			if now.After(last) {
+				last = now
+			}
+			continue
+		case <-timer.C:
+		}
+
+		for _, task := range tasks {
+			if !tp.deliver(task) {
+				return
+			}
+		}
+		last = due
 	}
 }
 
-// processAllTasks launched at each Tick :
-// Run all TickerPool tests in independent goroutines.
-// Fine timeout management is managed as close as possible to the probe.
-func (tp *TickerPool) processAllTasks() {
+// send sends the scheduled tasks once.
+func (tp *TickerPool) send(tasks []scheduled) {
 
-	utils.Log.WithFields(log.Fields{
-		"package": "ticker",
-	}).Debugf("Ticker %s START at %s with %d Tasks ", tp.frequency.String(), time.Now().Format(time.RFC3339), len(tp.Tasks))
+	for _, s := range tasks {
+		if !tp.sendAt(s.task, s.due) {
+			return
+		}
+	}
+}
 
-	// Task scheduling is an important part, for now it's very simple and limited.
-	// More refined way to avoid spikes will be engaged.
+// sendAt waits until due then sends the task to the scheduler if it is still in the TickerPool,
+// it returns false if the TickerPool is stopped.
+func (tp *TickerPool) sendAt(t *tPoolTasker, due time.Time) bool {
 
-	// To avoid spikes at each tick a little pause is interleaved between the tests.
-	// The pause scheduling_delay is calculated from 5% freq, otherwise for 24H freq the pause are too long
+	timer := time.NewTimer(time.Until(due))
+	defer timer.Stop()
 
-	// Dummy leapPause calc
-	maxInterval := float64(tp.frequency.Nanoseconds()) * 0.05
-	leapPause := time.Duration(int64(maxInterval) / int64(len(tp.Tasks)))
+	select {
+	case <-timer.C:
+	case <-tp.close:
+		return false
+	}
 
-	for i, _ := range tp.Tasks {
+	tp.mu.Lock()
+	task, present := t.task, tp.Tasks[t.task.TestStep.ID] == t
+	tp.mu.Unlock()
+	if !present {
+		return true
+	}
+	return tp.deliver(task)
+}
+
+// deliver sends the task to the scheduler,
+// it returns false if the TickerPool is stopped.
+func (tp *TickerPool) deliver(task teststruct.Task) bool {
 
-		go func(t *tPoolTasker) {
-			t.applyReSync()
-			process.ProcessTask(t.task)
-		}(tp.Tasks[i])
+	// Outside its active hours, failing or paused, the task skips its slot
+	if !task.TestStep.IsActive(time.Now()) || tp.failures.IsFailing(task.TestStep.ID) || isPaused(task) {
+		return true
+	}
 
-		// Sleep leapPause before another run
-		time.Sleep(leapPause)
+	// Blocks while the scheduler queue is full
+	select {
+	case tp.chanToScheduler <- task:
+		return true
+	case <-tp.close:
+		return false
 	}
-	/*
-		for i, _ := range tp.Tasks {
+}
 
-			go func() {
-				tp.Tasks[i].applyReSync()
-				process.ProcessTask(tp.Tasks[i].task)
-			}()
+// isPaused tells if the task is paused through the API,
+// a task without its TestSuite and TestCase cannot be.
+func isPaused(task teststruct.Task) bool {
+	if task.TestSuite == nil || task.TestCase == nil {
+		return false
+	}
+	return pause.Mgr.IsPaused(task.UIDs())
+}
 
-			// Sleep leapPause before another run
-			time.Sleep(leapPause)
-		}
-	*/
+// Stop the TickerPool
+func (tp *TickerPool) Stop() {
+	// Stop the tick
+	close(tp.close)
 }
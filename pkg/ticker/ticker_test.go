@@ -0,0 +1,200 @@
+package ticker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+func Test_startupTasks(t *testing.T) {
+
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	tasks := []*tPoolTasker{
+		{offset: 5 * time.Second},                     // slot soon
+		{offset: 20 * time.Minute},                    // never run, slot far
+		{offset: 30 * time.Minute, reSync: time.Hour}, // run before the reload
+		{offset: 40 * time.Minute, reSync: -time.Minute},
+	}
+
+	early := startupTasks(tasks, time.Hour, start)
+	if len(early) != 2 || early[0].task != tasks[1] || early[1].task != tasks[3] {
+		t.Fatalf("startupTasks() = %+v, want the 2nd and the 4th tasks", early)
+	}
+	for _, s := range early {
+		if s.due.Before(start) || s.due.Sub(start) >= startupSpread {
+			t.Errorf("startupTasks() due = %s, want within %s", s.due, startupSpread)
+		}
+	}
+}
+
+func TestTickerPool_run(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	freq := 60 * time.Millisecond
+	ch := make(chan teststruct.Task)
+	tp := newTickerPool(freq, ch, nil)
+	for _, ms := range []uint64{10, 30, 50} {
+		tp.AddTask(teststruct.Task{TestStep: &teststruct.TestStep{ID: ms * uint64(time.Millisecond)}})
+	}
+	tp.Start()
+	defer tp.Stop()
+
+	// Each task is sent once per period, at its offset
+	var ids []uint64
+	for len(ids) < 6 {
+		task := <-ch
+		offset := time.Duration(time.Now().UnixNano()) % freq
+		if d := offset - teststruct.ScheduleOffset(task.TestStep.ID, freq); d < 0 || d > 20*time.Millisecond {
+			t.Errorf("task %d sent at %s in the period, want %s", task.TestStep.ID, offset, teststruct.ScheduleOffset(task.TestStep.ID, freq))
+		}
+		ids = append(ids, task.TestStep.ID)
+	}
+	for i := 3; i < 6; i++ {
+		if ids[i] != ids[i-3] {
+			t.Errorf("sent %v, want the same order in each period", ids)
+		}
+	}
+
+	// Tasks added and removed while running
+	ms := uint64(time.Millisecond)
+	tp.AddTask(teststruct.Task{TestStep: &teststruct.TestStep{ID: 40 * ms}})
+	if left := tp.RemoveTask(30 * ms); left != 3 {
+		t.Fatalf("RemoveTask() = %d tasks left, want 3", left)
+	}
+	for task := <-ch; task.TestStep.ID != 10*ms; task = <-ch {
+	}
+	want := []uint64{40 * ms, 50 * ms, 10 * ms}
+	for _, id := range want {
+		if task := <-ch; task.TestStep.ID != id {
+			t.Errorf("sent %d, want %d", task.TestStep.ID, id)
+		}
+	}
+}
+
+func TestTickerPoolManager_sync(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	task := func(id uint64, freq time.Duration, tc *teststruct.TestCase) teststruct.Task {
+		tStep := &teststruct.TestStep{ID: id}
+		tStep.ProbeWrap.Frequency = freq
+		return teststruct.Task{TestCase: tc, TestStep: tStep}
+	}
+	t1, t2, t3 := task(1, time.Minute, nil), task(2, time.Hour, nil), task(3, time.Minute, nil)
+
+	tpm := NewTickerPoolManager(make(chan teststruct.Task), nil)
+	if added, removed := tpm.sync(map[uint64]teststruct.Task{1: t1, 2: t2}); added != 2 || removed != 0 {
+		t.Fatalf("sync() = %d added, %d removed, want 2, 0", added, removed)
+	}
+	kept := tpm.tickerPools[time.Minute].Tasks[1]
+
+	// The step 1 is kept in a new TestCase, 2 is removed and 3 is added
+	t1bis := teststruct.Task{TestCase: &teststruct.TestCase{}, TestStep: t1.TestStep}
+	if added, removed := tpm.sync(map[uint64]teststruct.Task{1: t1bis, 3: t3}); added != 1 || removed != 1 {
+		t.Fatalf("sync() = %d added, %d removed, want 1, 1", added, removed)
+	}
+	if tpm.IsTickerPool(time.Hour) {
+		t.Error("sync() expected the empty TickerPool to be removed")
+	}
+	if got := tpm.tickerPools[time.Minute].Tasks[1]; got != kept || got.task.TestCase != t1bis.TestCase {
+		t.Error("sync() expected the kept step to keep its slot with its new TestCase")
+	}
+	if _, present := tpm.tickerPools[time.Minute].Tasks[3]; !present {
+		t.Error("sync() expected the new step to be scheduled")
+	}
+}
+
+func TestTickerPoolManager_AddTask(t *testing.T) {
+
+	cron := &teststruct.Schedule{Cron: "30 2 * * *"}
+	if err := cron.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tpm := NewTickerPoolManager(make(chan teststruct.Task), nil)
+	if err := tpm.AddTickerPool(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	tStep := &teststruct.TestStep{ID: 1}
+	tStep.ProbeWrap.Frequency = time.Minute
+	cronStep := &teststruct.TestStep{ID: 2, Schedule: cron}
+	tpm.AddTask(teststruct.Task{TestStep: tStep})
+	tpm.AddTask(teststruct.Task{TestStep: cronStep})
+
+	if _, present := tpm.tickerPools[time.Minute].Tasks[1]; !present {
+		t.Error("AddTask() expected the step in the TickerPool of its frequency")
+	}
+	if _, present := tpm.cronPool.Tasks[2]; !present || len(tpm.tickerPools[time.Minute].Tasks) != 1 {
+		t.Error("AddTask() expected the cron step in the CronPool only")
+	}
+}
+
+func Test_nextInterval(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		backoff  float64
+		want     time.Duration
+	}{
+		{name: "constant", interval: 10 * time.Second, backoff: 1, want: 10 * time.Second},
+		{name: "doubled", interval: 10 * time.Second, backoff: 2, want: 20 * time.Second},
+		{name: "up to the frequency", interval: 40 * time.Second, backoff: 2, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pw := teststruct.ProbeWrap{Frequency: time.Minute, FailureBackoff: tt.backoff}
+			if got := nextInterval(tt.interval, pw); got != tt.want {
+				t.Errorf("nextInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailurePool_update(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	tStep := &teststruct.TestStep{ID: 1, Status: teststruct.Failure}
+	tStep.ProbeWrap = teststruct.ProbeWrap{Frequency: time.Hour, FailureFrequency: 10 * time.Second, FailureBackoff: 2}
+	task := teststruct.Task{TestStep: tStep}
+
+	fp := newFailurePool(nil, nil)
+	fp.AddTask(task)
+
+	// Unknown steps are ignored
+	fp.update(teststruct.Task{TestStep: &teststruct.TestStep{ID: 2, Status: teststruct.Failure}}, now)
+	if fp.IsFailing(2) {
+		t.Error("update() expected an unknown step not to be moved")
+	}
+
+	fp.update(task, now)
+	if !fp.IsFailing(1) {
+		t.Fatal("update() expected the failing step to be moved")
+	}
+
+	if tasks, next := fp.due(now.Add(5 * time.Second)); len(tasks) != 0 || !next.Equal(now.Add(10*time.Second)) {
+		t.Errorf("due() = %d tasks, next %s, want none before %s", len(tasks), next, now.Add(10*time.Second))
+	}
+	at := now.Add(10 * time.Second)
+	if tasks, next := fp.due(at); len(tasks) != 1 || !next.Equal(at.Add(10*time.Second)) {
+		t.Errorf("due() = %d tasks, next %s, want 1 then %s", len(tasks), next, at.Add(10*time.Second))
+	}
+	// The interval is doubled after each run
+	at = at.Add(10 * time.Second)
+	if _, next := fp.due(at); !next.Equal(at.Add(20 * time.Second)) {
+		t.Errorf("due() next = %s, want %s", next, at.Add(20*time.Second))
+	}
+
+	tStep.Status = teststruct.Success
+	fp.update(task, now)
+	if fp.IsFailing(1) {
+		t.Error("update() expected the recovered step back to its TickerPool")
+	}
+}
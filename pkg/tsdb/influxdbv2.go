@@ -120,7 +120,9 @@ func (idb *vInfluxDBv2) WritePoint(task teststruct.Task, vr *teststruct.VigieRes
 	writeAPI := c.WriteAPIBlocking(idb.conf.Organization, idb.conf.Bucket)
 
 	// Point
-	task.RLockAll()
+	task.TestStep.Mutex.RLock()
+	lastChange := task.TestStep.LastChange
+	task.TestStep.Mutex.RUnlock()
 
 	// Push the Step Results to InfluxDB
 	utils.Log.WithFields(logrus.Fields{
@@ -132,10 +134,17 @@ func (idb *vInfluxDBv2) WritePoint(task teststruct.Task, vr *teststruct.VigieRes
 
 	// Create a Time series data aka points for InfluxDB
 	// TAGS are used to identify a task in the DB for later queries
-	taskTags := tags
+	taskTags := make(map[string]string, len(tags)+4)
+	for k, v := range tags {
+		taskTags[k] = v
+	}
 	taskTags["testsuite"] = task.TestSuite.Name
 	taskTags["testcase"] = task.TestCase.Name
 	taskTags["teststep"] = task.TestStep.Name
+	// Results of a step in maintenance
+	if vr.Maintenance != "" {
+		taskTags["maintenance"] = vr.Maintenance
+	}
 
 	//var wg sync.WaitGroup
 	//wg.Add(len(vr.TestResults))
@@ -145,12 +154,21 @@ func (idb *vInfluxDBv2) WritePoint(task teststruct.Task, vr *teststruct.VigieRes
 		x := utils.MergeTagMaps(taskTags, vigieRes.ProbeReturn.Labels())
 		//	go func(vr teststruct.TestResult) {
 
+		// Probe values and the status of this result (success, warning, assert_failure ...)
+		fields := make(map[string]interface{})
+		for k, v := range vigieRes.ProbeReturn.Values() {
+			fields[k] = v
+		}
+		fields["teststatus"] = vigieRes.Status.Int()
+		// Number of runs of the probe, more than 1 if retried
+		fields["attempts"] = len(vr.Attempts)
+
 		// create data point
 		p := influxdb2.NewPoint(
 			task.TestStep.ProbeWrap.Probe.GetName(), // TODO : Trouver un meuilleur porteur du nommage de "metric"
 			x,                                       // TODO InfluxDB Opti : Ordonner Key A-Z
-			vigieRes.ProbeReturn.Values(),
-			task.TestStep.LastChange)
+			fields,
+			lastChange)
 
 		// write synchronously for now
 		err := writeAPI.WritePoint(context.Background(), p)
@@ -164,8 +182,6 @@ func (idb *vInfluxDBv2) WritePoint(task teststruct.Task, vr *teststruct.VigieRes
 	}
 	//wg.Wait()
 
-	task.RUnlockAll()
-
 	// Ensures background processes finishes
 	return nil
 }
@@ -57,8 +57,6 @@ func (w *warp10) validateConnection() error {
 
 func (w *warp10) WritePoint(task teststruct.Task, tags map[string]string) error {
 
-	task.RLockAll()
-
 	// Push the Step Results to InfluxDB
 	utils.Log.WithFields(logrus.Fields{
 		"package":   "process",
@@ -80,7 +78,6 @@ func (w *warp10) WritePoint(task teststruct.Task, tags map[string]string) error
 
 	w.insertTestToDB(gtsPayload)
 
-	task.RUnlockAll()
 	return nil
 
 }
@@ -156,7 +153,11 @@ func (w *warp10) genPayload(task teststruct.Task) (string, error) {
 	// TAGS are used to identify a task in the DB for later queries
 	taskTags := buildTags(task)
 
-	for _, vr := range task.TestStep.VigieResults {
+	task.TestStep.Mutex.RLock()
+	results, lastAttempt := task.TestStep.VigieResults, task.TestStep.LastAttempt
+	task.TestStep.Mutex.RUnlock()
+
+	for _, vr := range results {
 
 		metricValue, err := buildValue(vr)
 		if err != nil {
@@ -164,7 +165,7 @@ func (w *warp10) genPayload(task teststruct.Task) (string, error) {
 		}
 
 		gtsPoint := geoTimeSeries{
-			Timestamp:  task.TestStep.LastAttempt.UnixNano() / 1000, // Microsec
+			Timestamp:  lastAttempt.UnixNano() / 1000, // Microsec
 			Metric:     fmt.Sprint(w.conf.Prefix, "teststep"),
 			Tags:       strings.Join(taskTags, ","),
 			MultiValue: metricValue,
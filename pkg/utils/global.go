@@ -1,4 +1,4 @@
 package utils
 
-var ALLVARS map[string][]string
+var ALLVARS map[string]interface{}
 var TEMPPATH string
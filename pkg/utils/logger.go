@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/sirupsen/logrus"
+	"github.com/vincoll/vigie/pkg/secret"
 )
 
 // https://stackoverflow.com/questions/47737242/share-object-from-in-local-package
@@ -61,6 +62,9 @@ func InitLogger(lconf LogConf) {
 		}
 	}
 
+	// The values of the secrets are never logged
+	logger.AddHook(secret.LogHook{})
+
 	logger.WithFields(logrus.Fields{
 		"package": "logger",
 	}).Tracef("Logger is set to : %s", logger.Level.String())
@@ -0,0 +1,180 @@
+package timeutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression: minute hour day-of-month month day-of-week.
+// Fields accept *, values, ranges (1-5), lists (1,15) and steps (*/10, 0-30/5),
+// the macros @yearly, @monthly, @weekly, @daily and @hourly are supported.
+type Cron struct {
+	expr                         string
+	minute, hour, dom, month, dw uint64
+	domStar, dowStar             bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression.
+func ParseCron(expr string) (Cron, error) {
+
+	c := Cron{expr: expr}
+
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return Cron{}, fmt.Errorf("cron %q must have 5 fields: minute hour day-of-month month day-of-week", expr)
+	}
+
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return Cron{}, fmt.Errorf("cron %q minute: %s", expr, err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return Cron{}, fmt.Errorf("cron %q hour: %s", expr, err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return Cron{}, fmt.Errorf("cron %q day-of-month: %s", expr, err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return Cron{}, fmt.Errorf("cron %q month: %s", expr, err)
+	}
+	if c.dw, err = parseCronField(fields[4], 0, 7); err != nil {
+		return Cron{}, fmt.Errorf("cron %q day-of-week: %s", expr, err)
+	}
+	// 7 is also Sunday
+	if c.dw&(1<<7) != 0 {
+		c.dw |= 1
+	}
+	c.domStar = fields[2] == "*"
+	c.dowStar = fields[4] == "*"
+
+	return c, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			step = s
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", bounds[1])
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = v, v
+			if step > 1 {
+				// 5/15 is 5-max/15
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%d-%d is out of range [%d-%d]", lo, hi, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c Cron) String() string {
+	return c.expr
+}
+
+// IsZero tells if the Cron has not been parsed.
+func (c Cron) IsZero() bool {
+	return c.minute == 0
+}
+
+// dayMatch applies the cron rule: if both day fields are restricted, either can match.
+func (c Cron) dayMatch(t time.Time) bool {
+
+	domOK := c.dom&(1<<uint(t.Day())) != 0
+	dowOK := c.dw&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// Match tells if the minute of t is matched by the cron.
+func (c Cron) Match(t time.Time) bool {
+	return c.minute&(1<<uint(t.Minute())) != 0 &&
+		c.hour&(1<<uint(t.Hour())) != 0 &&
+		c.month&(1<<uint(t.Month())) != 0 &&
+		c.dayMatch(t)
+}
+
+// Next returns the first minute matched by the cron strictly after t, in the location of t.
+// A zero time is returned if nothing matches within 5 years (e.g. 30 February).
+func (c Cron) Next(t time.Time) time.Time {
+
+	if c.IsZero() {
+		return time.Time{}
+	}
+
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatch(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,66 @@
+package timeutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "0 2 * * *"},
+		{expr: "*/15 0-6 1,15 * 1-5"},
+		{expr: "30 4 * * 7"},
+		{expr: "@daily"},
+		{expr: "0 2 * *", wantErr: true},
+		{expr: "60 2 * * *", wantErr: true},
+		{expr: "0 2 * * mon", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "5-1 * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+
+	// Monday 2021-03-01
+	from := time.Date(2021, 3, 1, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "*/15 * * * *", want: time.Date(2021, 3, 1, 10, 15, 0, 0, time.UTC)},
+		{expr: "0 2 * * *", want: time.Date(2021, 3, 2, 2, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * 0", want: time.Date(2021, 3, 7, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * 7", want: time.Date(2021, 3, 7, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 * *", want: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Day-of-month or day-of-week
+		{expr: "0 0 15 * 3", want: time.Date(2021, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,21 +10,34 @@ import (
 // BY NAME
 
 func (v *Vigie) GetTestSuiteByName(name string) (teststruct.TSDescribe, error) {
-	for _, ts := range v.TestSuites {
+	for _, ts := range v.State.Snapshot().Suites {
 		if ts.Name == name {
-			return ts.ToJSON(), nil
+			return ts.Describe(), nil
 		}
 	}
 	return teststruct.TSDescribe{}, fmt.Errorf("testsuite %q not found", name)
 }
 
+// ALL
+
+// GetAllTestSuites returns every TestSuite with its TestCases and TestSteps.
+func (v *Vigie) GetAllTestSuites() []teststruct.TSDescribe {
+
+	snap := v.State.Snapshot()
+	tsList := make([]teststruct.TSDescribe, 0, len(snap.Suites))
+	for _, ts := range snap.Suites {
+		tsList = append(tsList, ts.Describe())
+	}
+	return tsList
+}
+
 // One BY ID
 
 func (v *Vigie) GetTestSuiteByID(tsID uint64) (teststruct.TSDescribe, error) {
 
-	ts, found := v.TestSuites[tsID]
+	ts, found := v.State.Snapshot().Suites[tsID]
 	if found {
-		return ts.ToJSON(), nil
+		return ts.Describe(), nil
 	} else {
 		return teststruct.TSDescribe{}, fmt.Errorf("testsuite ID: %d not found", tsID)
 	}
@@ -33,11 +46,11 @@ func (v *Vigie) GetTestSuiteByID(tsID uint64) (teststruct.TSDescribe, error) {
 
 func (v *Vigie) GetTestCaseByID(tsID, tcID uint64) (teststruct.TCDescribe, error) {
 
-	ts, tsfound := v.TestSuites[tsID]
+	ts, tsfound := v.State.Snapshot().Suites[tsID]
 	if tsfound {
-		tc, tcfound := ts.TestCases[tcID]
+		tc, tcfound := ts.Cases[tcID]
 		if tcfound {
-			return tc.ToJSON(), nil
+			return tc.Describe(), nil
 		} else {
 			return teststruct.TCDescribe{}, fmt.Errorf("testcase ID %d in testsuite ID %d not found", tsID, tcID)
 		}
@@ -50,13 +63,13 @@ func (v *Vigie) GetTestCaseByID(tsID, tcID uint64) (teststruct.TCDescribe, error
 
 func (v *Vigie) GetTestStepByID(tsID, tcID, tstpID uint64) (teststruct.TStepDescribe, error) {
 
-	ts, tsfound := v.TestSuites[tsID]
+	ts, tsfound := v.State.Snapshot().Suites[tsID]
 	if tsfound {
-		tc, tcfound := ts.TestCases[tcID]
+		tc, tcfound := ts.Cases[tcID]
 		if tcfound {
-			tstp, tstpfound := tc.TestSteps[tstpID]
+			tstp, tstpfound := tc.Steps[tstpID]
 			if tstpfound {
-				return tstp.ToTestStepDescribe(), nil
+				return tstp.Describe(), nil
 			} else {
 				return teststruct.TStepDescribe{}, fmt.Errorf("teststep ID %d not found in testcase ID %d in testsuite ID %d", tstpID, tcID, tsID)
 			}
@@ -79,74 +92,94 @@ func (v *Vigie) GetTestByUID(uID string) (*teststruct.UIDTest, error) {
 	}
 	splitUID := strings.Split(uID, "-")
 
-	switch len(splitUID) {
-	case 1:
-		ts, found := v.TestSuites[idTS]
-		if !found {
-			return nil, fmt.Errorf("testsuite ID: %d not found", idTS)
-		}
-		return &teststruct.UIDTest{TestSuite: ts.ToHeader()}, nil
+	// The TestSuite, TestCase and TestStep are read from the same snapshot
+	snap := v.State.Snapshot()
 
-	case 2:
-		ts, foundTS := v.TestSuites[idTS]
-		if !foundTS {
-			return nil, fmt.Errorf("testsuite ID: %d not found", idTS)
+	ts, foundTS := snap.Suites[idTS]
+	if !foundTS {
+		return nil, fmt.Errorf("testsuite ID: %d not found", idTS)
+	}
+	if len(splitUID) == 1 {
+		return &teststruct.UIDTest{TestSuite: ts.TSHeader}, nil
+	}
+
+	tc, foundTC := ts.Cases[idTC]
+	if !foundTC {
+		return nil, fmt.Errorf("testcase ID: %d not found", idTC)
+	}
+	if len(splitUID) == 2 {
+		return &teststruct.UIDTest{TestSuite: ts.TSHeader, TestCase: tc.TCHeader}, nil
+	}
+
+	tstp, foundTStep := tc.Steps[idTStep]
+	if !foundTStep {
+		return nil, fmt.Errorf("teststep ID: %d not found", idTStep)
+	}
+	return &teststruct.UIDTest{TestSuite: ts.TSHeader, TestCase: tc.TCHeader, TestStep: tstp.Describe()}, nil
+}
+
+// TasksByUID returns the tasks of the TestSteps of a TestSuite, a TestCase or a TestStep.
+func (v *Vigie) TasksByUID(uID string) ([]teststruct.Task, error) {
+
+	idTS, idTC, idTStep, err := GetCleanUID(uID)
+	if err != nil {
+		return nil, err
+	}
+	depth := len(strings.Split(uID, "-"))
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	ts, found := v.TestSuites[idTS]
+	if !found {
+		return nil, fmt.Errorf("testsuite ID: %d not found", idTS)
+	}
+
+	tasks := make([]teststruct.Task, 0)
+	for _, tc := range ts.TestCases {
+		if depth > 1 && tc.ID != idTC {
+			continue
 		}
-		tc, foundTC := v.TestSuites[idTS].TestCases[idTC]
-		if !foundTC {
-			return nil, fmt.Errorf("testcase ID: %d not found", idTC)
+		for _, tstp := range tc.TestSteps {
+			if depth > 2 && tstp.ID != idTStep {
+				continue
+			}
+			tasks = append(tasks, teststruct.Task{TestSuite: ts, TestCase: tc, TestStep: tstp})
 		}
-		return &teststruct.UIDTest{TestSuite: ts.ToHeader(), TestCase: tc.ToHeader()}, nil
+	}
 
-	case 3:
-		ts, foundTS := v.TestSuites[idTS]
-		if !foundTS {
-			return nil, fmt.Errorf("testsuite ID: %d not found", idTS)
-		}
-		tc, foundTC := v.TestSuites[idTS].TestCases[idTC]
-		if !foundTC {
+	if len(tasks) == 0 {
+		switch depth {
+		case 2:
 			return nil, fmt.Errorf("testcase ID: %d not found", idTC)
-		}
-		tstp, foundTStep := v.TestSuites[idTS].TestCases[idTC].TestSteps[idTStep]
-		if !foundTStep {
+		case 3:
 			return nil, fmt.Errorf("teststep ID: %d not found", idTStep)
 		}
-
-		return &teststruct.UIDTest{TestSuite: ts.ToHeader(), TestCase: tc.ToHeader(), TestStep: tstp.ToTestStepDescribe()}, nil
-
 	}
-
-	return nil, nil
+	return tasks, nil
 }
 
 // Header List BY ID
 
 func (v *Vigie) GetTestSuitesList() ([]teststruct.TSHeader, error) {
 
-	var tsListHeader = make([]teststruct.TSHeader, 0, len(v.TestSuites))
-	v.mu.RLock()
-	for _, tSuite := range v.TestSuites {
-		tsListHeader = append(tsListHeader, tSuite.ToHeader())
+	snap := v.State.Snapshot()
+	var tsListHeader = make([]teststruct.TSHeader, 0, len(snap.Suites))
+	for _, tSuite := range snap.Suites {
+		tsListHeader = append(tsListHeader, tSuite.TSHeader)
 	}
-	v.mu.RUnlock()
 
 	return tsListHeader, nil
 }
 
 func (v *Vigie) GetTestCasesList(tsID uint64) ([]teststruct.TCHeader, error) {
 
-	v.mu.RLock()
-	defer v.mu.RUnlock()
-
-	_, tsfound := v.TestSuites[tsID]
+	ts, tsfound := v.State.Snapshot().Suites[tsID]
 	if tsfound {
-		v.TestSuites[tsID].Mutex.RLock()
-
-		tcListHeader := make([]teststruct.TCHeader, 0, len(v.TestSuites[tsID].TestCases))
-		for _, tc := range v.TestSuites[tsID].TestCases {
-			tcListHeader = append(tcListHeader, tc.ToHeader())
+		tcListHeader := make([]teststruct.TCHeader, 0, len(ts.Cases))
+		for _, tc := range ts.Cases {
+			tcListHeader = append(tcListHeader, tc.TCHeader)
 		}
-		v.TestSuites[tsID].Mutex.RUnlock()
 
 		return tcListHeader, nil
 
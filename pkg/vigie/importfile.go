@@ -4,8 +4,8 @@ import (
 	"fmt"
 	consul "github.com/hashicorp/consul/api"
 	log "github.com/sirupsen/logrus"
+	"github.com/vincoll/vigie/pkg/pause"
 	"github.com/vincoll/vigie/pkg/teststruct"
-	"github.com/vincoll/vigie/pkg/ticker"
 	"github.com/vincoll/vigie/pkg/utils"
 	"strconv"
 	"time"
@@ -26,7 +26,10 @@ func (v *Vigie) loadAndRun2(newTSs map[uint64]*teststruct.TestSuite) error {
 	//
 	// Import a new set of Testsuites
 	//
-	importedTS, anyChanges := v.ImportAllTestSuites(newTSs)
+	// The TestSuites kept are merged in the state manager: the runs are not applied meanwhile
+	var importedTS map[uint64]*teststruct.TestSuite
+	var anyChanges bool
+	v.State.Update(func() { importedTS, anyChanges = v.ImportAllTestSuites(newTSs) })
 	if anyChanges == false {
 		// No changes, no need to swap, keep the Vigie state as it is
 		utils.Log.WithFields(log.Fields{
@@ -37,14 +40,10 @@ func (v *Vigie) loadAndRun2(newTSs map[uint64]*teststruct.TestSuite) error {
 
 		return nil
 	}
-	//
-	// Create new TickerPools
-	//
-	TPMngr := ticker.NewTickerPoolManager(v.TickerPoolManager.ChanToSched)
-
-	TPMngr.ImportTS(importedTS)
 
-	//utils.Log.Debug("New Tickerpool generated")
+	// Now that TS are set, we need to swap the old and running Vigie state
+	// by the new state, and update the scheduling of the TestSteps.
+	v.swapStateAndRun(importedTS)
 
 	elapsed := time.Since(start)
 	utils.Log.WithFields(log.Fields{
@@ -54,11 +53,6 @@ func (v *Vigie) loadAndRun2(newTSs map[uint64]*teststruct.TestSuite) error {
 		"value":   elapsed.Seconds(),
 	}).Debugf("TOTAL Load Testsuite and generate scheduling duration: %s", elapsed)
 
-	// Now that TS and TickerPools are set, we need to
-	// swap the old and running Vigie state by the new state.
-
-	v.swapStateAndRun(importedTS, TPMngr)
-
 	return nil
 }
 
@@ -95,7 +89,10 @@ func (v *Vigie) loadAndRun() error {
 	//
 	// Import a new set of Testsuites
 	//
-	importedTS, anyChanges := v.ImportAllTestSuites(newTSs)
+	// The TestSuites kept are merged in the state manager: the runs are not applied meanwhile
+	var importedTS map[uint64]*teststruct.TestSuite
+	var anyChanges bool
+	v.State.Update(func() { importedTS, anyChanges = v.ImportAllTestSuites(newTSs) })
 	if anyChanges == false {
 		// No changes, no need to swap, keep the Vigie state as it is
 		utils.Log.WithFields(log.Fields{
@@ -106,14 +103,10 @@ func (v *Vigie) loadAndRun() error {
 
 		return nil
 	}
-	//
-	// Create new TickerPools
-	//
-	TPMngr := ticker.NewTickerPoolManager(v.TickerPoolManager.ChanToSched)
-
-	TPMngr.ImportTS(importedTS)
 
-	//utils.Log.Debug("New Tickerpool generated")
+	// Now that TS are set, we need to swap the old and running Vigie state
+	// by the new state, and update the scheduling of the TestSteps.
+	v.swapStateAndRun(importedTS)
 
 	elapsed := time.Since(start)
 	utils.Log.WithFields(log.Fields{
@@ -123,11 +116,6 @@ func (v *Vigie) loadAndRun() error {
 		"value":   elapsed.Seconds(),
 	}).Debugf("TOTAL Load Testsuite and generate scheduling duration: %s", elapsed)
 
-	// Now that TS and TickerPools are set, we need to
-	// swap the old and running Vigie state by the new state.
-
-	v.swapStateAndRun(importedTS, TPMngr)
-
 	return nil
 }
 
@@ -223,13 +211,16 @@ func (v *Vigie) pushTestsToConsul(TSs map[uint64]*teststruct.TestSuite) error {
 // ImportAllTestCases will add new TCs, remove oldTC that are absent from the new TCs, keep common TCs
 func (v *Vigie) ImportAllTestSuites(newTSs map[uint64]*teststruct.TestSuite) (TSs map[uint64]*teststruct.TestSuite, anyChanges bool) {
 
+	// Taken before the old TestSuites get the new TestCases
+	oldUIDs := uIDsByName(v.TestSuites)
+
 	// Compilation to avoid multiples loops
 	newStateTS := make(map[string]uint64, 0)
 	for _, nts := range newTSs {
 		newStateTS[nts.Name] = nts.ID
 	}
 
-	// UPDATE
+	// UPDATE or REMOVE
 	for _, oTS := range v.TestSuites {
 		// If old TC name is in newTCs
 		if nTSid, alreadyExists := newStateTS[oTS.Name]; alreadyExists {
@@ -239,11 +230,30 @@ func (v *Vigie) ImportAllTestSuites(newTSs map[uint64]*teststruct.TestSuite) (TS
 				// That means that the old import have changed. And so TSteps.
 				// The full old TestCase state is keep, but we need to go deeper to update this TestCase.
 				anyChanges = true
-				newTSs[nTSid].ImportAllTestCases(oTS.TestCases)
+				// The old TestSuite merges its state into the new TestCases
+				oTS.ImportAllTestCases(newTSs[nTSid].TestCases)
+				// The status is kept until the next run
+				oTS.Mutex.RLock()
+				newTSs[nTSid].Status, newTSs[nTSid].LastChange = oTS.Status, oTS.LastChange
+				oTS.Mutex.RUnlock()
 			} else {
 				// Name and ID are identical = No Changes > replace newTS by "OldTS" to keep the state
 				newTSs[nTSid] = oTS
 			}
+		} else {
+			// Old TS absent from the new TSs
+			anyChanges = true
+		}
+	}
+
+	// ADD
+	oldStateTS := make(map[string]bool, len(v.TestSuites))
+	for _, oTS := range v.TestSuites {
+		oldStateTS[oTS.Name] = true
+	}
+	for _, nts := range newTSs {
+		if !oldStateTS[nts.Name] {
+			anyChanges = true
 		}
 	}
 
@@ -252,6 +262,15 @@ func (v *Vigie) ImportAllTestSuites(newTSs map[uint64]*teststruct.TestSuite) (TS
 		anyChanges = true
 	}
 
+	// The pauses follow their TestStructs: an edit changes their UIDs
+	if anyChanges {
+		if changed := pause.Mgr.Rekey(reloadedUIDs(oldUIDs, uIDsByName(newTSs))); changed > 0 {
+			utils.Log.WithFields(log.Fields{
+				"package": "vigie",
+			}).Infof("%d pauses moved to the reloaded TestStructs or dropped with the removed ones", changed)
+		}
+	}
+
 	return newTSs, anyChanges
 }
 
@@ -0,0 +1,167 @@
+package vigie
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vincoll/vigie/pkg/load"
+	"github.com/vincoll/vigie/pkg/state"
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/ticker"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+const suiteV1 = `
+name: web
+config:
+  frequency:
+    exec: 1m
+testcases:
+- name: kept
+  steps:
+  - name: ok
+    probe: {type: exec, command: ["echo", "kept"]}
+- name: edited
+  steps:
+  - name: first
+    probe: {type: exec, command: ["echo", "first"]}
+  - name: second
+    probe: {type: exec, command: ["echo", "second"]}
+- name: removed
+  steps:
+  - name: gone
+    probe: {type: exec, command: ["echo", "gone"]}
+`
+
+// The case "edited" has a step changed, "removed" is removed and "added" is added
+const suiteV2 = `
+name: web
+config:
+  frequency:
+    exec: 1m
+testcases:
+- name: kept
+  steps:
+  - name: ok
+    probe: {type: exec, command: ["echo", "kept"]}
+- name: edited
+  steps:
+  - name: first
+    probe: {type: exec, command: ["echo", "first"]}
+  - name: second
+    probe: {type: exec, command: ["echo", "second", "edited"]}
+- name: added
+  steps:
+  - name: new
+    probe: {type: exec, command: ["echo", "new"]}
+`
+
+// newTestVigie returns a Vigie without its API, Consul and temp folder.
+func newTestVigie() *Vigie {
+	return &Vigie{
+		TestSuites:        map[uint64]*teststruct.TestSuite{},
+		TickerPoolManager: ticker.NewTickerPoolManager(make(chan teststruct.Task, 100), nil),
+		State:             state.NewManager(),
+	}
+}
+
+// loadSuite imports a testsuite file written in dir, as the ImportManager does.
+func loadSuite(t *testing.T, dir, content string) map[uint64]*teststruct.TestSuite {
+
+	file := filepath.Join(dir, "web.yml")
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ts, err := load.NewUnMarshallTool(nil).ImportTestSuite(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[uint64]*teststruct.TestSuite{ts.ID: ts}
+}
+
+// findStep returns the TestStep of Vigie by its TestCase and name, nil if absent.
+func findStep(v *Vigie, tcName, stepName string) *teststruct.TestStep {
+
+	for _, ts := range v.TestSuites {
+		for _, tc := range ts.TestCases {
+			for _, tStep := range tc.TestSteps {
+				if tc.Name == tcName && tStep.Name == stepName {
+					return tStep
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestVigie_reload(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	dir, err := ioutil.TempDir("", "vigie-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	v := newTestVigie()
+	defer v.TickerPoolManager.StopEachTickerPool()
+	if err := v.loadAndRun2(loadSuite(t, dir, suiteV1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The steps have run before the reload
+	kept, first := findStep(v, "kept", "ok"), findStep(v, "edited", "first")
+	second, gone := findStep(v, "edited", "second"), findStep(v, "removed", "gone")
+	for _, tStep := range []*teststruct.TestStep{kept, first} {
+		tStep.Mutex.Lock()
+		tStep.Status = teststruct.Success
+		tStep.VigieResults = []teststruct.TestResult{{}}
+		tStep.Mutex.Unlock()
+	}
+
+	if err := v.loadAndRun2(loadSuite(t, dir, suiteV2)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		tc    string
+		step  string
+		want  *teststruct.TestStep // nil for a new TestStep
+		state bool                 // keeps its state
+	}{
+		{name: "unchanged case", tc: "kept", step: "ok", want: kept, state: true},
+		{name: "unchanged step of an edited case", tc: "edited", step: "first", want: first, state: true},
+		{name: "edited step", tc: "edited", step: "second"},
+		{name: "added case", tc: "added", step: "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findStep(v, tt.tc, tt.step)
+			if got == nil {
+				t.Fatalf("TestStep %s/%s is missing after the reload", tt.tc, tt.step)
+			}
+			if tt.want != nil && got != tt.want {
+				t.Error("the unchanged TestStep is replaced by a new one")
+			}
+			if tt.state && (got.GetStatus() != teststruct.Success || len(got.VigieResults) != 1) {
+				t.Errorf("TestStep status = %s with %d results, want its state kept", got.GetStatus(), len(got.VigieResults))
+			}
+			if !v.TickerPoolManager.IsScheduled(got.ID) {
+				t.Error("TestStep is not scheduled")
+			}
+		})
+	}
+
+	if findStep(v, "removed", "gone") != nil {
+		t.Error("the removed TestCase is still in Vigie")
+	}
+	for _, tStep := range []*teststruct.TestStep{gone, second} {
+		if v.TickerPoolManager.IsScheduled(tStep.ID) {
+			t.Errorf("removed TestStep %s is still scheduled", tStep.Name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package vigie
+
+import (
+	"time"
+
+	"github.com/vincoll/vigie/pkg/pause"
+	"github.com/vincoll/vigie/pkg/process"
+	"github.com/vincoll/vigie/pkg/teststruct"
+)
+
+// ApplyPauses sets the pauses on the TestSteps at once, without waiting for their next tick:
+// the steps paused get the Paused status, the steps resumed wait for their next run.
+func (v *Vigie) ApplyPauses() (changed int) {
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	v.State.Update(func() { changed = v.applyPauses() })
+	return changed
+}
+
+// applyPauses returns the count of TestSteps paused or resumed,
+// v.mu must be held and it must run in the state manager.
+func (v *Vigie) applyPauses() (changed int) {
+
+	now := time.Now()
+	for _, ts := range v.TestSuites {
+		for _, tc := range ts.TestCases {
+			for _, tStep := range tc.TestSteps {
+				task := teststruct.Task{TestSuite: ts, TestCase: tc, TestStep: tStep}
+
+				var uID string
+				if p, paused := pause.Mgr.Check(task.UIDs(), now); paused {
+					uID = p.UID
+				}
+				if process.ApplyPause(task, uID) {
+					changed++
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// reloadedUIDs maps the UIDs of the old TestSuites, TestCases and TestSteps to the UIDs
+// of the new ones with the same names (uIDsByName): an edit changes the UIDs, not the names.
+// A removed one is mapped to an empty UID. A name shared by several is ambiguous: not mapped.
+func reloadedUIDs(oldUIDs, newUIDs map[structName]string) map[string]string {
+
+	uIDs := make(map[string]string, len(oldUIDs))
+	for name, oldUID := range oldUIDs {
+		newUID, found := newUIDs[name]
+		if oldUID == "" || (found && newUID == "") {
+			continue
+		}
+		uIDs[oldUID] = newUID
+	}
+	return uIDs
+}
+
+// structName names a TestSuite, a TestCase (its TestSuite and its name) or a TestStep.
+type structName struct {
+	level                      int // 0 TestSuite, 1 TestCase, 2 TestStep
+	testsuite, testcase, tStep string
+}
+
+// uIDsByName returns the UIDs of the TestSuites, TestCases and TestSteps
+// by their names, empty if a name is not unique.
+func uIDsByName(tss map[uint64]*teststruct.TestSuite) map[structName]string {
+
+	uIDs := make(map[structName]string)
+	add := func(name structName, uID string) {
+		if prev, dup := uIDs[name]; dup && prev != uID {
+			uID = ""
+		}
+		uIDs[name] = uID
+	}
+
+	for _, ts := range tss {
+		for _, tc := range ts.TestCases {
+			for _, tStep := range tc.TestSteps {
+				task := teststruct.Task{TestSuite: ts, TestCase: tc, TestStep: tStep}
+				uID := task.UIDs()
+				add(structName{level: 0, testsuite: ts.Name}, uID[0])
+				add(structName{level: 1, testsuite: ts.Name, testcase: tc.Name}, uID[1])
+				add(structName{level: 2, testsuite: ts.Name, testcase: tc.Name, tStep: tStep.Name}, uID[2])
+			}
+		}
+	}
+	return uIDs
+}
@@ -0,0 +1,85 @@
+package vigie
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vincoll/vigie/pkg/pause"
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+// uIDOf returns the UIDs (TestSuite, TestCase, TestStep) of a TestStep of Vigie.
+func uIDOf(v *Vigie, tcName, stepName string) []string {
+
+	for _, ts := range v.TestSuites {
+		for _, tc := range ts.TestCases {
+			for _, tStep := range tc.TestSteps {
+				if tc.Name == tcName && tStep.Name == stepName {
+					task := teststruct.Task{TestSuite: ts, TestCase: tc, TestStep: tStep}
+					return task.UIDs()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestVigie_reloadPauses(t *testing.T) {
+
+	utils.InitLogger(utils.LogConf{})
+
+	dir, err := ioutil.TempDir("", "vigie-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	v := newTestVigie()
+	defer v.TickerPoolManager.StopEachTickerPool()
+	if err := v.loadAndRun2(loadSuite(t, dir, suiteV1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The TestCase kept, the TestStep edited and the TestStep removed are paused
+	for _, uID := range []string{uIDOf(v, "kept", "ok")[1], uIDOf(v, "edited", "second")[2], uIDOf(v, "removed", "gone")[2]} {
+		if _, err := pause.Mgr.Add(pause.Pause{UID: uID, Reason: "reload"}); err != nil {
+			t.Fatal(err)
+		}
+		defer func(uID string) { _ = pause.Mgr.Resume(uID) }(uID)
+	}
+	v.ApplyPauses()
+
+	// The edit changes the UIDs of the TestSuite and of all its children
+	if err := v.loadAndRun2(loadSuite(t, dir, suiteV2)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tc     string
+		step   string
+		paused string // UID of the pause, empty if not paused
+	}{
+		{tc: "kept", step: "ok", paused: uIDOf(v, "kept", "ok")[1]},
+		{tc: "edited", step: "second", paused: uIDOf(v, "edited", "second")[2]},
+		{tc: "edited", step: "first"},
+		{tc: "added", step: "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tc+"/"+tt.step, func(t *testing.T) {
+			got := findStep(v, tt.tc, tt.step)
+			if got.Paused != tt.paused {
+				t.Errorf("TestStep paused by %q, want %q", got.Paused, tt.paused)
+			}
+			if _, paused := pause.Mgr.Check(uIDOf(v, tt.tc, tt.step), got.LastChange); paused != (tt.paused != "") {
+				t.Errorf("Check() = %v, want %v", paused, tt.paused != "")
+			}
+		})
+	}
+
+	// The pause of the removed TestStep is dropped
+	if pauses := pause.Mgr.Pauses(); len(pauses) != 2 {
+		t.Errorf("Pauses() = %v, want the 2 pauses moved", pauses)
+	}
+}
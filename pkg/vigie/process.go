@@ -140,16 +140,40 @@ func (v *Vigie) receiveTests() {
 	}()
 }
 
-func (v *Vigie) swapStateAndRun(newTSs map[uint64]*teststruct.TestSuite, tpm *ticker.TickerPoolManager) {
+func (v *Vigie) swapStateAndRun(newTSs map[uint64]*teststruct.TestSuite) {
 
 	// Lock on Vigie has been made by the parent func.
 	utils.Log.Debug("Swap OLD / NEW TSs and TP")
 
-	// Stop and close Old Tickers Goroutines to avoid leak.
-	v.TickerPoolManager.StopEachTickerPool()
 	v.TestSuites = newTSs
-	v.TickerPoolManager = tpm
-	// (Re) initiate the tickers pools
+	v.State.Load(newTSs)
+
+	// Dependencies between the TestSteps are resolved on the new TestSuites
+	for _, err := range teststruct.IndexDependencies(newTSs) {
+		utils.Log.WithFields(log.Fields{
+			"package": "vigie",
+		}).Errorf("Invalid dependency: %s", err)
+	}
+
+	// Concurrency limits per probe type
+	if v.Scheduler != nil {
+		v.Scheduler.SetLimits(newTSs)
+	}
+
+	// The new TestSteps get their state saved before a restart
+	v.State.Update(v.restoreStates)
+
+	// The TestSteps kept are not rescheduled: they keep their slot,
+	// only the added and removed ones are (un)scheduled.
+	added, removed := v.TickerPoolManager.ImportTS(newTSs)
+	utils.Log.WithFields(log.Fields{
+		"package": "vigie",
+	}).Infof("Scheduling updated: %d TestSteps added, %d removed", added, removed)
+
+	// The pauses, moved to the new UIDs by ImportAllTestSuites, are set again on the new TestSteps
+	v.State.Update(func() { v.applyPauses() })
+
+	// Start the tickers pools on the first load
 	v.TickerPoolManager.StartEachTickerPool()
 	return
 }
@@ -184,7 +208,7 @@ func (v *Vigie) createTickerPools(nTS map[uint64]*teststruct.TestSuite) *ticker.
 	// On each TestSuites Collected
 	// createTickerPools TestCaseCount and Tickers ()
 
-	TPMngr := ticker.NewTickerPoolManager(v.TickerPoolManager.ChanToSched)
+	TPMngr := ticker.NewTickerPoolManager(v.TickerPoolManager.ChanToSched, v.TickerPoolManager.ChanFromSched)
 
 	for _, ts := range nTS {
 		// Create Tickers based on TestSuites frequency
@@ -196,8 +220,8 @@ func (v *Vigie) createTickerPools(nTS map[uint64]*teststruct.TestSuite) *ticker.
 				// Create/Add a new TickerPool (TP)
 				freq := tstp2.ProbeWrap.Frequency
 
-				// Create TP if needed
-				if !TPMngr.IsTickerPool(freq) {
+				// Create TP if needed, the cron steps have their own pool
+				if !tstp2.Schedule.IsCron() && !TPMngr.IsTickerPool(freq) {
 					// if does not exists => create new tickerpool
 					err := TPMngr.AddTickerPool(freq)
 					if err != nil {
@@ -0,0 +1,105 @@
+package vigie
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vincoll/vigie/pkg/alertmanager"
+	"github.com/vincoll/vigie/pkg/store"
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+// InitStore loads the state saved by a previous run, then saves the state
+// of the TestSteps at the interval of the store.
+func (v *Vigie) InitStore(conf store.ConfStore) error {
+
+	if !conf.Enable {
+		return nil
+	}
+
+	st, err := store.NewStore(conf)
+	if err != nil {
+		return err
+	}
+	v.Store = st
+
+	go v.saveStateLoop()
+	return nil
+}
+
+// restoreStates sets the state saved on the TestSteps not run yet, then updates
+// their parents. v.mu must be held and it must run in the state manager.
+func (v *Vigie) restoreStates() {
+
+	if v.Store == nil {
+		return
+	}
+
+	var restored []teststruct.Task
+	for _, ts := range v.TestSuites {
+		tsRestored := false
+		for _, tc := range ts.TestCases {
+			tcRestored := false
+			for _, tStep := range tc.TestSteps {
+				if saved, found := v.Store.Get(tStep.ID); found && tStep.RestoreState(saved) {
+					restored = append(restored, teststruct.Task{TestSuite: ts, TestCase: tc, TestStep: tStep})
+					tcRestored = true
+				}
+			}
+			if tcRestored {
+				tc.UpdateStatus()
+				tsRestored = true
+			}
+		}
+		if tsRestored {
+			ts.UpdateStatus()
+		}
+	}
+
+	// The failing TestSteps are alerted again by the reminders, once their parents are updated
+	if alertmanager.AM.IsEnabled() {
+		for _, task := range restored {
+			_ = alertmanager.AM.RestoreAlert(task)
+		}
+	}
+
+	if len(restored) > 0 {
+		utils.Log.WithFields(log.Fields{
+			"package": "vigie",
+		}).Infof("State of %d TestSteps restored", len(restored))
+	}
+}
+
+// saveState records the state of every TestStep into the store, then saves it.
+func (v *Vigie) saveState() error {
+
+	v.mu.RLock()
+	for _, ts := range v.TestSuites {
+		for _, tc := range ts.TestCases {
+			for _, tStep := range tc.TestSteps {
+				if st, ok := tStep.State(); ok {
+					v.Store.Put(tStep.ID, st)
+				}
+			}
+		}
+	}
+	v.mu.RUnlock()
+
+	return v.Store.Save()
+}
+
+func (v *Vigie) saveStateLoop() {
+
+	tick := time.NewTicker(v.Store.Interval)
+	defer tick.Stop()
+
+	for range tick.C {
+		if err := v.saveState(); err != nil {
+			utils.Log.WithFields(log.Fields{
+				"package": "vigie",
+			}).Errorf("State of the TestSteps not saved: %s", err)
+		}
+	}
+}
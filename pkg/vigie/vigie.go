@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"github.com/vincoll/vigie/pkg/ha"
 	"github.com/vincoll/vigie/pkg/load"
+	"github.com/vincoll/vigie/pkg/process"
 	"github.com/vincoll/vigie/pkg/scheduler"
+	"github.com/vincoll/vigie/pkg/state"
+	"github.com/vincoll/vigie/pkg/store"
 	"github.com/vincoll/vigie/pkg/tsdb"
 	"github.com/vincoll/vigie/pkg/utils"
 
@@ -28,6 +31,8 @@ type Vigie struct {
 	Scheduler         *scheduler.Scheduler
 	TsdbManager       *tsdb.Manager
 	TickerPoolManager *ticker.TickerPoolManager
+	Store             *store.Store   // State of the TestSteps across restarts, nil if disabled
+	State             *state.Manager // Single writer of the TestSuites, the API reads its snapshots
 	incomingTests     chan map[uint64]*teststruct.TestSuite
 }
 
@@ -36,13 +41,15 @@ func NewVigie() (*Vigie, error) {
 
 	// Chans
 	chanToScheduler := make(chan teststruct.Task)
+	// Results of the runs, read by the tickers to follow the failing steps
+	chanFromScheduler := make(chan teststruct.Task, 100)
 	// Insert Chan Before (PoC) for now
 	chanImportMgr := make(chan map[uint64]*teststruct.TestSuite)
 
 	v := &Vigie{
 		TestSuites:        map[uint64]*teststruct.TestSuite{},
-		TickerPoolManager: ticker.NewTickerPoolManager(chanToScheduler),
-		Scheduler:         scheduler.NewScheduler(chanToScheduler, 999),
+		TickerPoolManager: ticker.NewTickerPoolManager(chanToScheduler, chanFromScheduler),
+		State:             state.NewManager(),
 		incomingTests:     chanImportMgr,
 		Status:            "NotReady",
 	}
@@ -55,11 +62,28 @@ func NewVigie() (*Vigie, error) {
 	return v, nil
 }
 
+// InitScheduler starts the workers running the tasks sent by the tickers.
+func (v *Vigie) InitScheduler(conf scheduler.ConfScheduler) error {
+
+	sched, err := scheduler.NewScheduler(v.TickerPoolManager.ChanToSched, v.TickerPoolManager.ChanFromSched, v.State, conf)
+	if err != nil {
+		return err
+	}
+	v.Scheduler = sched
+	return nil
+}
+
 func (v *Vigie) GracefulShutdown() {
 
+	// The retries waiting would hold the workers
+	process.Stop()
 	v.ImportManager.GracefulShutdown()
 	v.ConsulClient.GracefulShutdown()
 
+	if v.Store != nil {
+		_ = v.saveState()
+	}
+
 }
 
 func (v *Vigie) Health() (status string) {
@@ -15,6 +15,7 @@ import (
 
 type apiVigie struct {
 	vigie *vigie.Vigie
+	runs  *runManager
 }
 
 func InitWebAPI(confWAPI ConfWebAPI, vigieInstance *vigie.Vigie) error {
@@ -23,6 +24,7 @@ func InitWebAPI(confWAPI ConfWebAPI, vigieInstance *vigie.Vigie) error {
 
 		api := apiVigie{
 			vigie: vigieInstance,
+			runs:  newRunManager(),
 		}
 
 		go api.Run(confWAPI)
@@ -43,6 +45,18 @@ func (api *apiVigie) Run(confWAPI ConfWebAPI) {
 	// Add Vigie API endpoints
 	api.addVigieAPI(router)
 
+	// Add maintenance and silences endpoints
+	api.addMaintenanceAPI(router)
+
+	// Add on-demand runs endpoints
+	api.addRunAPI(router)
+
+	// Add pause and resume endpoints
+	api.addPauseAPI(router)
+
+	// Add history of the TestSteps endpoints
+	api.addHistoryAPI(router)
+
 	// Load and Expose pprof addProfiling
 	cors := handlers.CORS(
 		handlers.AllowedHeaders([]string{"content-type"}),
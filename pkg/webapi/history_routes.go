@@ -0,0 +1,57 @@
+package webapi
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (api *apiVigie) addHistoryAPI(router *mux.Router) {
+
+	router.HandleFunc("/api/history/{uid}", api.getHistory).Methods("GET") // GET /api/history/6-87
+}
+
+// stepHistory is the history of a TestStep kept by the state store.
+type stepHistory struct {
+	UID      string         `json:"uid"`
+	TestStep string         `json:"teststep"`
+	Runs     []historyPoint `json:"runs"`
+}
+
+type historyPoint struct {
+	Time   time.Time `json:"time"`
+	Status string    `json:"status"`
+}
+
+// getHistory returns the runs kept of the TestSteps of a TestSuite, a TestCase or a TestStep.
+func (api *apiVigie) getHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if api.vigie.Store == nil {
+		http.Error(w, "the state store is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	tasks, err := api.vigie.TasksByUID(mux.Vars(r)["uid"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	histories := make([]stepHistory, 0, len(tasks))
+	for _, task := range tasks {
+		points := api.vigie.Store.History(task.TestStep.ID)
+		sh := stepHistory{
+			UID:      fmt.Sprintf("%d-%d-%d", task.TestSuite.ID, task.TestCase.ID, task.TestStep.ID),
+			TestStep: task.TestStep.Name,
+			Runs:     make([]historyPoint, 0, len(points)),
+		}
+		for _, p := range points {
+			sh.Runs = append(sh.Runs, historyPoint{Time: p.Time, Status: p.Status.String()})
+		}
+		histories = append(histories, sh)
+	}
+	writeJSON(w, histories)
+}
@@ -0,0 +1,118 @@
+package webapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vincoll/vigie/pkg/maintenance"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+func (api *apiVigie) addMaintenanceAPI(router *mux.Router) {
+
+	router.HandleFunc("/api/maintenance", api.getMaintenance).Methods("GET")
+
+	// SILENCES
+	router.HandleFunc("/api/silences", api.getSilences).Methods("GET")
+	router.HandleFunc("/api/silences", api.postSilence).Methods("POST")
+	router.HandleFunc("/api/silences/{id}", api.deleteSilence).Methods("DELETE")
+}
+
+// silenceRequest is an ad-hoc silence, ending at end or after its duration.
+type silenceRequest struct {
+	Matchers  map[string]string `json:"matchers"`
+	Start     time.Time         `json:"start"`
+	End       time.Time         `json:"end"`
+	Duration  string            `json:"duration"`
+	Comment   string            `json:"comment"`
+	CreatedBy string            `json:"createdby"`
+}
+
+// getMaintenance returns the global maintenance windows and the silences.
+func (api *apiVigie) getMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	writeJSON(w, struct {
+		Windows  []maintenance.Window  `json:"windows"`
+		Silences []maintenance.Silence `json:"silences"`
+	}{
+		Windows:  maintenance.Mgr.Windows(),
+		Silences: maintenance.Mgr.Silences(),
+	})
+}
+
+func (api *apiVigie) getSilences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	writeJSON(w, maintenance.Mgr.Silences())
+}
+
+// postSilence adds a silence: {"matchers": {"testsuite": "billing"}, "duration": "2h", "comment": "migration"}
+func (api *apiVigie) postSilence(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req silenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid silence: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	s := maintenance.Silence{
+		Matchers:  req.Matchers,
+		Start:     req.Start,
+		End:       req.End,
+		Comment:   req.Comment,
+		CreatedBy: req.CreatedBy,
+	}
+
+	if req.Duration != "" {
+		if !req.End.IsZero() {
+			http.Error(w, "a silence has an end or a duration, not both", http.StatusBadRequest)
+			return
+		}
+		dur, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid duration: %s", err), http.StatusBadRequest)
+			return
+		}
+		start := req.Start
+		if start.IsZero() {
+			start = time.Now()
+		}
+		s.Start = start
+		s.End = start.Add(dur)
+	}
+
+	s, err := maintenance.Mgr.AddSilence(s)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"package": "webapi", "silence": s.ID, "matchers": s.Matchers, "end": s.End,
+	}).Infof("Silence added by %q: %s", s.CreatedBy, s.Comment)
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, s)
+}
+
+func (api *apiVigie) deleteSilence(w http.ResponseWriter, r *http.Request) {
+
+	id := mux.Vars(r)["id"]
+	if err := maintenance.Mgr.ExpireSilence(id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"package": "webapi", "silence": id,
+	}).Info("Silence expired")
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+package webapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vincoll/vigie/pkg/pause"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+func (api *apiVigie) addPauseAPI(router *mux.Router) {
+
+	router.HandleFunc("/api/pauses", api.getPauses).Methods("GET")
+	router.HandleFunc("/api/pause/{uid}", api.postPause).Methods("POST") // POST /api/pause/6-87
+	router.HandleFunc("/api/pause/{uid}", api.deletePause).Methods("DELETE")
+}
+
+// pauseRequest pauses a UID until resumed, or until expire or after its duration.
+type pauseRequest struct {
+	Reason   string    `json:"reason"`
+	PausedBy string    `json:"pausedby"`
+	Expire   time.Time `json:"expire"`
+	Duration string    `json:"duration"`
+}
+
+func (api *apiVigie) getPauses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	writeJSON(w, pause.Mgr.Pauses())
+}
+
+// postPause pauses the TestSteps of a TestSuite, a TestCase or a TestStep:
+// {"pausedby": "jdoe", "reason": "migration", "duration": "2h"}
+func (api *apiVigie) postPause(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	uID := mux.Vars(r)["uid"]
+	if _, err := api.vigie.TasksByUID(uID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// The body is optional: a pause without reason nor expiry
+	var req pauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, fmt.Sprintf("invalid pause: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	p := pause.Pause{UID: uID, Reason: req.Reason, PausedBy: req.PausedBy, Expire: req.Expire}
+
+	if req.Duration != "" {
+		if !req.Expire.IsZero() {
+			http.Error(w, "a pause has an expire or a duration, not both", http.StatusBadRequest)
+			return
+		}
+		dur, err := time.ParseDuration(req.Duration)
+		if err != nil || dur <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration %q", req.Duration), http.StatusBadRequest)
+			return
+		}
+		p.Expire = time.Now().Add(dur)
+	}
+
+	p, err := pause.Mgr.Add(p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	changed := api.vigie.ApplyPauses()
+
+	utils.Log.WithFields(logrus.Fields{
+		"package": "webapi", "uid": uID, "expire": p.Expire,
+	}).Infof("Paused by %q: %s (%d TestSteps paused)", p.PausedBy, p.Reason, changed)
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, p)
+}
+
+func (api *apiVigie) deletePause(w http.ResponseWriter, r *http.Request) {
+
+	uID := mux.Vars(r)["uid"]
+	if err := pause.Mgr.Resume(uID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	changed := api.vigie.ApplyPauses()
+
+	utils.Log.WithFields(logrus.Fields{
+		"package": "webapi", "uid": uID,
+	}).Infof("Resumed (%d TestSteps resumed)", changed)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,242 @@
+package webapi
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/utils"
+)
+
+const (
+	runMinInterval = 10 * time.Second // between two on-demand runs of a TestStep
+	runDefaultWait = 30 * time.Second // answer of POST /api/run, before returning the job
+	runMaxWait     = 5 * time.Minute
+	runJobTTL      = time.Hour // the jobs are kept for GET /api/run/jobs/{id}
+)
+
+// Status of an on-demand run of a TestStep
+const (
+	runPending     = "pending"
+	runDone        = "done"
+	runRateLimited = "rate_limited"
+)
+
+func (api *apiVigie) addRunAPI(router *mux.Router) {
+
+	router.HandleFunc("/api/run/{uid}", api.postRun).Methods("POST") // POST /api/run/6-87-230?wait=10s
+	router.HandleFunc("/api/run/jobs/{id}", api.getRunJob).Methods("GET")
+}
+
+// runManager keeps the on-demand runs and rate-limits them per TestStep.
+type runManager struct {
+	mu      sync.Mutex
+	jobs    map[string]*runJob
+	lastRun map[uint64]time.Time // last on-demand run of each TestStep
+}
+
+func newRunManager() *runManager {
+	return &runManager{jobs: map[string]*runJob{}, lastRun: map[uint64]time.Time{}}
+}
+
+// runJob is the on-demand run of the TestSteps of a UID.
+type runJob struct {
+	mu      sync.Mutex
+	ID      string     `json:"id"`
+	UID     string     `json:"uid"`
+	Created time.Time  `json:"created"`
+	Done    bool       `json:"done"`
+	Runs    []*stepRun `json:"runs"`
+	pending int
+	done    chan struct{}
+}
+
+// stepRun is the on-demand run of a TestStep, its result is the one written by the scheduler.
+type stepRun struct {
+	UID        string                  `json:"uid"`
+	TestStep   string                  `json:"teststep"`
+	Status     string                  `json:"status"`
+	StepStatus string                  `json:"stepstatus,omitempty"`
+	Result     *teststruct.VigieResult `json:"result,omitempty"`
+}
+
+func (j *runJob) MarshalJSON() ([]byte, error) {
+
+	type jobJSON runJob
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return json.Marshal((*jobJSON)(j))
+}
+
+// finish records the result of a run, the job is done once every run is.
+func (j *runJob) finish(sr *stepRun, res *teststruct.VigieResult) {
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	sr.Status, sr.Result = runDone, res
+	if res != nil {
+		sr.StepStatus = res.Status.String()
+	}
+	j.pending--
+	if j.pending == 0 {
+		j.Done = true
+		close(j.done)
+	}
+}
+
+// allow tells if the TestStep can be run on demand, and records the run.
+func (rm *runManager) allow(id uint64, now time.Time) (bool, time.Duration) {
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if last, present := rm.lastRun[id]; present && now.Sub(last) < runMinInterval {
+		return false, runMinInterval - now.Sub(last)
+	}
+	rm.lastRun[id] = now
+	return true, 0
+}
+
+// add keeps the job, the jobs expired are removed.
+func (rm *runManager) add(job *runJob) {
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	for id, j := range rm.jobs {
+		if time.Since(j.Created) > runJobTTL {
+			delete(rm.jobs, id)
+		}
+	}
+	for id, last := range rm.lastRun {
+		if time.Since(last) > runMinInterval {
+			delete(rm.lastRun, id)
+		}
+	}
+	rm.jobs[job.ID] = job
+}
+
+func (rm *runManager) get(id string) (*runJob, bool) {
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	job, present := rm.jobs[id]
+	return job, present
+}
+
+// postRun runs the TestSteps of a TestSuite, a TestCase or a TestStep ahead of their ticks.
+// The state and the alerting are updated like a tick. The job is returned with the results
+// once the runs are done, or when wait is over: its id gives the results later.
+func (api *apiVigie) postRun(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if api.vigie.Scheduler == nil {
+		http.Error(w, "the scheduler is not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	wait := runDefaultWait
+	if raw := r.URL.Query().Get("wait"); raw != "" {
+		dur, err := time.ParseDuration(raw)
+		if err != nil || dur < 0 || dur > runMaxWait {
+			http.Error(w, fmt.Sprintf("invalid wait %q: a duration between 0s and %s", raw, runMaxWait), http.StatusBadRequest)
+			return
+		}
+		wait = dur
+	}
+
+	uID := mux.Vars(r)["uid"]
+	tasks, err := api.vigie.TasksByUID(uID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	job := &runJob{ID: id, UID: uID, Created: time.Now(), Runs: make([]*stepRun, 0, len(tasks)), done: make(chan struct{})}
+
+	// The TestSteps run on demand recently are not run again
+	var retryAfter time.Duration
+	for _, task := range tasks {
+		sr := &stepRun{
+			UID:      fmt.Sprintf("%d-%d-%d", task.TestSuite.ID, task.TestCase.ID, task.TestStep.ID),
+			TestStep: task.TestStep.Name,
+			Status:   runPending,
+		}
+		job.Runs = append(job.Runs, sr)
+
+		if ok, after := api.runs.allow(task.TestStep.ID, job.Created); !ok {
+			sr.Status = runRateLimited
+			retryAfter = after
+			continue
+		}
+		job.pending++
+	}
+
+	if job.pending == 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		writeJSON(w, job)
+		return
+	}
+
+	api.runs.add(job)
+	count := job.pending
+	for i, task := range tasks {
+		if job.Runs[i].Status != runPending {
+			continue
+		}
+		go func(task teststruct.Task, sr *stepRun) {
+			job.finish(sr, <-api.vigie.Scheduler.RunNow(task))
+		}(task, job.Runs[i])
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"package": "webapi", "uid": uID, "job": job.ID,
+	}).Infof("On-demand run of %d TestSteps", count)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-job.done:
+		writeJSON(w, job)
+	case <-timer.C:
+		w.WriteHeader(http.StatusAccepted)
+		writeJSON(w, job)
+	case <-r.Context().Done():
+	}
+}
+
+func (api *apiVigie) getRunJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	job, present := api.runs.get(mux.Vars(r)["id"])
+	if !present {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, job)
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", b), nil
+}
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
-	"github.com/vincoll/vigie/pkg/teststruct"
+	"github.com/vincoll/vigie/pkg/secret"
 	"net/http"
 	"strconv"
 )
@@ -33,28 +33,29 @@ func (api *apiVigie) addVigieAPI(router *mux.Router) {
 	router.HandleFunc("/api/id/{idTS}/{idTC}", api.getTestCase).Methods("GET")
 	router.HandleFunc("/api/id/{idTS}", api.getTestSuite).Methods("GET")
 
+	// IMPORT
+	router.HandleFunc("/api/import/errors", api.getImportErrors).Methods("GET")
+
 }
 
 func (api *apiVigie) getAllTestSuites(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	var tsList = make([]teststruct.TSDescribe, 0, len(api.vigie.TestSuites))
-	for _, tSuite := range api.vigie.TestSuites {
-		tsList = append(tsList, tSuite.ToJSON())
-	}
-	_ = json.NewEncoder(w).Encode(tsList)
+	writeJSON(w, api.vigie.GetAllTestSuites())
 }
 
-func (api *apiVigie) getTestSuitesList(w http.ResponseWriter, r *http.Request) {
+// getImportErrors returns the files which failed to be imported during the last reload.
+func (api *apiVigie) getImportErrors(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	var tsListHeader = make([]teststruct.TSHeader, 0, len(api.vigie.TestSuites))
+	writeJSON(w, api.vigie.ImportManager.FileErrors())
+}
 
-	for _, tSuite := range api.vigie.TestSuites {
-		tsListHeader = append(tsListHeader, tSuite.ToHeader())
-	}
+func (api *apiVigie) getTestSuitesList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	_ = json.NewEncoder(w).Encode(tsListHeader)
+	tsListHeader, _ := api.vigie.GetTestSuitesList()
+	writeJSON(w, tsListHeader)
 }
 
 func (api *apiVigie) getTestSuite(w http.ResponseWriter, r *http.Request) {
@@ -74,7 +75,7 @@ func (api *apiVigie) getTestSuite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(ts)
+	writeJSON(w, ts)
 }
 
 func (api *apiVigie) getTestCase(w http.ResponseWriter, r *http.Request) {
@@ -109,7 +110,7 @@ func (api *apiVigie) getTestCase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(ts)
+	writeJSON(w, ts)
 }
 
 func (api *apiVigie) getTestCaseList(w http.ResponseWriter, r *http.Request) {
@@ -136,7 +137,7 @@ func (api *apiVigie) getTestCaseList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(tcListHeader)
+	writeJSON(w, tcListHeader)
 }
 
 func (api *apiVigie) getTestStep(w http.ResponseWriter, r *http.Request) {
@@ -179,7 +180,7 @@ func (api *apiVigie) getTestStep(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(ts)
+	writeJSON(w, ts)
 }
 
 func (api *apiVigie) getTestbyUID(w http.ResponseWriter, r *http.Request) {
@@ -199,5 +200,16 @@ func (api *apiVigie) getTestbyUID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(txResult)
+	writeJSON(w, txResult)
+}
+
+// writeJSON writes a JSON response, the values of the secrets are redacted.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+
+	dat, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(secret.RedactBytes(append(dat, '\n')))
 }